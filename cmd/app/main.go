@@ -1,16 +1,35 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"flag"
+	"hashcowuwu/lychee/internal/alert"
+	"hashcowuwu/lychee/internal/ban"
 	"hashcowuwu/lychee/internal/config"
+	"hashcowuwu/lychee/internal/executor"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/metrics"
 	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/monitor/auth"
+	"hashcowuwu/lychee/internal/monitor/dns"
+	"hashcowuwu/lychee/internal/monitor/heartbeat"
+	"hashcowuwu/lychee/internal/monitor/host"
+	"hashcowuwu/lychee/internal/monitor/httpprobe"
+	"hashcowuwu/lychee/internal/monitor/integrity"
 	"hashcowuwu/lychee/internal/monitor/journal"
+	"hashcowuwu/lychee/internal/monitor/logfile"
+	"hashcowuwu/lychee/internal/monitor/port"
+	"hashcowuwu/lychee/internal/monitor/process"
+	"hashcowuwu/lychee/internal/monitor/script"
 	"hashcowuwu/lychee/internal/monitor/systemd"
-	"hashcowuwu/lychee/internal/notifier"
+	"hashcowuwu/lychee/internal/monitor/tlscert"
 	"hashcowuwu/lychee/internal/notifier/lark"
+	"hashcowuwu/lychee/internal/server"
+	"hashcowuwu/lychee/internal/state"
 	"log"
-	"strings"
+	"os"
+	"strconv"
 	"time"
 )
 
@@ -19,69 +38,304 @@ func main() {
 	flag.Parse()
 	cfg, err := config.Load(*configPath)
 	if err != nil {
-		log.Fatalf("无法加载配置: %v", err)
+		log.Fatal(i18n.T("main.config_load_failed", err))
 	}
-	log.Printf("加载的 checkInterval: %d", cfg.CheckInterval)
+	log.Print(i18n.T("main.language", i18n.Current()))
+	log.Print(i18n.T("main.check_interval_loaded", cfg.CheckInterval))
 
-	var notif notifier.Notifier = lark.New(cfg.Lark.WebhookURLs)
+	receivers, err := buildReceivers(cfg)
+	if err != nil {
+		log.Fatal(i18n.T("main.config_load_failed", err))
+	}
+	if len(receivers) == 0 {
+		log.Println(i18n.T("main.no_receivers"))
+	}
+	// 所有需要运行系统命令的监控器共用同一个执行器
+	var exec executor.Executor = executor.System{}
 	var monitors []monitor.Monitor
 	for _, serviceName := range cfg.Systemd.Services {
-		monitors = append(monitors, systemd.New(serviceName))
+		if systemd.IsPattern(serviceName) {
+			m, err := systemd.NewPattern(serviceName, exec)
+			if err != nil {
+				log.Print(i18n.T("main.monitor_create_failed", serviceName, err))
+				continue
+			}
+			monitors = append(monitors, m)
+			continue
+		}
+		monitors = append(monitors, systemd.New(serviceName, exec))
+	}
+	if cfg.Systemd.Failed.Enabled {
+		m, err := systemd.NewFailed(cfg.Systemd.Failed, exec)
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", "systemd-failed", err))
+		} else {
+			log.Print(i18n.T("main.monitor_setup", m.Name()))
+			monitors = append(monitors, m)
+		}
+	}
+	if cfg.Systemd.SystemState {
+		m := systemd.NewSystemState(exec)
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
+	}
+	for _, timerCfg := range cfg.Systemd.Timers {
+		m, err := systemd.NewTimer(timerCfg, exec)
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", cmp.Or(timerCfg.Name, timerCfg.Timer, timerCfg.Service), err))
+			continue
+		}
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
 	}
 
 	for _, journalCfg := range cfg.Journal {
-		log.Printf("为服务 [%s] 设置 journal 日志监控, 关键字: %v", journalCfg.ServiceName, journalCfg.Keywords)
-		m, err := journal.New(journalCfg.ServiceName, journalCfg.Keywords)
+		source, engine := journalCfg.Source(), journalCfg.Engine()
+		log.Print(i18n.T("main.journal_setup", source, len(engine.Rules)))
+		var m monitor.Monitor
+		if journalCfg.Stream {
+			m, err = journal.NewFollower(source, engine, exec, journalCfg.BufferSize)
+		} else {
+			m, err = journal.New(source, engine, exec)
+		}
 		if err != nil {
-			log.Printf("警告: 无法为服务 [%s] 创建 journal 监控器: %v", journalCfg.ServiceName, err)
+			log.Print(i18n.T("main.journal_create_failed", source, err))
 			continue
 		}
 		monitors = append(monitors, m)
 	}
 
-	log.Println("运维监控工具启动...")
+	for i, fileCfg := range cfg.Files {
+		name := fileCfg.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		log.Print(i18n.T("main.logfile_setup", name, fileCfg.Paths))
+		m, err := logfile.New(name, fileCfg.Paths, fileCfg.Engine(), state.Path(cfg.StateDir, "logfile", name))
+		if err != nil {
+			log.Print(i18n.T("main.logfile_create_failed", name, err))
+			continue
+		}
+		monitors = append(monitors, m)
+	}
+
+	for _, httpCfg := range cfg.HTTP {
+		m, err := httpprobe.New(httpCfg)
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", cmp.Or(httpCfg.Name, httpCfg.URL), err))
+			continue
+		}
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
+	}
+
+	for i, tlsCfg := range cfg.TLS {
+		m, err := tlscert.New(tlsCfg)
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", cmp.Or(tlsCfg.Name, tlsCfg.Address, strconv.Itoa(i)), err))
+			continue
+		}
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
+	}
+
+	for _, portCfg := range cfg.Ports {
+		m, err := port.New(portCfg)
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", cmp.Or(portCfg.Name, portCfg.Address), err))
+			continue
+		}
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
+	}
+
+	for _, dnsCfg := range cfg.DNS {
+		m, err := dns.New(dnsCfg)
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", cmp.Or(dnsCfg.Name, dnsCfg.Query), err))
+			continue
+		}
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
+	}
+
+	hostMonitors, err := host.New(cfg.Host)
+	if err != nil {
+		log.Print(i18n.T("main.monitor_create_failed", "host", err))
+	}
+	for _, m := range hostMonitors {
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
+	}
+
+	for _, procCfg := range cfg.Processes {
+		m, err := process.New(procCfg)
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", cmp.Or(procCfg.Name, procCfg.Process, procCfg.Cmdline, procCfg.PIDFile, procCfg.User), err))
+			continue
+		}
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
+	}
+
+	registry := metrics.NewRegistry()
+	for _, execCfg := range cfg.Exec {
+		m, err := script.New(execCfg, registry, exec)
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", cmp.Or(execCfg.Name, execCfg.Command), err))
+			continue
+		}
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
+	}
+
+	for i, integrityCfg := range cfg.Integrity {
+		name := cmp.Or(integrityCfg.Name, strconv.Itoa(i))
+		m, err := integrity.New(integrityCfg, state.Path(cfg.StateDir, "integrity", name))
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", name, err))
+			continue
+		}
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
+	}
+
+	for _, authCfg := range cfg.Auth {
+		name := cmp.Or(authCfg.Name, "auth")
+		m, err := auth.New(authCfg, exec, state.Path(cfg.StateDir, "auth", name))
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", name, err))
+			continue
+		}
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		monitors = append(monitors, m)
+	}
+
+	// 封禁管理器也是监控器，定时检查时解除到期的封禁
+	var banner *ban.Manager
+	if cfg.Ban.Enabled {
+		banner, err = ban.New(cfg.Ban, exec, state.Path(cfg.StateDir, "ban", "autoban"))
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", "autoban", err))
+		} else {
+			log.Print(i18n.T("main.ban_enabled", banner))
+			monitors = append(monitors, banner)
+		}
+	}
+
+	var heartbeats []*heartbeat.HeartbeatMonitor
+	for _, hbCfg := range cfg.Heartbeats {
+		name := cmp.Or(hbCfg.Name, hbCfg.Token)
+		m, err := heartbeat.New(hbCfg, state.Path(cfg.StateDir, "heartbeat", name))
+		if err != nil {
+			log.Print(i18n.T("main.monitor_create_failed", name, err))
+			continue
+		}
+		log.Print(i18n.T("main.monitor_setup", m.Name()))
+		heartbeats = append(heartbeats, m)
+		monitors = append(monitors, m)
+	}
+
+	if cfg.Server.Enabled() {
+		srv := server.New(cfg.Server.Listen)
+		srv.Handle("/ping/", heartbeat.NewHandler(heartbeats))
+		srv.Handle("/metrics", registry)
+		if err := srv.Start(); err != nil {
+			log.Print(i18n.T("main.server_failed", err))
+		} else {
+			log.Print(i18n.T("main.server_started", srv.Addr()))
+		}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	tracker := alert.NewTracker(hostname)
+	dispatcher := alert.NewDispatcher(receivers, hostname, cfg.Group.GroupOptions())
+	go dispatcher.Run(context.Background())
+
+	// 串流监控器在后台持续运行，结果直接交给 dispatcher
+	for _, m := range monitors {
+		if s, ok := m.(monitor.Streamer); ok {
+			log.Print(i18n.T("main.stream_started", s.Name()))
+			go s.Stream(context.Background(), func(r monitor.Result) {
+				handleResult(s.Name(), r, tracker, dispatcher, banner)
+			})
+		}
+	}
+
+	log.Println(i18n.T("main.started"))
 	if cfg.CheckInterval <= 0 {
 		cfg.CheckInterval = 60
-		log.Printf("checkInterval 非正数，使用默认值: %ds", cfg.CheckInterval)
+		log.Print(i18n.T("main.check_interval_default", cfg.CheckInterval))
 	}
 	interval := time.Duration(cfg.CheckInterval) * time.Second
-	log.Printf("计时器间隔: %v", interval)
+	log.Print(i18n.T("main.ticker_interval", interval))
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	failedMessages := runChecks(monitors)
-	sendAggregateNotification(notif, failedMessages)
+	runChecks(monitors, tracker, dispatcher, banner)
 
 	for range ticker.C {
-		failedMessages := runChecks(monitors)
-		sendAggregateNotification(notif, failedMessages)
+		runChecks(monitors, tracker, dispatcher, banner)
 	}
 }
 
-func runChecks(monitors []monitor.Monitor) []string {
-	log.Println("开始执行新一轮监控检查...")
-	var failedMessages []string
+// buildReceivers 根据配置创建所有通知接收方
+func buildReceivers(cfg *config.Config) ([]*alert.Receiver, error) {
+	var receivers []*alert.Receiver
+	for _, rc := range cfg.AllReceivers() {
+		tmpl, err := rc.Template()
+		if err != nil {
+			return nil, err
+		}
+		oversize, err := alert.ParseOversize(rc.Oversize)
+		if err != nil {
+			return nil, err
+		}
+		receivers = append(receivers, &alert.Receiver{
+			Name:     rc.Name,
+			Notifier: lark.New(rc.WebhookURLs),
+			Template: tmpl,
+			MaxSize:  rc.MaxMessageSize,
+			Oversize: oversize,
+		})
+	}
+	return receivers, nil
+}
+
+// runChecks 执行一轮检查，把失败结果交给 dispatcher 分组发送，恢复的监控器从分组中移除
+func runChecks(monitors []monitor.Monitor, tracker *alert.Tracker, dispatcher *alert.Dispatcher, banner *ban.Manager) {
+	log.Println(i18n.T("main.check_round_start"))
+	failed := 0
 	for _, m := range monitors {
 		result := m.Check()
-		log.Printf("监控器 [%s]: 状态=%t, 消息=%s\n", m.Name(), result.Success, result.Message)
-		if !result.Success {
-			failedMessages = append(failedMessages, result.Message)
+		log.Print(i18n.T("main.check_result", m.Name(), result.Success, result.Message))
+		if handleResult(m.Name(), result, tracker, dispatcher, banner) {
+			failed++
 		}
 	}
-	if len(failedMessages) == 0 {
-		log.Println("所有服务状态正常。")
+	if failed == 0 {
+		log.Println(i18n.T("main.all_ok"))
 	}
-	return failedMessages
 }
 
-func sendAggregateNotification(notif notifier.Notifier, messages []string) {
-	if len(messages) == 0 {
-		return
+// handleResult 把一个监控结果交给 dispatcher，返回结果是否处于告警状态。
+// 启用自动封禁时，结果中报告的攻击来源会被封禁，封禁结果作为 autoban 的事件发送。
+func handleResult(name string, result monitor.Result, tracker *alert.Tracker, dispatcher *alert.Dispatcher, banner *ban.Manager) bool {
+	if banner != nil {
+		for _, ip := range result.Offenders {
+			if r, ok := banner.Ban(ip, name); ok {
+				handleResult(banner.Name(), r, tracker, dispatcher, nil)
+			}
+		}
 	}
-	fullMessage := "以下服务出现异常:\n" + strings.Join(messages, "\n")
-	log.Println("发现服务异常，准备发送聚合通知...")
-	err := notif.Notify(context.Background(), "🚨 服务异常告警", fullMessage)
-	if err != nil {
-		log.Printf("发送聚合通知失败: %v\n", err)
+	now := time.Now()
+	if a, firing := tracker.Observe(name, result, now); firing {
+		dispatcher.Add(a, now)
+		return true
 	}
+	dispatcher.Resolve(name)
+	return false
 }
@@ -0,0 +1,99 @@
+// Package alert 将监控结果转换为告警，并负责渲染和投递通知。
+package alert
+
+import (
+	"hashcowuwu/lychee/internal/monitor"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Alert 是一条正在触发的告警，也是通知模板中 .Alerts 的元素类型
+type Alert struct {
+	Host     string            // 产生告警的主机名
+	Monitor  string            // 监控器名称
+	Severity monitor.Severity  // 严重程度
+	Labels   map[string]string // 标签，至少包含 host、monitor 和 severity
+	StartsAt time.Time         // 首次失败的时间
+	Duration time.Duration     // 截至本次检查已持续的时间
+	Details  string            // 监控器给出的详细信息
+	LogLines []string          // 相关的最近日志行
+	Fields   map[string]string // 最近一条相关日志的结构化字段
+	Event    bool              // 是否为一次性事件，事件在送达后即被移除
+}
+
+// Tracker 记录每个监控器首次失败的时间，用于计算告警的开始时间和持续时长
+type Tracker struct {
+	host   string
+	mu     sync.Mutex
+	starts map[string]time.Time
+}
+
+// NewTracker 创建一个新的 Tracker
+func NewTracker(host string) *Tracker {
+	return &Tracker{
+		host:   host,
+		starts: make(map[string]time.Time),
+	}
+}
+
+// Observe 记录监控器 name 在 now 时刻的检查结果。
+// 结果失败时返回对应的告警和 true；结果成功时清除该监控器的失败记录。
+func (t *Tracker) Observe(name string, r monitor.Result, now time.Time) (Alert, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if r.Success {
+		delete(t.starts, name)
+		return Alert{}, false
+	}
+
+	// 事件没有持续时间的概念，每次都从 now 开始
+	start, ok := t.starts[name]
+	if r.Event {
+		start = now
+	} else if !ok {
+		start = now
+		t.starts[name] = start
+	}
+
+	severity := r.Severity
+	if severity == "" {
+		severity = monitor.SeverityCritical
+	}
+
+	labels := make(map[string]string, len(r.Labels)+3)
+	for k, v := range r.Labels {
+		labels[k] = v
+	}
+	labels["host"] = t.host
+	labels["monitor"] = name
+	labels["severity"] = string(severity)
+
+	return Alert{
+		Host:     t.host,
+		Monitor:  name,
+		Severity: severity,
+		Labels:   labels,
+		StartsAt: start,
+		Duration: now.Sub(start),
+		Details:  r.Message,
+		LogLines: r.LogLines,
+		Fields:   r.Fields,
+		Event:    r.Event,
+	}, true
+}
+
+// SortedLabels 返回按 key 排序的 "key=value" 列表，方便在模板中稳定输出
+func (a Alert) SortedLabels() []string {
+	keys := make([]string, 0, len(a.Labels))
+	for k := range a.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+a.Labels[k])
+	}
+	return pairs
+}
@@ -0,0 +1,241 @@
+package alert
+
+import (
+	"context"
+	"hashcowuwu/lychee/internal/i18n"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GroupAll 作为 GroupOptions.By 的唯一元素时，按全部标签分组，即每条告警单独成组
+const GroupAll = "..."
+
+// GroupOptions 控制告警的分组与发送节奏，语义与 Alertmanager 的同名配置一致
+type GroupOptions struct {
+	By             []string      // 分组使用的标签，为空时所有告警归为一组
+	Wait           time.Duration // 新分组首次发送前的等待时间，用于收集同一批相关告警
+	Interval       time.Duration // 分组中出现新告警或 severity 变化后，两次发送的最小间隔
+	RepeatInterval time.Duration // 分组内容未变化时重复提醒的间隔
+}
+
+// DefaultGroupOptions 返回默认的分组配置
+func DefaultGroupOptions() GroupOptions {
+	return GroupOptions{
+		Wait:           30 * time.Second,
+		Interval:       5 * time.Minute,
+		RepeatInterval: 4 * time.Hour,
+	}
+}
+
+// group 是一组拥有相同分组标签的告警。
+// 发送过的分组清空后仍保留 groupInterval，期间重新加入的告警 (例如持续出现的日志事件)
+// 按 groupInterval 合并发送，而不是每次都作为新分组只等待 groupWait
+type group struct {
+	key      string
+	labels   map[string]string
+	alerts   map[string]Alert // key 为告警指纹
+	created  time.Time
+	lastSent time.Time // 零值表示尚未发送过
+	dirty    bool      // 上次发送后是否出现了新告警或 severity 变化
+}
+
+// Dispatcher 按标签将告警分组，并按 GroupOptions 的节奏向所有接收方发送通知
+type Dispatcher struct {
+	opts      GroupOptions
+	host      string
+	receivers []*Receiver
+
+	mu     sync.Mutex
+	groups map[string]*group
+	seq    uint64 // 用于生成事件告警的指纹
+}
+
+// NewDispatcher 创建一个新的 Dispatcher
+func NewDispatcher(receivers []*Receiver, host string, opts GroupOptions) *Dispatcher {
+	return &Dispatcher{
+		opts:      opts,
+		host:      host,
+		receivers: receivers,
+		groups:    make(map[string]*group),
+	}
+}
+
+// Add 将一条触发中的告警加入对应分组。
+// 持续状态类告警以监控器名称为指纹，重复加入只会更新内容；事件类告警每次都是新告警。
+func (d *Dispatcher) Add(a Alert, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key, labels := d.groupKey(a)
+	g, ok := d.groups[key]
+	if !ok {
+		g = &group{key: key, labels: labels, alerts: make(map[string]Alert), created: now}
+		d.groups[key] = g
+	}
+
+	fp := a.Monitor
+	if a.Event {
+		d.seq++
+		fp += "#" + strconv.FormatUint(d.seq, 10)
+	} else {
+		// 告警换组时 (例如 severity 变化) 从旧分组中移除
+		d.removeLocked(a.Monitor, g)
+	}
+
+	// 只有新告警或 severity 变化才需要尽快发送；磁盘使用率、延迟等数值变化只更新内容，
+	// 否则消息中带数字的监控器会每隔 groupInterval 重复提醒
+	if old, ok := g.alerts[fp]; !ok || old.Severity != a.Severity {
+		g.dirty = true
+	}
+	g.alerts[fp] = a
+}
+
+// Resolve 移除监控器 name 对应的持续状态类告警
+func (d *Dispatcher) Resolve(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.removeLocked(name, nil)
+}
+
+// removeLocked 从除 except 之外的所有分组中移除指纹为 name 的告警，except 为 nil 时从所有分组中移除。
+// 不分组时所有告警的分组 key 都是空字符串，所以这里比较分组本身而不是 key。
+// 清空后的分组如果发送过，由 due 在 groupInterval 之后删除。
+func (d *Dispatcher) removeLocked(name string, except *group) {
+	for key, g := range d.groups {
+		if g == except {
+			continue
+		}
+		if _, ok := g.alerts[name]; ok {
+			delete(g.alerts, name)
+			if len(g.alerts) == 0 && g.lastSent.IsZero() {
+				delete(d.groups, key)
+			}
+		}
+	}
+}
+
+// Run 周期性地检查各分组是否到了发送时间，直到 ctx 结束
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.Flush(ctx, now)
+		}
+	}
+}
+
+// Flush 发送所有在 now 时刻到期的分组通知
+func (d *Dispatcher) Flush(ctx context.Context, now time.Time) {
+	for _, data := range d.due(now) {
+		log.Print(i18n.T("alert.group_sending", groupName(data.GroupLabels), len(data.Alerts)))
+		for _, r := range d.receivers {
+			if err := r.Send(ctx, data); err != nil {
+				log.Print(i18n.T("alert.notify_failed", r.Name, err))
+			}
+		}
+	}
+}
+
+// due 取出所有到期分组的通知数据，并更新分组的发送状态
+func (d *Dispatcher) due(now time.Time) []Data {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var out []Data
+	for key, g := range d.groups {
+		if len(g.alerts) == 0 {
+			if g.lastSent.IsZero() || now.Sub(g.lastSent) >= d.opts.Interval {
+				delete(d.groups, key)
+			}
+			continue
+		}
+		if !g.ready(now, d.opts) {
+			continue
+		}
+		data := Data{Host: d.host, GroupLabels: g.labels}
+		fps := make([]string, 0, len(g.alerts))
+		for fp := range g.alerts {
+			fps = append(fps, fp)
+		}
+		sort.Slice(fps, func(i, j int) bool {
+			ai, aj := g.alerts[fps[i]], g.alerts[fps[j]]
+			if !ai.StartsAt.Equal(aj.StartsAt) {
+				return ai.StartsAt.Before(aj.StartsAt)
+			}
+			return fps[i] < fps[j]
+		})
+		for _, fp := range fps {
+			a := g.alerts[fp]
+			if !a.Event {
+				a.Duration = now.Sub(a.StartsAt)
+			}
+			data.Alerts = append(data.Alerts, a)
+			// 事件送达一次即可
+			if a.Event {
+				delete(g.alerts, fp)
+			}
+		}
+		g.lastSent = now
+		g.dirty = false
+		out = append(out, data)
+	}
+	return out
+}
+
+// ready 判断分组在 now 时刻是否应当发送
+func (g *group) ready(now time.Time, opts GroupOptions) bool {
+	if len(g.alerts) == 0 {
+		return false
+	}
+	if g.lastSent.IsZero() {
+		return now.Sub(g.created) >= opts.Wait
+	}
+	if g.dirty {
+		return now.Sub(g.lastSent) >= opts.Interval
+	}
+	return now.Sub(g.lastSent) >= opts.RepeatInterval
+}
+
+// groupKey 根据分组标签计算告警所属分组的 key 和标签
+func (d *Dispatcher) groupKey(a Alert) (string, map[string]string) {
+	by := d.opts.By
+	if len(by) == 1 && by[0] == GroupAll {
+		by = make([]string, 0, len(a.Labels))
+		for k := range a.Labels {
+			by = append(by, k)
+		}
+		sort.Strings(by)
+	}
+	labels := make(map[string]string, len(by))
+	parts := make([]string, 0, len(by))
+	for _, name := range by {
+		labels[name] = a.Labels[name]
+		parts = append(parts, name+"="+a.Labels[name])
+	}
+	return strings.Join(parts, ","), labels
+}
+
+// groupName 返回用于日志的分组名称
+func groupName(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "{}"
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+labels[k])
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
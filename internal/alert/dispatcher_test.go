@@ -0,0 +1,124 @@
+package alert
+
+import (
+	"hashcowuwu/lychee/internal/monitor"
+	"testing"
+	"time"
+)
+
+func TestDispatcherResolve(t *testing.T) {
+	opts := GroupOptions{Wait: time.Second, Interval: time.Second, RepeatInterval: time.Hour}
+	tests := []struct {
+		name string
+		by   []string
+	}{
+		{"no grouping", nil},
+		{"by type", []string{"type"}},
+		{"by all labels", []string{GroupAll}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts.By = tt.by
+			d := NewDispatcher(nil, "host", opts)
+			tracker := NewTracker("host")
+			now := time.Now()
+			observe := func(success bool) {
+				now = now.Add(time.Second)
+				r := monitor.Result{Success: success, Message: "down", Labels: map[string]string{"type": "systemd"}}
+				if a, firing := tracker.Observe("m", r, now); firing {
+					d.Add(a, now)
+				} else {
+					d.Resolve("m")
+				}
+			}
+
+			observe(false)
+			if got := len(d.due(now.Add(time.Second))); got != 1 {
+				t.Fatalf("first failure: %d notifications, want 1", got)
+			}
+			observe(true)
+			for _, g := range d.groups {
+				if len(g.alerts) != 0 {
+					t.Fatalf("after recovery: %d alerts left in group %q", len(g.alerts), g.key)
+				}
+			}
+			// 恢复后再次失败是新的告警，等待 groupWait 后发送
+			observe(false)
+			if got := len(d.due(now.Add(time.Second))); got != 1 {
+				t.Fatalf("second failure: %d notifications, want 1", got)
+			}
+		})
+	}
+}
+
+func TestDispatcherDetailsChange(t *testing.T) {
+	d := NewDispatcher(nil, "host", GroupOptions{Wait: time.Second, Interval: time.Minute, RepeatInterval: time.Hour})
+	now := time.Now()
+	add := func(details string, severity monitor.Severity) {
+		d.Add(Alert{Monitor: "disk", Details: details, Severity: severity, StartsAt: now}, now)
+	}
+
+	add("disk / is 91% full", monitor.SeverityWarning)
+	now = now.Add(time.Second)
+	if got := len(d.due(now)); got != 1 {
+		t.Fatalf("first notification: %d, want 1", got)
+	}
+	// 数值变化不会在 groupInterval 后重新提醒，但内容会更新
+	add("disk / is 92% full", monitor.SeverityWarning)
+	now = now.Add(2 * time.Minute)
+	if got := len(d.due(now)); got != 0 {
+		t.Fatalf("details changed: %d notifications, want 0", got)
+	}
+	if got := d.groups[""].alerts["disk"].Details; got != "disk / is 92% full" {
+		t.Errorf("Details = %q, want the latest", got)
+	}
+	// severity 变化在 groupInterval 后发送
+	add("disk / is 97% full", monitor.SeverityCritical)
+	now = now.Add(2 * time.Minute)
+	if got := len(d.due(now)); got != 1 {
+		t.Fatalf("severity changed: %d notifications, want 1", got)
+	}
+}
+
+func TestDispatcherEvents(t *testing.T) {
+	d := NewDispatcher(nil, "host", GroupOptions{Wait: 30 * time.Second, Interval: 5 * time.Minute, RepeatInterval: time.Hour})
+	start := time.Now()
+	event := func(at time.Duration) {
+		now := start.Add(at)
+		d.Add(Alert{Monitor: "journal", Event: true, StartsAt: now}, now)
+	}
+	tests := []struct {
+		name   string
+		event  bool
+		at     time.Duration
+		alerts int // 在 at 时刻发送的告警数，0 表示不发送
+		groups int // 发送后保留的分组数
+	}{
+		{"first event waits groupWait", true, 0, 0, 1},
+		{"first notification", false, 30 * time.Second, 1, 1},
+		{"burst within groupInterval", true, 40 * time.Second, 0, 1},
+		{"held back", false, time.Minute, 0, 1},
+		{"more events", true, 2 * time.Minute, 0, 1},
+		{"batched after groupInterval", false, 5*time.Minute + 30*time.Second, 2, 1},
+		{"nothing new", false, 6 * time.Minute, 0, 1},
+		{"empty group expires", false, 10*time.Minute + 30*time.Second, 0, 0},
+		{"new burst waits groupWait again", true, 11 * time.Minute, 0, 1},
+		{"new burst sent", false, 11*time.Minute + 30*time.Second, 1, 1},
+	}
+	for _, tt := range tests {
+		if tt.event {
+			event(tt.at)
+		}
+		out := d.due(start.Add(tt.at))
+		got := 0
+		for _, data := range out {
+			got += len(data.Alerts)
+		}
+		if len(out) > 1 || got != tt.alerts {
+			t.Errorf("%s: %d notifications with %d alerts, want %d alerts", tt.name, len(out), got, tt.alerts)
+		}
+		if len(d.groups) != tt.groups {
+			t.Errorf("%s: %d groups, want %d", tt.name, len(d.groups), tt.groups)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package alert
+
+import (
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"strings"
+	"unicode/utf8"
+)
+
+// Oversize 决定渲染后的消息超过通知器大小上限时如何处理
+type Oversize string
+
+const (
+	// OversizeSummarize 只保留放得下的前几行，并注明还有多少行未显示
+	OversizeSummarize Oversize = "summarize"
+	// OversizeSplit 按行拆分成多条带序号的消息依次发送
+	OversizeSplit Oversize = "split"
+)
+
+// maxParts 是拆分时最多发送的消息条数，剩余内容在最后一条中摘要
+const maxParts = 10
+
+// ParseOversize 解析配置中的处理方式，空字符串表示 OversizeSummarize
+func ParseOversize(s string) (Oversize, error) {
+	switch Oversize(s) {
+	case "", OversizeSummarize:
+		return OversizeSummarize, nil
+	case OversizeSplit:
+		return OversizeSplit, nil
+	}
+	return "", i18n.Errorf("alert.oversize_unknown", s)
+}
+
+// message 是一条待发送的通知
+type message struct {
+	subject string
+	body    string
+}
+
+// fit 让消息的标题加正文不超过 limit 字节，limit <= 0 表示不限制。
+// 通知器通常以 JSON 发送消息，所以按 JSON 转义后的长度计算 (见 jsonLen)。
+func fit(subject, body string, limit int, mode Oversize, lang i18n.Language) []message {
+	if limit <= 0 || jsonLen(subject)+jsonLen(body) <= limit {
+		return []message{{subject, body}}
+	}
+	if jsonLen(subject) > limit/2 {
+		subject = cut(subject, limit/2)
+	}
+	if mode != OversizeSplit {
+		return []message{{subject, summarize(strings.Split(body, "\n"), limit-jsonLen(subject), lang)}}
+	}
+
+	// 预留 " (10/10)" 这样的序号
+	suffix := len(fmt.Sprintf(" (%d/%d)", maxParts, maxParts))
+	budget := limit - jsonLen(subject) - suffix
+	parts := split(body, budget)
+	if len(parts) > maxParts {
+		rest := strings.Split(strings.Join(parts[maxParts-1:], "\n"), "\n")
+		parts = append(parts[:maxParts-1], summarize(rest, budget, lang))
+	}
+	messages := make([]message, len(parts))
+	for i, p := range parts {
+		messages[i] = message{subject: fmt.Sprintf("%s (%d/%d)", subject, i+1, len(parts)), body: p}
+	}
+	return messages
+}
+
+// summarize 保留 budget 字节内能放下的前几行，末尾注明省略的行数
+func summarize(lines []string, budget int, lang i18n.Language) string {
+	// 按最大可能的省略行数预留说明的长度
+	reserve := jsonLen(i18n.Translate(lang, "alert.more_lines", len(lines))) + newlineLen
+	var sb strings.Builder
+	size, kept := 0, 0
+	for _, l := range lines {
+		if size+jsonLen(l)+newlineLen+reserve > budget {
+			break
+		}
+		sb.WriteString(l + "\n")
+		size += jsonLen(l) + newlineLen
+		kept++
+	}
+	if kept == 0 && len(lines) > 0 {
+		// 第一行就放不下时截断它
+		sb.WriteString(cut(lines[0], budget-reserve) + "\n")
+		kept = 1
+	}
+	if omitted := len(lines) - kept; omitted > 0 {
+		sb.WriteString(i18n.Translate(lang, "alert.more_lines", omitted))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// split 按行把 body 拆成每段不超过 budget 字节的多段，过长的单行会被硬切开
+func split(body string, budget int) []string {
+	budget = max(budget, maxRuneLen)
+	var parts []string
+	var sb strings.Builder
+	size := 0
+	flush := func() {
+		parts = append(parts, strings.TrimRight(sb.String(), "\n"))
+		sb.Reset()
+		size = 0
+	}
+	for _, l := range strings.Split(body, "\n") {
+		for jsonLen(l) > budget {
+			head := cut(l, budget)
+			if sb.Len() > 0 {
+				flush()
+			}
+			parts = append(parts, head)
+			l = l[len(head):]
+		}
+		if sb.Len() > 0 && size+jsonLen(l) > budget {
+			flush()
+		}
+		sb.WriteString(l + "\n")
+		size += jsonLen(l) + newlineLen
+	}
+	if sb.Len() > 0 {
+		flush()
+	}
+	return parts
+}
+
+const (
+	// newlineLen 是换行符转义为 \n 后的长度
+	newlineLen = 2
+	// maxRuneLen 是单个字符转义后的最大长度，例如 < 转义为 \u003c
+	maxRuneLen = 6
+)
+
+// cut 在不切断字符的前提下返回 s 转义后不超过 n 字节的最长前缀
+func cut(s string, n int) string {
+	size := 0
+	for i, r := range s {
+		size += runeLen(r)
+		if size > n {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// jsonLen 返回 s 被 encoding/json 编码为字符串后的长度，不含两端的引号。
+// < > & 会被转义为 6 字节的 \u00XX，引号、反斜杠和换行等会变成两个字节。
+func jsonLen(s string) int {
+	size := 0
+	for _, r := range s {
+		size += runeLen(r)
+	}
+	return size
+}
+
+// runeLen 返回字符 r 在 JSON 字符串中的长度
+func runeLen(r rune) int {
+	switch {
+	case r == '"' || r == '\\' || r == '\n' || r == '\r' || r == '\t':
+		return 2
+	case r < 0x20 || r == '<' || r == '>' || r == '&' || r == '\u2028' || r == '\u2029':
+		return 6
+	}
+	// 无效的 UTF-8 字节会被替换为 3 字节的 U+FFFD，与 r 的长度相同
+	return utf8.RuneLen(r)
+}
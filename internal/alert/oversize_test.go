@@ -0,0 +1,191 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// encoded 返回 s 经 json.Marshal 编码后的长度，不含两端的引号
+func encoded(t *testing.T, s string) int {
+	t.Helper()
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(data) - 2
+}
+
+// lines 生成 n 行文本，每行以 prefix 开头
+func lines(n int, prefix string) string {
+	var sb strings.Builder
+	for i := range n {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(&sb, "%s %d", prefix, i)
+	}
+	return sb.String()
+}
+
+func TestFitLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		body    string
+		limit   int
+	}{
+		{"ascii lines", "alert", lines(50, "nginx.service is down"), 200},
+		{"cjk lines", "告警", lines(50, "磁盘空间不足，请尽快清理"), 200},
+		{"long ascii line", "alert", strings.Repeat("x", 1000), 150},
+		{"long cjk line", "告警", strings.Repeat("磁盘", 500), 151},
+		{"emoji", "🚨 告警", strings.Repeat("🔥 fire\n", 100), 97},
+		{"long subject", strings.Repeat("标题", 100), lines(20, "line"), 120},
+		{"many parts", "alert", lines(500, "journal ERROR"), 300},
+		// JSON 日志和 Java 栈中的引号、尖括号转义后会变长
+		{"json escapes", "alert <app>", lines(50, `{"msg":"a < b && c > d","path":"C:\\tmp"}`), 300},
+		{"stack trace", "alert", strings.Repeat("\tat Foo.<init>(Foo.java:1)\n", 100), 250},
+	}
+	for _, tt := range tests {
+		for _, mode := range []Oversize{OversizeSummarize, OversizeSplit} {
+			for _, lang := range []i18n.Language{i18n.ZhCN, i18n.En} {
+				t.Run(fmt.Sprintf("%s/%s/%s", tt.name, mode, lang), func(t *testing.T) {
+					messages := fit(tt.subject, tt.body, tt.limit, mode, lang)
+					if len(messages) == 0 || len(messages) > maxParts {
+						t.Fatalf("%d messages", len(messages))
+					}
+					for i, m := range messages {
+						if size := encoded(t, m.subject) + encoded(t, m.body); size > tt.limit {
+							t.Errorf("message %d is %d bytes, limit %d", i+1, size, tt.limit)
+						}
+						// 截断不能切开 UTF-8 字符
+						if !utf8.ValidString(m.subject) || !utf8.ValidString(m.body) {
+							t.Errorf("message %d has a split rune: %q %q", i+1, m.subject, m.body)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestFitUnchanged(t *testing.T) {
+	for _, limit := range []int{0, -1, 100} {
+		got := fit("alert", "short body", limit, OversizeSplit, i18n.En)
+		if len(got) != 1 || got[0] != (message{"alert", "short body"}) {
+			t.Errorf("limit %d: fit = %+v, want the message unchanged", limit, got)
+		}
+	}
+}
+
+func TestFitSummarize(t *testing.T) {
+	body := lines(10, "line")
+	tests := []struct {
+		lang i18n.Language
+		want string
+	}{
+		{i18n.En, "line 0\nline 1\nline 2\n… and 7 more lines"},
+		{i18n.ZhCN, "line 0\nline 1\n… 还有 8 行未显示"},
+		{i18n.ZhTW, "line 0\nline 1\n… 還有 8 行未顯示"},
+	}
+	for _, tt := range tests {
+		got := fit("alert", body, 52, OversizeSummarize, tt.lang)
+		if len(got) != 1 || got[0].body != tt.want {
+			t.Errorf("%s: fit = %+v, want body %q", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestFitSplit(t *testing.T) {
+	got := fit("alert", lines(6, "line"), 30, OversizeSplit, i18n.En)
+	want := []message{
+		{"alert (1/3)", "line 0\nline 1"},
+		{"alert (2/3)", "line 2\nline 3"},
+		{"alert (3/3)", "line 4\nline 5"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("fit = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("part %d = %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestFitSplitOverflow(t *testing.T) {
+	body := lines(500, "journal ERROR")
+	got := fit("alert", body, 300, OversizeSplit, i18n.En)
+	if len(got) != maxParts {
+		t.Fatalf("%d parts, want %d", len(got), maxParts)
+	}
+	for i, m := range got {
+		if want := fmt.Sprintf("alert (%d/%d)", i+1, maxParts); m.subject != want {
+			t.Errorf("part %d subject = %q, want %q", i+1, m.subject, want)
+		}
+	}
+	// 放不下的内容在最后一条中摘要
+	last := got[maxParts-1].body
+	if !strings.HasPrefix(last, "journal ERROR ") || !strings.Contains(last, "more lines") {
+		t.Errorf("last part = %q, want a summary", last)
+	}
+	if strings.Contains(last, "journal ERROR 499") {
+		t.Errorf("last part contains the final line: %q", last)
+	}
+	shown := 0
+	for _, m := range got {
+		shown += strings.Count(m.body, "journal ERROR")
+	}
+	var omitted int
+	fmt.Sscanf(last[strings.LastIndex(last, "… and "):], "… and %d more lines", &omitted)
+	if shown+omitted != 500 {
+		t.Errorf("%d lines shown and %d omitted, want 500 in total", shown, omitted)
+	}
+}
+
+func TestJSONLen(t *testing.T) {
+	for _, s := range []string{
+		"", "plain", "磁盘 🔥", `"quoted" \ back`, "<init> & co", "a\nb\r\tc\x01", "\u2028\u2029", "\xff\xfe", "\ufffd",
+	} {
+		if got, want := jsonLen(s), encoded(t, s); got != want {
+			t.Errorf("jsonLen(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestCut(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"磁盘", 4, "磁"},
+		{"磁盘", 3, "磁"},
+		{"磁盘", 2, ""},
+		{"🔥x", 3, ""},
+		{"abc", -1, ""},
+		{"a<b", 6, "a"},
+		{`"x"`, 4, `"x`},
+	}
+	for _, tt := range tests {
+		if got := cut(tt.s, tt.n); got != tt.want {
+			t.Errorf("cut(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestParseOversize(t *testing.T) {
+	for in, want := range map[string]Oversize{"": OversizeSummarize, "summarize": OversizeSummarize, "split": OversizeSplit} {
+		if got, err := ParseOversize(in); err != nil || got != want {
+			t.Errorf("ParseOversize(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseOversize("truncate"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+}
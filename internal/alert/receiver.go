@@ -0,0 +1,38 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"hashcowuwu/lychee/internal/notifier"
+)
+
+// Receiver 是一个通知接收方：通知器加上它自己的模板
+type Receiver struct {
+	Name     string
+	Notifier notifier.Notifier
+	Template *Template
+	// MaxSize 覆盖通知器声明的消息大小上限 (字节)，0 表示使用通知器的上限
+	MaxSize int
+	// Oversize 决定消息超过上限时摘要还是拆分，空值表示摘要
+	Oversize Oversize
+}
+
+// Send 渲染模板并通过通知器发送。
+// 消息超过大小上限时按 Oversize 摘要或拆分成多条依次发送，某一条失败不影响其余各条。
+func (r *Receiver) Send(ctx context.Context, data Data) error {
+	subject, body, err := r.Template.Render(data)
+	if err != nil {
+		return err
+	}
+	limit := r.MaxSize
+	if limit <= 0 {
+		limit = notifier.MaxMessageSize(r.Notifier)
+	}
+	var errs []error
+	for _, m := range fit(subject, body, limit, r.Oversize, r.Template.language()) {
+		if err := r.Notifier.Notify(ctx, m.subject, m.body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
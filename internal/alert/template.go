@@ -0,0 +1,124 @@
+package alert
+
+import (
+	"bytes"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultSubjectTemplate 是未配置时使用的通知标题模板
+const DefaultSubjectTemplate = `{{ t "alert.subject" }}`
+
+// DefaultBodyTemplate 是未配置时使用的通知正文模板
+const DefaultBodyTemplate = `{{ t "alert.body_header" }}
+{{- range .Alerts }}
+[{{ .Severity }}] {{ .Details }}
+{{- if .Duration }} ({{ t "alert.lasting" (duration .Duration) }}){{ end }}
+{{- end }}`
+
+// Data 是渲染通知模板时传入的数据
+type Data struct {
+	Host        string            // 本机主机名
+	Language    i18n.Language     // 渲染使用的语言
+	GroupLabels map[string]string // 本次通知所属分组的标签
+	Alerts      []Alert           // 本次通知包含的告警
+}
+
+// Template 是一组已解析的标题和正文模板
+type Template struct {
+	lang    i18n.Language
+	subject *template.Template
+	body    *template.Template
+}
+
+// NewTemplate 解析标题和正文模板，空字符串使用默认模板。
+// 模板中的 t 函数按 lang 翻译消息目录中的 key；lang 为空时跟随全局语言。
+// 除语法检查外，还会用示例数据试渲染一次，以便在加载配置时发现字段名错误。
+func NewTemplate(subject, body string, lang i18n.Language) (*Template, error) {
+	if subject == "" {
+		subject = DefaultSubjectTemplate
+	}
+	if body == "" {
+		body = DefaultBodyTemplate
+	}
+
+	t := &Template{lang: lang}
+	var err error
+	if t.subject, err = template.New("subject").Funcs(t.funcs()).Parse(subject); err != nil {
+		return nil, i18n.Errorf("alert.template_subject_invalid", err)
+	}
+	if t.body, err = template.New("body").Funcs(t.funcs()).Parse(body); err != nil {
+		return nil, i18n.Errorf("alert.template_body_invalid", err)
+	}
+	if _, _, err := t.Render(sampleData()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render 使用 data 渲染标题和正文
+func (t *Template) Render(data Data) (subject, body string, err error) {
+	if data.Language == "" {
+		data.Language = t.language()
+	}
+	var buf bytes.Buffer
+	if err := t.subject.Execute(&buf, data); err != nil {
+		return "", "", i18n.Errorf("alert.template_subject_invalid", err)
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := t.body.Execute(&buf, data); err != nil {
+		return "", "", i18n.Errorf("alert.template_body_invalid", err)
+	}
+	return subject, strings.TrimSpace(buf.String()), nil
+}
+
+func (t *Template) language() i18n.Language {
+	if t.lang != "" {
+		return t.lang
+	}
+	return i18n.Current()
+}
+
+func (t *Template) funcs() template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string, args ...any) string {
+			return i18n.Translate(t.language(), key, args...)
+		},
+		"join": func(sep string, elems []string) string {
+			return strings.Join(elems, sep)
+		},
+		"duration": func(d time.Duration) string {
+			return d.Round(time.Second).String()
+		},
+		"time": func(ts time.Time) string {
+			return ts.Format("2006-01-02 15:04:05")
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}
+}
+
+// sampleData 返回一份字段齐全的示例数据，用于校验模板
+func sampleData() Data {
+	start := time.Now().Add(-5 * time.Minute)
+	return Data{
+		Host:        "localhost",
+		GroupLabels: map[string]string{"host": "localhost"},
+		Alerts: []Alert{{
+			Host:     "localhost",
+			Monitor:  "systemd-service(example.service)",
+			Severity: monitor.SeverityCritical,
+			Labels:   map[string]string{"host": "localhost", "monitor": "systemd-service(example.service)", "severity": "critical"},
+			StartsAt: start,
+			Duration: 5 * time.Minute,
+			Details:  "example",
+			LogLines: []string{"example log line"},
+			Fields:   map[string]string{"PRIORITY": "3", "SYSLOG_IDENTIFIER": "example", "_PID": "1"},
+		}},
+	}
+}
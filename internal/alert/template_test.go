@@ -0,0 +1,96 @@
+package alert
+
+import (
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"testing"
+	"time"
+)
+
+func testData() Data {
+	return Data{
+		Host: "web-1",
+		Alerts: []Alert{
+			{Monitor: "nginx", Severity: monitor.SeverityCritical, Details: "nginx.service is down", Duration: 90 * time.Second},
+			{Monitor: "journal", Severity: monitor.SeverityWarning, Details: "ERROR disk full", Event: true},
+		},
+	}
+}
+
+func TestTemplateLanguages(t *testing.T) {
+	tests := []struct {
+		lang    i18n.Language
+		subject string
+		body    string
+	}{
+		{i18n.ZhCN, "🚨 服务异常告警", "以下服务出现异常:\n[critical] nginx.service is down (已持续 1m30s)\n[warning] ERROR disk full"},
+		{i18n.ZhTW, "🚨 服務異常告警", "以下服務出現異常:\n[critical] nginx.service is down (已持續 1m30s)\n[warning] ERROR disk full"},
+		{i18n.En, "🚨 Service Alert", "The following services have problems:\n[critical] nginx.service is down (for 1m30s)\n[warning] ERROR disk full"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.lang), func(t *testing.T) {
+			tmpl, err := NewTemplate("", "", tt.lang)
+			if err != nil {
+				t.Fatal(err)
+			}
+			subject, body, err := tmpl.Render(testData())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if subject != tt.subject || body != tt.body {
+				t.Errorf("Render() =\n%s\n%s\nwant\n%s\n%s", subject, body, tt.subject, tt.body)
+			}
+		})
+	}
+}
+
+func TestTemplateFollowsGlobalLanguage(t *testing.T) {
+	defer i18n.SetLanguage(i18n.Current())
+	tmpl, err := NewTemplate("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i18n.SetLanguage(i18n.En)
+	if subject, _, _ := tmpl.Render(testData()); subject != "🚨 Service Alert" {
+		t.Errorf("subject = %q, want the English default", subject)
+	}
+	i18n.SetLanguage(i18n.ZhTW)
+	if subject, _, _ := tmpl.Render(testData()); subject != "🚨 服務異常告警" {
+		t.Errorf("subject = %q, want the zh-TW default", subject)
+	}
+}
+
+func TestTemplateCustom(t *testing.T) {
+	tmpl, err := NewTemplate(
+		`[{{ upper .Host }}] {{ len .Alerts }} {{ t "alert.subject" }}`,
+		`{{ range .Alerts }}{{ .Monitor }}={{ .Severity }}{{ if .Event }} (event){{ end }}; {{ end }}{{ .Language }}`,
+		i18n.En)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, body, err := tmpl.Render(testData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[WEB-1] 2 🚨 Service Alert"; subject != want {
+		t.Errorf("subject = %q, want %q", subject, want)
+	}
+	if want := "nginx=critical; journal=warning (event); en"; body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestTemplateInvalid(t *testing.T) {
+	tests := []struct {
+		name, subject, body string
+	}{
+		{"syntax", "{{ .Host", ""},
+		{"unknown field", "", "{{ .Hostname }}"},
+		{"unknown function", "{{ shout .Host }}", ""},
+	}
+	for _, tt := range tests {
+		if _, err := NewTemplate(tt.subject, tt.body, i18n.En); err == nil {
+			t.Errorf("%s: NewTemplate succeeded", tt.name)
+		}
+	}
+}
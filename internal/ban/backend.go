@@ -0,0 +1,131 @@
+package ban
+
+import (
+	"context"
+	"hashcowuwu/lychee/internal/executor"
+	"hashcowuwu/lychee/internal/i18n"
+	"net/netip"
+)
+
+// backend 把地址加入或移出防火墙
+type backend interface {
+	// supports 判断是否配置了封禁该地址族的位置
+	supports(addr netip.Addr) bool
+	ban(ctx context.Context, addr netip.Addr) error
+	// unban 解除封禁，地址已不在防火墙中 (例如防火墙重新加载或被手动解除) 时视为成功
+	unban(ctx context.Context, addr netip.Addr) error
+}
+
+// exists 运行 iptables -C 判断规则是否在防火墙中: 以非 0 状态退出表示不存在，命令无法运行时返回错误
+func exists(ctx context.Context, exec executor.Executor, name string, args ...string) (bool, error) {
+	_, err := exec.Output(ctx, name, args...)
+	if err == nil {
+		return true, nil
+	}
+	if _, exited := executor.ExitCode(err); exited {
+		return false, nil
+	}
+	return false, err
+}
+
+// nftables 把地址加入预先创建的 nftables 集合，规则由管理员配置，例如
+//
+//	nft add set inet filter lychee '{ type ipv4_addr; }'
+//	nft add rule inet filter input ip saddr @lychee drop
+type nftables struct {
+	exec          executor.Executor
+	family, table string
+	set, set6     string
+}
+
+func (n *nftables) supports(addr netip.Addr) bool {
+	return addr.Is4() || n.set6 != ""
+}
+
+func (n *nftables) setFor(addr netip.Addr) (string, error) {
+	if addr.Is4() {
+		return n.set, nil
+	}
+	if n.set6 == "" {
+		return "", i18n.Errorf("ban.ipv6_disabled")
+	}
+	return n.set6, nil
+}
+
+func (n *nftables) ban(ctx context.Context, addr netip.Addr) error {
+	set, err := n.setFor(addr)
+	if err != nil {
+		return err
+	}
+	_, err = n.exec.Output(ctx, "nft", "add", "element", n.family, n.table, set, "{ "+addr.String()+" }")
+	return err
+}
+
+func (n *nftables) unban(ctx context.Context, addr netip.Addr) error {
+	set, err := n.setFor(addr)
+	if err != nil {
+		return err
+	}
+	element := "{ " + addr.String() + " }"
+	// 元素已不存在时 delete 会失败，先用 get 检查
+	if _, err := n.exec.Output(ctx, "nft", "get", "element", n.family, n.table, set, element); err != nil {
+		if n.elementMissing(ctx, set, err) {
+			return nil
+		}
+		return err
+	}
+	_, err = n.exec.Output(ctx, "nft", "delete", "element", n.family, n.table, set, element)
+	return err
+}
+
+// elementMissing 判断 get element 的失败是否只是因为元素不在集合中。
+// 集合或表不存在、地址族错误时 nft 同样以非 0 状态退出，所以再确认集合本身可以读取；
+// --terse 不输出集合中的元素。
+func (n *nftables) elementMissing(ctx context.Context, set string, err error) bool {
+	if _, exited := executor.ExitCode(err); !exited {
+		return false
+	}
+	_, err = n.exec.Output(ctx, "nft", "--terse", "list", "set", n.family, n.table, set)
+	return err == nil
+}
+
+// iptables 在预先创建的链中为每个地址插入一条 DROP 规则，IPv6 地址使用 ip6tables，例如
+//
+//	iptables -N LYCHEE && iptables -I INPUT -j LYCHEE
+type iptables struct {
+	exec  executor.Executor
+	chain string
+}
+
+func (t *iptables) supports(addr netip.Addr) bool {
+	return true
+}
+
+func (t *iptables) command(addr netip.Addr) string {
+	if addr.Is4() {
+		return "iptables"
+	}
+	return "ip6tables"
+}
+
+func (t *iptables) rule(op string, addr netip.Addr) []string {
+	return []string{"-w", op, t.chain, "-s", addr.String(), "-j", "DROP"}
+}
+
+func (t *iptables) ban(ctx context.Context, addr netip.Addr) error {
+	// 规则已存在时 (例如重启后恢复封禁) 不重复插入
+	if ok, err := exists(ctx, t.exec, t.command(addr), t.rule("-C", addr)...); ok || err != nil {
+		return err
+	}
+	_, err := t.exec.Output(ctx, t.command(addr), t.rule("-I", addr)...)
+	return err
+}
+
+func (t *iptables) unban(ctx context.Context, addr netip.Addr) error {
+	// 规则已不存在时 -D 会失败，先用 -C 检查
+	if ok, err := exists(ctx, t.exec, t.command(addr), t.rule("-C", addr)...); !ok || err != nil {
+		return err
+	}
+	_, err := t.exec.Output(ctx, t.command(addr), t.rule("-D", addr)...)
+	return err
+}
@@ -0,0 +1,244 @@
+// Package ban 实现类似 fail2ban 的自动封禁: 监控器在结果中报告的攻击来源被加入 nftables 集合或 iptables 链，
+// 到期后自动解除。封禁记录保存在状态目录中，重启后继续按原来的到期时间解除。
+package ban
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"hashcowuwu/lychee/internal/executor"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/netutil"
+	"hashcowuwu/lychee/internal/state"
+	"log"
+	"maps"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDuration 是未配置 duration 时的封禁时长
+const DefaultDuration = time.Hour
+
+// commandTimeout 是一次防火墙命令的最长运行时间
+const commandTimeout = 10 * time.Second
+
+// Config 描述自动封禁
+type Config struct {
+	Enabled   bool          `yaml:"enabled"`
+	Backend   string        `yaml:"backend"`   // nftables (默认) 或 iptables
+	Family    string        `yaml:"family"`    // nftables 表的地址族，默认 inet
+	Table     string        `yaml:"table"`     // nftables 表，默认 filter
+	Set       string        `yaml:"set"`       // 存放 IPv4 地址的 nftables 集合，默认 lychee
+	Set6      string        `yaml:"set6"`      // 存放 IPv6 地址的 nftables 集合，为空时不封禁 IPv6
+	Chain     string        `yaml:"chain"`     // iptables 链，默认 LYCHEE
+	Duration  time.Duration `yaml:"duration"`  // 封禁时长，默认 1h
+	Whitelist []string      `yaml:"whitelist"` // 从不封禁的地址或网段，回环地址总是不封禁
+}
+
+// Validate 检查配置
+func (c Config) Validate() error {
+	switch c.Backend {
+	case "", "nftables", "iptables":
+	default:
+		return i18n.Errorf("ban.backend_unknown", c.Backend)
+	}
+	if c.Duration < 0 {
+		return i18n.Errorf("ban.duration_negative")
+	}
+	for _, w := range c.Whitelist {
+		if _, err := netutil.ParseNetwork(w); err != nil {
+			return i18n.Errorf("ban.whitelist_invalid", w, err)
+		}
+	}
+	return nil
+}
+
+// entry 是一条封禁记录
+type entry struct {
+	Reason  string    `json:"reason"` // 触发封禁的监控器
+	Since   time.Time `json:"since"`
+	Expires time.Time `json:"expires"`
+}
+
+// Manager 执行封禁并在到期后解除。它同时是一个监控器: Check 解除到期的封禁并报告结果。
+type Manager struct {
+	cfg       Config
+	backend   backend
+	whitelist []netip.Prefix
+	statePath string
+
+	mu       sync.Mutex
+	bans     map[string]*entry
+	restored bool // 是否已在启动后重新应用保存的封禁
+	// unsupportedLogged 表示已记录过无法封禁 IPv6 地址，之后的 IPv6 地址直接跳过
+	unsupportedLogged bool
+}
+
+// New 创建封禁管理器，命令通过 exec 执行；statePath 为空时不持久化
+func New(cfg Config, exec executor.Executor, statePath string) (*Manager, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Duration == 0 {
+		cfg.Duration = DefaultDuration
+	}
+	m := &Manager{cfg: cfg, statePath: statePath, bans: make(map[string]*entry)}
+	if cfg.Backend == "iptables" {
+		m.backend = &iptables{exec: exec, chain: cmp.Or(cfg.Chain, "LYCHEE")}
+	} else {
+		m.backend = &nftables{exec: exec, family: cmp.Or(cfg.Family, "inet"), table: cmp.Or(cfg.Table, "filter"), set: cmp.Or(cfg.Set, "lychee"), set6: cfg.Set6}
+	}
+	for _, w := range cfg.Whitelist {
+		p, _ := netutil.ParseNetwork(w)
+		m.whitelist = append(m.whitelist, p)
+	}
+	if statePath != "" {
+		if err := state.Load(statePath, &m.bans); err != nil {
+			log.Print(i18n.T("ban.state_load_failed", err))
+		}
+	}
+	return m, nil
+}
+
+// Name 返回监控器名称
+func (m *Manager) Name() string {
+	return "autoban"
+}
+
+// Ban 封禁 ip，reason 为触发封禁的监控器。
+// 地址无效、在白名单中、已被封禁或没有配置对应地址族的封禁位置 (nftables 未配置 set6) 时返回 false；
+// 否则返回描述封禁结果的事件。
+func (m *Manager) Ban(ip, reason string) (monitor.Result, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return monitor.Result{}, false
+	}
+	addr = addr.Unmap()
+	if m.whitelisted(addr) {
+		return monitor.Result{}, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := addr.String()
+	if _, ok := m.bans[key]; ok {
+		return monitor.Result{}, false
+	}
+	if !m.backend.supports(addr) {
+		// 每次触发都告警没有意义，只在第一次时记录日志
+		if !m.unsupportedLogged {
+			log.Print(i18n.T("ban.ipv6_skipped", key, reason))
+			m.unsupportedLogged = true
+		}
+		return monitor.Result{}, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	if err := m.backend.ban(ctx, addr); err != nil {
+		return m.event(i18n.T("ban.ban_failed", key, reason, err), monitor.SeverityCritical, key), true
+	}
+	now := time.Now()
+	m.bans[key] = &entry{Reason: reason, Since: now, Expires: now.Add(m.cfg.Duration)}
+	m.save()
+	return m.event(i18n.T("ban.banned", key, m.cfg.Duration, reason), monitor.SeverityWarning, key), true
+}
+
+// Check 解除到期的封禁。启动后第一次检查时还会重新应用仍然有效的封禁，例如重启后防火墙规则已被清空。
+func (m *Manager) Check() monitor.Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	now := time.Now()
+	var messages, ips []string
+	severity := monitor.SeverityWarning
+	changed := false
+	for _, ip := range slices.Sorted(maps.Keys(m.bans)) {
+		e := m.bans[ip]
+		addr, err := netip.ParseAddr(ip)
+		// 无效的记录，或之后删除了 set6 配置的 IPv6 记录，无法再解除，直接丢弃
+		if err != nil || !m.backend.supports(addr) {
+			delete(m.bans, ip)
+			changed = true
+			continue
+		}
+		if now.Before(e.Expires) {
+			if !m.restored {
+				if err := m.backend.ban(ctx, addr); err != nil {
+					log.Print(i18n.T("ban.restore_failed", ip, err))
+				}
+			}
+			continue
+		}
+		if err := m.backend.unban(ctx, addr); err != nil {
+			// 保留记录，下一轮检查时重试
+			messages = append(messages, i18n.T("ban.unban_failed", ip, err))
+			severity = monitor.SeverityCritical
+			continue
+		}
+		delete(m.bans, ip)
+		messages = append(messages, i18n.T("ban.unbanned", ip, e.Reason, e.Since.Format(time.DateTime)))
+		ips = append(ips, ip)
+	}
+	m.restored = true
+	if changed || len(ips) > 0 {
+		m.save()
+	}
+
+	if len(messages) == 0 {
+		return monitor.Result{Success: true, Message: i18n.T("ban.ok", len(m.bans)), Labels: m.labels()}
+	}
+	return m.event(strings.Join(messages, "\n"), severity, strings.Join(ips, ","))
+}
+
+func (m *Manager) whitelisted(addr netip.Addr) bool {
+	if addr.IsLoopback() || addr.IsUnspecified() {
+		return true
+	}
+	for _, p := range m.whitelist {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// event 生成封禁或解除封禁的通知
+func (m *Manager) event(message string, severity monitor.Severity, ip string) monitor.Result {
+	r := monitor.Result{Success: false, Message: message, Severity: severity, Labels: m.labels(), Event: true}
+	if ip != "" {
+		r.Fields = map[string]string{"ban_ip": ip}
+	}
+	return r
+}
+
+// save 保存封禁记录，失败时只记录日志
+func (m *Manager) save() {
+	if m.statePath == "" {
+		return
+	}
+	if err := state.Save(m.statePath, m.bans); err != nil {
+		log.Print(i18n.T("ban.state_save_failed", err))
+	}
+}
+
+// labels 返回附加在结果上的标签
+func (m *Manager) labels() map[string]string {
+	return map[string]string{"type": "ban", "service": m.Name()}
+}
+
+// String 返回封禁方式的简短描述，用于启动日志
+func (m *Manager) String() string {
+	switch b := m.backend.(type) {
+	case *iptables:
+		return fmt.Sprintf("iptables chain %s", b.chain)
+	case *nftables:
+		return fmt.Sprintf("nftables %s %s %s", b.family, b.table, b.set)
+	}
+	return ""
+}
@@ -0,0 +1,221 @@
+package ban
+
+import (
+	"errors"
+	"hashcowuwu/lychee/internal/executor/executortest"
+	"hashcowuwu/lychee/internal/monitor"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBan(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		fake     *executortest.Fake
+		ip       string
+		ok       bool
+		severity monitor.Severity
+		calls    []string
+	}{
+		{
+			name:     "nftables",
+			fake:     executortest.New().On("nft add element inet filter lychee { 203.0.113.5 }", executortest.Response{}),
+			ip:       "203.0.113.5",
+			ok:       true,
+			severity: monitor.SeverityWarning,
+			calls:    []string{"nft add element inet filter lychee { 203.0.113.5 }"},
+		},
+		{name: "nftables ipv6 without set6", fake: executortest.New(), ip: "2001:db8::1"},
+		{
+			name:     "nftables ipv4-mapped",
+			cfg:      Config{Set6: "lychee6"},
+			fake:     executortest.New().On("nft add element inet filter lychee { 203.0.113.5 }", executortest.Response{}),
+			ip:       "::ffff:203.0.113.5",
+			ok:       true,
+			severity: monitor.SeverityWarning,
+			calls:    []string{"nft add element inet filter lychee { 203.0.113.5 }"},
+		},
+		{
+			name: "iptables",
+			cfg:  Config{Backend: "iptables"},
+			fake: executortest.New().
+				On("iptables -w -C LYCHEE -s 203.0.113.5 -j DROP", executortest.Response{ExitCode: 1}).
+				On("iptables -w -I LYCHEE -s 203.0.113.5 -j DROP", executortest.Response{}),
+			ip:       "203.0.113.5",
+			ok:       true,
+			severity: monitor.SeverityWarning,
+			calls:    []string{"iptables -w -C LYCHEE -s 203.0.113.5 -j DROP", "iptables -w -I LYCHEE -s 203.0.113.5 -j DROP"},
+		},
+		{
+			name:     "iptables rule exists",
+			cfg:      Config{Backend: "iptables", Chain: "INPUT"},
+			fake:     executortest.New().On("ip6tables -w -C INPUT -s 2001:db8::1 -j DROP", executortest.Response{}),
+			ip:       "2001:db8::1",
+			ok:       true,
+			severity: monitor.SeverityWarning,
+			calls:    []string{"ip6tables -w -C INPUT -s 2001:db8::1 -j DROP"},
+		},
+		{
+			name:     "command failed",
+			fake:     executortest.New().On("nft add element inet filter lychee { 203.0.113.5 }", executortest.Response{ExitCode: 1}),
+			ip:       "203.0.113.5",
+			ok:       true,
+			severity: monitor.SeverityCritical,
+			calls:    []string{"nft add element inet filter lychee { 203.0.113.5 }"},
+		},
+		{name: "whitelisted", cfg: Config{Whitelist: []string{"10.0.0.0/8"}}, fake: executortest.New(), ip: "10.1.2.3"},
+		{name: "loopback", fake: executortest.New(), ip: "127.0.0.1"},
+		{name: "invalid", fake: executortest.New(), ip: "not-an-ip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.cfg, tt.fake, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, ok := m.Ban(tt.ip, "test")
+			if ok != tt.ok || r.Severity != tt.severity {
+				t.Errorf("got %v, Severity = %q, want %v, %q (%s)", ok, r.Severity, tt.ok, tt.severity, r.Message)
+			}
+			if !slices.Equal(tt.fake.Calls(), tt.calls) {
+				t.Errorf("calls = %q, want %q", tt.fake.Calls(), tt.calls)
+			}
+		})
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	const (
+		add = "nft add element inet filter lychee { 203.0.113.5 }"
+		get = "nft get element inet filter lychee { 203.0.113.5 }"
+		del = "nft delete element inet filter lychee { 203.0.113.5 }"
+	)
+	statePath := filepath.Join(t.TempDir(), "ban.json")
+	fake := executortest.New().On(add, executortest.Response{}).On(get, executortest.Response{}).On(del, executortest.Response{})
+	m, err := New(Config{Duration: time.Hour}, fake, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Ban("203.0.113.5", "test"); !ok {
+		t.Fatal("first ban was skipped")
+	}
+	if _, ok := m.Ban("203.0.113.5", "test"); ok {
+		t.Error("address banned twice")
+	}
+
+	// 重启后读取封禁记录，第一次检查重新应用仍然有效的封禁
+	m, err = New(Config{Duration: time.Hour}, fake, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := m.Check(); !r.Success {
+		t.Errorf("check before expiry: %s", r.Message)
+	}
+	if want := []string{add, add}; !slices.Equal(fake.Calls(), want) {
+		t.Errorf("calls = %q, want %q", fake.Calls(), want)
+	}
+
+	m.bans["203.0.113.5"].Expires = time.Now().Add(-time.Second)
+	r := m.Check()
+	if r.Success || !r.Event || r.Fields["ban_ip"] != "203.0.113.5" {
+		t.Errorf("check after expiry: %+v", r)
+	}
+	if len(m.bans) != 0 {
+		t.Errorf("bans = %v, want none", m.bans)
+	}
+	if got := fake.Calls(); got[len(got)-1] != del {
+		t.Errorf("last call = %q, want %q", got[len(got)-1], del)
+	}
+}
+
+func TestUnban(t *testing.T) {
+	const (
+		get   = "nft get element inet filter lychee { 203.0.113.5 }"
+		del   = "nft delete element inet filter lychee { 203.0.113.5 }"
+		list  = "nft --terse list set inet filter lychee"
+		check = "iptables -w -C LYCHEE -s 203.0.113.5 -j DROP"
+		drop  = "iptables -w -D LYCHEE -s 203.0.113.5 -j DROP"
+	)
+	tests := []struct {
+		name     string
+		cfg      Config
+		fake     *executortest.Fake
+		removed  bool
+		severity monitor.Severity
+		calls    []string
+	}{
+		{
+			name:     "nftables",
+			fake:     executortest.New().On(get, executortest.Response{}).On(del, executortest.Response{}),
+			removed:  true,
+			severity: monitor.SeverityWarning,
+			calls:    []string{get, del},
+		},
+		{
+			// 防火墙重新加载或被手动解除后元素已不存在
+			name:     "nftables element gone",
+			fake:     executortest.New().On(get, executortest.Response{ExitCode: 1}).On(list, executortest.Response{}),
+			removed:  true,
+			severity: monitor.SeverityWarning,
+			calls:    []string{get, list},
+		},
+		{
+			// 集合或表不存在 (或地址族错误) 时不能当作已解除，地址可能仍被封禁
+			name:     "nftables set missing",
+			fake:     executortest.New().On(get, executortest.Response{ExitCode: 1}).On(list, executortest.Response{ExitCode: 1}),
+			severity: monitor.SeverityCritical,
+			calls:    []string{get, list},
+		},
+		{
+			name:     "nftables delete failed",
+			fake:     executortest.New().On(get, executortest.Response{}).On(del, executortest.Response{ExitCode: 1}),
+			severity: monitor.SeverityCritical,
+			calls:    []string{get, del},
+		},
+		{
+			name:     "nft missing",
+			fake:     executortest.New().On(get, executortest.Response{Err: errors.New("executable file not found")}),
+			severity: monitor.SeverityCritical,
+			calls:    []string{get},
+		},
+		{
+			name:     "iptables",
+			cfg:      Config{Backend: "iptables"},
+			fake:     executortest.New().On(check, executortest.Response{}).On(drop, executortest.Response{}),
+			removed:  true,
+			severity: monitor.SeverityWarning,
+			calls:    []string{check, drop},
+		},
+		{
+			name:     "iptables rule gone",
+			cfg:      Config{Backend: "iptables"},
+			fake:     executortest.New().On(check, executortest.Response{ExitCode: 1}),
+			removed:  true,
+			severity: monitor.SeverityWarning,
+			calls:    []string{check},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.cfg, tt.fake, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.restored = true
+			m.bans["203.0.113.5"] = &entry{Reason: "test", Since: time.Now().Add(-2 * time.Hour), Expires: time.Now().Add(-time.Hour)}
+			r := m.Check()
+			if _, kept := m.bans["203.0.113.5"]; kept == tt.removed {
+				t.Errorf("record kept = %v, want %v", kept, !tt.removed)
+			}
+			if r.Severity != tt.severity {
+				t.Errorf("Severity = %q, want %q (%s)", r.Severity, tt.severity, r.Message)
+			}
+			if !slices.Equal(tt.fake.Calls(), tt.calls) {
+				t.Errorf("calls = %q, want %q", tt.fake.Calls(), tt.calls)
+			}
+		})
+	}
+}
@@ -1,25 +1,150 @@
 package config
 
 import (
+	"cmp"
+	"hashcowuwu/lychee/internal/alert"
+	"hashcowuwu/lychee/internal/ban"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor/auth"
+	"hashcowuwu/lychee/internal/monitor/dns"
+	"hashcowuwu/lychee/internal/monitor/heartbeat"
+	"hashcowuwu/lychee/internal/monitor/host"
+	"hashcowuwu/lychee/internal/monitor/httpprobe"
+	"hashcowuwu/lychee/internal/monitor/integrity"
+	"hashcowuwu/lychee/internal/monitor/journal"
+	"hashcowuwu/lychee/internal/monitor/logfile"
+	"hashcowuwu/lychee/internal/monitor/port"
+	"hashcowuwu/lychee/internal/monitor/process"
+	"hashcowuwu/lychee/internal/monitor/rules"
+	"hashcowuwu/lychee/internal/monitor/script"
+	"hashcowuwu/lychee/internal/monitor/systemd"
+	"hashcowuwu/lychee/internal/monitor/tlscert"
+	"hashcowuwu/lychee/internal/server"
+	"hashcowuwu/lychee/internal/state"
+	"strconv"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type JournalConfig struct {
-	ServiceName string   `yaml:"serviceName"`
-	Keywords    []string `yaml:"keywords"`
+	ServiceName string         `yaml:"serviceName"`
+	UserUnit    string         `yaml:"userUnit"`   // 使用者服务 (journalctl --user-unit)
+	Kernel      bool           `yaml:"kernel"`     // 只读取内核消息 (journalctl -k)
+	Identifier  string         `yaml:"identifier"` // 按 SYSLOG_IDENTIFIER 过滤 (journalctl -t)
+	Priority    string         `yaml:"priority"`   // 只读取该优先级及更严重的日志，例如 err
+	Stream      bool           `yaml:"stream"`     // 常驻 journalctl -f 实时跟随，而不是每轮检查轮询一次
+	BufferSize  int            `yaml:"bufferSize"` // 串流模式下等待处理的日志行数上限，默认 1024
+	Keywords    []string       `yaml:"keywords"`   // 旧写法，等价于不区分大小写的正则规则
+	Rules       []rules.Config `yaml:"rules"`
+
+	Multiline rules.MultilineConfig `yaml:"multiline"` // 合并堆栈等多行日志后再匹配规则
+	Parse     string                `yaml:"parse"`     // 把 MESSAGE 按 json 或 logfmt 解析成字段，供规则的 fields 过滤
+	Context   rules.ContextConfig   `yaml:"context"`   // 告警中附带命中前后的日志
+}
+
+// Source 返回该配置对应的 journal 读取范围
+func (j JournalConfig) Source() journal.Source {
+	return journal.Source{
+		Unit:       j.ServiceName,
+		UserUnit:   j.UserUnit,
+		Kernel:     j.Kernel,
+		Identifier: j.Identifier,
+		Priority:   j.Priority,
+	}
+}
+
+// AllRules 返回该服务的全部规则，keywords 转换成的规则排在前面
+func (j JournalConfig) AllRules() []rules.Config {
+	return append(rules.FromKeywords(j.Keywords), j.Rules...)
+}
+
+// Engine 返回该服务的规则引擎配置
+func (j JournalConfig) Engine() rules.EngineConfig {
+	return rules.EngineConfig{Rules: j.AllRules(), Multiline: j.Multiline, Parse: j.Parse, Context: j.Context}
+}
+
+// FileConfig 描述一组需要跟踪的普通日志文件，规则写法与 journal 相同
+type FileConfig struct {
+	Name      string                `yaml:"name"`
+	Paths     []string              `yaml:"paths"` // glob 模式，例如 /var/log/app/*.log，.gz 文件只用于轮转后的补读
+	Keywords  []string              `yaml:"keywords"`
+	Rules     []rules.Config        `yaml:"rules"` // 阈值按文件分别计数，同一 glob 匹配的多个文件的命中不会累加
+	Multiline rules.MultilineConfig `yaml:"multiline"`
+	Parse     string                `yaml:"parse"`
+	Context   rules.ContextConfig   `yaml:"context"`
+}
+
+// Engine 返回该组文件的规则引擎配置
+func (f FileConfig) Engine() rules.EngineConfig {
+	return rules.EngineConfig{
+		Rules:     append(rules.FromKeywords(f.Keywords), f.Rules...),
+		Multiline: f.Multiline,
+		Parse:     f.Parse,
+		Context:   f.Context,
+	}
+}
+
+// TemplateConfig 是通知的 text/template 模板，留空使用内置默认模板
+type TemplateConfig struct {
+	Subject string `yaml:"subject"`
+	Body    string `yaml:"body"`
+}
+
+// ReceiverConfig 描述一个通知接收方
+type ReceiverConfig struct {
+	Name        string         `yaml:"name"`
+	Type        string         `yaml:"type"` // 目前仅支持 lark
+	WebhookURLs []string       `yaml:"webhookURLs"`
+	Language    string         `yaml:"language"` // 覆盖全局 language
+	Templates   TemplateConfig `yaml:"templates"`
+	// MaxMessageSize 覆盖通知器自身的消息大小上限 (字节)，例如飞书为 16 KB
+	MaxMessageSize int `yaml:"maxMessageSize"`
+	// Oversize 决定消息超过上限时的处理方式: summarize (默认，只保留前几行并注明省略的行数) / split (拆分成多条发送)
+	Oversize string `yaml:"oversize"`
+}
+
+// GroupConfig 控制告警分组与发送节奏，留空的时间使用默认值
+type GroupConfig struct {
+	GroupBy        []string      `yaml:"groupBy"`        // 分组标签，"..." 表示按全部标签分组
+	GroupWait      time.Duration `yaml:"groupWait"`      // 默认 30s
+	GroupInterval  time.Duration `yaml:"groupInterval"`  // 默认 5m
+	RepeatInterval time.Duration `yaml:"repeatInterval"` // 默认 4h
 }
 
 type Config struct {
-	CheckInterval int `yaml:"checkInterval"` // 修改为 yaml 标签，匹配配置文件
+	CheckInterval int    `yaml:"checkInterval"` // 修改为 yaml 标签，匹配配置文件
+	Language      string `yaml:"language"`      // 告警与日志使用的语言: zh-CN / zh-TW / en，默认 zh-CN
+	StateDir      string `yaml:"stateDir"`      // 持久化状态 (例如日志文件读取进度) 的目录，默认 /var/lib/lychee
 	Systemd       struct {
-		Services []string `yaml:"services"`
+		Services    []string              `yaml:"services"`    // 服务名或 glob 模式，例如 app-*.service
+		Timers      []systemd.TimerConfig `yaml:"timers"`      // 定时器和 oneshot 服务，不能用 is-active 判断
+		Failed      systemd.FailedConfig  `yaml:"failed"`      // 任何单元进入 failed 状态时告警
+		SystemState bool                  `yaml:"systemState"` // 检查 systemctl is-system-running，degraded 时告警
 	} `yaml:"systemd"`
 	Lark struct {
-		WebhookURLs []string `yaml:"webhook_urls"`
+		WebhookURLs []string       `yaml:"webhook_urls"`
+		Templates   TemplateConfig `yaml:"templates"`
 	} `yaml:"lark"`
-	Journal []JournalConfig `yaml:"journal"`
+	Receivers  []ReceiverConfig   `yaml:"receivers"`
+	Group      GroupConfig        `yaml:"group"`
+	Journal    []JournalConfig    `yaml:"journal"`
+	Files      []FileConfig       `yaml:"files"`
+	HTTP       []httpprobe.Config `yaml:"http"`
+	TLS        []tlscert.Config   `yaml:"tls"`
+	Ports      []port.Config      `yaml:"ports"`
+	DNS        []dns.Config       `yaml:"dns"`
+	Host       host.Config        `yaml:"host"`
+	Processes  []process.Config   `yaml:"processes"`
+	Server     server.Config      `yaml:"server"` // 内置 HTTP 服务，心跳 ping 地址由它提供
+	Heartbeats []heartbeat.Config `yaml:"heartbeats"`
+	Exec       []script.Config    `yaml:"exec"` // Nagios 风格的检查脚本
+	Integrity  []integrity.Config `yaml:"integrity"`
+	Auth       []auth.Config      `yaml:"auth"` // sshd 和 sudo 的认证日志分析
+	Ban        ban.Config         `yaml:"ban"`  // 自动封禁监控器报告的攻击来源
 }
 
+// Load 读取并校验配置文件，同时把配置的 language 设为当前语言
 func Load(path string) (*Config, error) {
 	viper.SetConfigFile(path)
 	viper.AutomaticEnv()
@@ -33,5 +158,192 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	if cfg.StateDir == "" {
+		cfg.StateDir = state.DefaultDir
+	}
+
+	// 先切换到配置的语言，校验错误才会以该语言报告
+	lang, err := i18n.Parse(cfg.Language)
+	if err != nil {
+		return nil, err
+	}
+	i18n.SetLanguage(lang)
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+// AllReceivers 返回所有通知接收方。
+// 旧的 `lark` 配置段在配置了 Webhook 时作为名为 lark 的接收方追加在最后。
+func (c *Config) AllReceivers() []ReceiverConfig {
+	receivers := append([]ReceiverConfig(nil), c.Receivers...)
+	if len(c.Lark.WebhookURLs) > 0 {
+		receivers = append(receivers, ReceiverConfig{
+			Name:        "lark",
+			Type:        "lark",
+			WebhookURLs: c.Lark.WebhookURLs,
+			Templates:   c.Lark.Templates,
+		})
+	}
+	return receivers
+}
+
+// GroupOptions 将分组配置转换为 alert.GroupOptions，未配置的字段使用默认值
+func (g GroupConfig) GroupOptions() alert.GroupOptions {
+	opts := alert.DefaultGroupOptions()
+	opts.By = g.GroupBy
+	if g.GroupWait > 0 {
+		opts.Wait = g.GroupWait
+	}
+	if g.GroupInterval > 0 {
+		opts.Interval = g.GroupInterval
+	}
+	if g.RepeatInterval > 0 {
+		opts.RepeatInterval = g.RepeatInterval
+	}
+	return opts
+}
+
+// Template 解析接收方的通知模板。未单独配置 language 时模板跟随全局语言。
+func (r ReceiverConfig) Template() (*alert.Template, error) {
+	var lang i18n.Language
+	if r.Language != "" {
+		var err error
+		if lang, err = i18n.Parse(r.Language); err != nil {
+			return nil, err
+		}
+	}
+	return alert.NewTemplate(r.Templates.Subject, r.Templates.Body, lang)
+}
+
+// validate 检查无法在运行时恢复的配置错误，例如未知语言和无效模板
+func (c *Config) validate() error {
+	if c.Group.GroupWait < 0 || c.Group.GroupInterval < 0 || c.Group.RepeatInterval < 0 {
+		return i18n.Errorf("config.group_negative")
+	}
+	for _, label := range c.Group.GroupBy {
+		if label == alert.GroupAll && len(c.Group.GroupBy) > 1 {
+			return i18n.Errorf("config.group_all_exclusive")
+		}
+	}
+	for _, j := range c.Journal {
+		if err := j.Source().Validate(); err != nil {
+			return i18n.Errorf("config.journal_invalid", j.Source(), err)
+		}
+		if _, err := rules.NewEngine(j.Engine()); err != nil {
+			return i18n.Errorf("config.journal_invalid", j.Source(), err)
+		}
+	}
+	for i, f := range c.Files {
+		if f.Name == "" {
+			f.Name = strconv.Itoa(i)
+		}
+		if err := logfile.ValidatePatterns(f.Paths); err != nil {
+			return i18n.Errorf("config.logfile_invalid", f.Name, err)
+		}
+		if _, err := rules.NewEngine(f.Engine()); err != nil {
+			return i18n.Errorf("config.logfile_invalid", f.Name, err)
+		}
+	}
+	for _, h := range c.HTTP {
+		if err := h.Validate(); err != nil {
+			return i18n.Errorf("config.http_invalid", cmp.Or(h.Name, h.URL), err)
+		}
+	}
+	for i, t := range c.TLS {
+		if err := t.Validate(); err != nil {
+			return i18n.Errorf("config.tls_invalid", cmp.Or(t.Name, t.Address, strconv.Itoa(i)), err)
+		}
+	}
+	for _, p := range c.Ports {
+		if err := p.Validate(); err != nil {
+			return i18n.Errorf("config.port_invalid", cmp.Or(p.Name, p.Address), err)
+		}
+	}
+	for _, d := range c.DNS {
+		if err := d.Validate(); err != nil {
+			return i18n.Errorf("config.dns_invalid", cmp.Or(d.Name, d.Query), err)
+		}
+	}
+	if err := c.Host.Validate(); err != nil {
+		return i18n.Errorf("config.host_invalid", err)
+	}
+	for _, p := range c.Processes {
+		if err := p.Validate(); err != nil {
+			return i18n.Errorf("config.process_invalid", cmp.Or(p.Name, p.Process, p.Cmdline, p.PIDFile, p.User), err)
+		}
+	}
+	for _, s := range c.Systemd.Services {
+		if systemd.IsPattern(s) {
+			if err := systemd.ValidatePattern(s); err != nil {
+				return i18n.Errorf("config.systemd_invalid", err)
+			}
+		}
+	}
+	if err := c.Systemd.Failed.Validate(); err != nil {
+		return i18n.Errorf("config.systemd_invalid", err)
+	}
+	for _, t := range c.Systemd.Timers {
+		if err := t.Validate(); err != nil {
+			return i18n.Errorf("config.timer_invalid", cmp.Or(t.Name, t.Timer, t.Service), err)
+		}
+	}
+	for _, e := range c.Exec {
+		if err := e.Validate(); err != nil {
+			return i18n.Errorf("config.exec_invalid", cmp.Or(e.Name, e.Command), err)
+		}
+	}
+	for i, f := range c.Integrity {
+		if err := f.Validate(); err != nil {
+			return i18n.Errorf("config.integrity_invalid", cmp.Or(f.Name, strconv.Itoa(i)), err)
+		}
+	}
+	for _, a := range c.Auth {
+		if err := a.Validate(); err != nil {
+			return i18n.Errorf("config.auth_invalid", cmp.Or(a.Name, "auth"), err)
+		}
+	}
+	if c.Ban.Enabled {
+		if err := c.Ban.Validate(); err != nil {
+			return i18n.Errorf("config.ban_invalid", err)
+		}
+	}
+	if err := c.Server.Validate(); err != nil {
+		return err
+	}
+	if len(c.Heartbeats) > 0 && !c.Server.Enabled() {
+		return i18n.Errorf("config.heartbeat_server_missing")
+	}
+	tokens := make(map[string]bool)
+	for _, h := range c.Heartbeats {
+		name := cmp.Or(h.Name, h.Token)
+		if err := h.Validate(); err != nil {
+			return i18n.Errorf("config.heartbeat_invalid", name, err)
+		}
+		if tokens[h.Token] {
+			return i18n.Errorf("config.heartbeat_duplicate", name)
+		}
+		tokens[h.Token] = true
+	}
+	for i, r := range c.AllReceivers() {
+		if r.Name == "" {
+			return i18n.Errorf("config.receiver_name_missing", i)
+		}
+		if r.Type != "" && r.Type != "lark" {
+			return i18n.Errorf("config.receiver_type_unknown", r.Name, r.Type)
+		}
+		if _, err := r.Template(); err != nil {
+			return i18n.Errorf("config.receiver_invalid", r.Name, err)
+		}
+		if _, err := alert.ParseOversize(r.Oversize); err != nil {
+			return i18n.Errorf("config.receiver_invalid", r.Name, err)
+		}
+		if r.MaxMessageSize < 0 {
+			return i18n.Errorf("config.receiver_invalid", r.Name, i18n.Errorf("config.max_message_size_negative"))
+		}
+	}
+	return nil
+}
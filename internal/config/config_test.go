@@ -0,0 +1,31 @@
+package config
+
+import (
+	"hashcowuwu/lychee/internal/i18n"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadValidatesInConfiguredLanguage(t *testing.T) {
+	t.Cleanup(func() { i18n.SetLanguage(i18n.DefaultLanguage) })
+	tests := []struct {
+		language string
+		want     i18n.Language
+	}{
+		{"en", i18n.En},
+		{"zh-TW", i18n.ZhTW},
+		{"", i18n.ZhCN},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		data := "language: " + tt.language + "\ngroup:\n  groupWait: -1s\n"
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if want := i18n.Translate(tt.want, "config.group_negative"); err == nil || err.Error() != want {
+			t.Errorf("language %q: Load() error = %v, want %q", tt.language, err, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package executor 抽象外部命令的执行。需要运行系统命令的功能通过 Executor 调用，
+// 测试时可以替换为不需要 root 权限、也不依赖真实系统工具的实现，见 executortest。
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Executor 运行外部命令
+type Executor interface {
+	// Output 运行命令并返回标准输出。命令以非 0 状态退出时，可以用 ExitCode 从错误中取得退出码。
+	Output(ctx context.Context, name string, args ...string) ([]byte, error)
+	// Start 启动命令，通过返回的 Process 逐步读取标准输出，用于输出很多或持续输出的命令。
+	// ctx 结束时命令被终止。
+	Start(ctx context.Context, name string, args ...string) (Process, error)
+	// Run 按 cmd 的设置运行命令，把标准输出和标准错误写入 stdout 和 stderr，用于运行用户配置的脚本。
+	// 命令在独立的进程组中运行，ctx 结束时整个进程组被终止。
+	Run(ctx context.Context, cmd Command, stdout, stderr io.Writer) error
+}
+
+// Command 描述需要额外设置环境、工作目录或用户的命令
+type Command struct {
+	Name string
+	Args []string
+	Env  []string // 追加到当前进程的环境变量之后，格式为 KEY=value
+	Dir  string   // 工作目录，为空时使用当前目录
+	// Credential 不为 nil 时以该用户运行，需要以 root 运行，非 Unix 系统上被忽略
+	Credential *Credential
+}
+
+// Credential 是运行命令的用户和组
+type Credential struct {
+	UID, GID uint32
+	Groups   []uint32
+}
+
+// Process 是已经启动的命令，读取的是它的标准输出
+type Process interface {
+	io.Reader
+	// Wait 等待命令结束，必须在读完标准输出之后调用
+	Wait() error
+}
+
+// ExitCode 返回命令以非 0 状态退出时的退出码，err 不是此类错误时第二个返回值为 false
+func ExitCode(err error) (int, bool) {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), true
+	}
+	return 0, false
+}
+
+// System 直接在本机运行命令。Output 和 Start 的语言环境固定为 C，使命令的输出 (例如时间戳) 可以解析；
+// Run 运行的是用户的脚本，保持原来的环境。
+type System struct{}
+
+// Output 实现 Executor，非 0 退出时错误信息中附带标准错误的内容
+func (System) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	out, err := command(ctx, name, args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if stderr := bytes.TrimSpace(exitErr.Stderr); len(stderr) > 0 {
+			err = fmt.Errorf("%w: %s", err, stderr)
+		}
+	}
+	return out, err
+}
+
+// Start 实现 Executor
+func (System) Start(ctx context.Context, name string, args ...string) (Process, error) {
+	cmd := command(ctx, name, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &process{Reader: stdout, cmd: cmd}, nil
+}
+
+// Run 实现 Executor
+func (System) Run(ctx context.Context, c Command, stdout, stderr io.Writer) error {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Env = append(os.Environ(), c.Env...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	setProcAttr(cmd, c.Credential)
+	// 进程组中的子进程可能继承了输出管道，等待一段时间后不再读取
+	cmd.WaitDelay = time.Second
+	return cmd.Run()
+}
+
+func command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	return cmd
+}
+
+type process struct {
+	io.Reader
+	cmd *exec.Cmd
+}
+
+func (p *process) Wait() error {
+	return p.cmd.Wait()
+}
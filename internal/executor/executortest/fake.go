@@ -0,0 +1,135 @@
+// Package executortest 提供用于测试的 executor.Executor 实现，按命令行回放预先录制的输出和退出码。
+package executortest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hashcowuwu/lychee/internal/executor"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Response 是一次命令执行的录制结果
+type Response struct {
+	Stdout   string
+	Stderr   string // 只有 Run 会写出
+	ExitCode int    // 非 0 时返回 *ExitError
+	Err      error  // 命令无法运行时的错误，例如找不到可执行文件，优先于 ExitCode
+}
+
+// ExitError 是命令以非 0 状态退出的错误，可以用 executor.ExitCode 取得退出码
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode 返回退出码
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// Fake 按完整的命令行 (命令和参数以空格连接) 回放录制的结果。
+// 同一命令行录制了多个结果时依次返回，最后一个结果重复使用；没有录制的命令返回错误。
+type Fake struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	calls     []string
+}
+
+// New 创建没有任何录制结果的 Fake
+func New() *Fake {
+	return &Fake{responses: make(map[string][]Response)}
+}
+
+// On 为命令行录制结果，返回 f 以便链式调用
+func (f *Fake) On(cmdline string, responses ...Response) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[cmdline] = append(f.responses[cmdline], responses...)
+	return f
+}
+
+// Calls 返回已执行的命令行
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// Output 实现 executor.Executor
+func (f *Fake) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r, err := f.next(ctx, name, args)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(r.Stdout), r.err()
+}
+
+// Start 实现 executor.Executor，输出一次性全部可读
+func (f *Fake) Start(ctx context.Context, name string, args ...string) (executor.Process, error) {
+	r, err := f.next(ctx, name, args)
+	if err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return &process{Reader: bytes.NewReader([]byte(r.Stdout)), err: r.err()}, nil
+}
+
+// Run 实现 executor.Executor，只按命令名和参数匹配，不检查环境、工作目录和用户
+func (f *Fake) Run(ctx context.Context, cmd executor.Command, stdout, stderr io.Writer) error {
+	r, err := f.next(ctx, cmd.Name, cmd.Args)
+	if err != nil {
+		return err
+	}
+	if r.Err != nil {
+		return r.Err
+	}
+	io.WriteString(stdout, r.Stdout)
+	io.WriteString(stderr, r.Stderr)
+	return r.err()
+}
+
+// next 记录调用并取出下一个结果
+func (f *Fake) next(ctx context.Context, name string, args []string) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+	cmdline := strings.Join(append([]string{name}, args...), " ")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, cmdline)
+	queue := f.responses[cmdline]
+	if len(queue) == 0 {
+		return Response{}, fmt.Errorf("executortest: unexpected command %q", cmdline)
+	}
+	if len(queue) > 1 {
+		f.responses[cmdline] = queue[1:]
+	}
+	return queue[0], nil
+}
+
+func (r Response) err() error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if r.ExitCode != 0 {
+		return &ExitError{Code: r.ExitCode}
+	}
+	return nil
+}
+
+type process struct {
+	*bytes.Reader
+	err error
+}
+
+func (p *process) Wait() error {
+	return p.err
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package executor
+
+import "os/exec"
+
+// setProcAttr 在非 Unix 系统上不支持切换用户，ctx 结束时只结束命令本身
+func setProcAttr(cmd *exec.Cmd, cred *Credential) {}
@@ -0,0 +1,314 @@
+package i18n
+
+// en is the English message catalog
+var en = map[string]string{
+	"i18n.unsupported_language": "unsupported language %q (available: %s)",
+
+	// cmd/app
+	"main.config_load_failed":     "failed to load config: %v",
+	"main.language":               "using language: %s",
+	"main.check_interval_loaded":  "loaded checkInterval: %d",
+	"main.journal_setup":          "setting up journal monitoring for service [%s], %d rule(s)",
+	"main.journal_create_failed":  "warning: failed to create journal monitor for service [%s]: %v",
+	"main.logfile_setup":          "setting up log file monitoring [%s], paths: %v",
+	"main.logfile_create_failed":  "warning: failed to create log file monitor [%s]: %v",
+	"main.started":                "lychee monitor starting...",
+	"main.check_interval_default": "checkInterval is not positive, using default: %ds",
+	"main.ticker_interval":        "ticker interval: %v",
+	"main.check_round_start":      "starting a new round of checks...",
+	"main.check_result":           "monitor [%s]: success=%t, message=%s",
+	"main.all_ok":                 "all services are healthy.",
+	"main.no_receivers":           "warning: no notification receivers configured, alerts will only be logged",
+	"main.stream_started":         "streaming monitor [%s] started in background",
+	"main.monitor_setup":          "adding monitor: %s",
+	"main.ban_enabled":            "auto-ban enabled: %s",
+	"main.monitor_create_failed":  "failed to create monitor [%s]: %v",
+	"main.server_started":         "built-in HTTP server listening on %s",
+	"main.server_failed":          "failed to start the built-in HTTP server: %v",
+
+	// alert notifications
+	"alert.subject":     "🚨 Service Alert",
+	"alert.body_header": "The following services have problems:",
+	"alert.lasting":     "for %s",
+
+	"alert.group_sending": "group %s has %d alert(s), sending aggregated notification...",
+	"alert.notify_failed": "failed to send aggregated notification to receiver [%s]: %v",
+
+	"alert.template_subject_invalid": "invalid subject template: %w",
+	"alert.template_body_invalid":    "invalid body template: %w",
+	"alert.oversize_unknown":         "unsupported oversize mode %q (available: summarize, split)",
+	"alert.more_lines":               "… and %d more lines",
+
+	// config
+	"config.receiver_name_missing":     "receiver #%d has no name",
+	"config.receiver_type_unknown":     "receiver [%s] has unsupported type %q",
+	"config.receiver_invalid":          "receiver [%s] is invalid: %w",
+	"config.journal_invalid":           "journal config for service [%s] is invalid: %w",
+	"config.logfile_invalid":           "log file config [%s] is invalid: %w",
+	"config.http_invalid":              "HTTP probe [%s] is invalid: %w",
+	"config.tls_invalid":               "TLS certificate check [%s] is invalid: %w",
+	"config.port_invalid":              "port check [%s] is invalid: %w",
+	"config.dns_invalid":               "DNS check [%s] is invalid: %w",
+	"config.host_invalid":              "host resource monitoring is invalid: %w",
+	"config.process_invalid":           "process monitor [%s] is invalid: %w",
+	"config.timer_invalid":             "scheduled job [%s] is invalid: %w",
+	"config.systemd_invalid":           "invalid systemd configuration: %w",
+	"config.heartbeat_invalid":         "heartbeat [%s] is invalid: %w",
+	"config.heartbeat_duplicate":       "heartbeat [%s] reuses the token of another heartbeat",
+	"config.heartbeat_server_missing":  "server.listen must be set when heartbeats are configured",
+	"config.exec_invalid":              "check script [%s] is invalid: %w",
+	"config.integrity_invalid":         "file integrity monitor [%s] is invalid: %w",
+	"config.auth_invalid":              "auth analysis [%s] is invalid: %w",
+	"config.ban_invalid":               "auto-ban is invalid: %w",
+	"config.group_negative":            "group intervals must not be negative",
+	"config.group_all_exclusive":       "\"...\" in groupBy cannot be combined with other labels",
+	"config.max_message_size_negative": "maxMessageSize must not be negative",
+
+	// systemd
+	"systemd.inactive":               "service %s is not active or does not exist.",
+	"systemd.active":                 "service %s is running.",
+	"systemd.timer_unit_missing":     "at least one of timer and service must be set",
+	"systemd.timer_max_age_negative": "maxAge must not be negative",
+	"systemd.show_failed":            "failed to read the state of unit %s: %v",
+	"systemd.timer_inactive":         "timer %s is not active (state: %s), the job will not be triggered",
+	"systemd.timer_failed":           "the last run of %s failed: result=%s, exit code %s",
+	"systemd.timer_never":            "never",
+	"systemd.timer_missed":           "scheduled job [%s] has not succeeded within %s (last success: %s)",
+	"systemd.timer_ok":               "scheduled job [%s] OK, last success: %s, next run: %s",
+	"systemd.pattern_invalid":        "invalid unit pattern %q: %v",
+	"systemd.list_failed":            "failed to list systemd units: %v",
+	"systemd.pattern_empty":          "no loaded unit matches %s",
+	"systemd.unit_state":             "service %s is not running (state: %s/%s)",
+	"systemd.pattern_ok":             "all %[2]d units matching %[1]s are running",
+	"systemd.units_failed":           "%d units are in failed state:\n%s",
+	"systemd.units_ok":               "no unit is in failed state",
+	"systemd.system_state_failed":    "failed to read the system state: %v",
+	"systemd.system_state":           "system state is %s",
+	"systemd.system_state_empty":     "systemctl returned no output",
+	"systemd.system_ok":              "system state is normal: %s",
+
+	// journal
+	"journal.cursor_init_failed":  "note: failed to initialise cursor for service [%s] (new service without logs?): %v",
+	"journal.start_failed":        "service [%s]: failed to start journalctl: %v",
+	"journal.keyword_matched":     "service [%s] journal matched rule '%s': %s",
+	"journal.threshold_matched":   "service [%s] journal rule '%s' matched %d times (window %s, previous window %d), latest: %s",
+	"journal.exit_code":           "note: service [%s] journalctl exited with status %d",
+	"journal.wait_failed":         "error: service [%s] waiting for journalctl failed: %v",
+	"journal.follow_exited":       "service [%s] journalctl -f exited (%v), restarting from last cursor in %v",
+	"journal.follow_backpressure": "warning: service [%s] rules are falling behind, %d-line buffer is full, pausing journalctl reads",
+	"journal.follow_eof":          "journalctl output ended unexpectedly",
+	"journal.follow_down":         "journal follower for service [%s] is down: %v",
+	"journal.follow_ok":           "journal follower for service [%s] is running.",
+
+	// log rules
+	"rules.pattern_empty":      "rule [%s] has no pattern",
+	"rules.regex_invalid":      "rule [%s] has an invalid regex %q: %w",
+	"rules.threshold_negative": "rule [%s]: threshold and rateIncrease must not be negative",
+	"rules.window_missing":     "rule [%s] sets threshold but no window",
+	"rules.threshold_missing":  "rule [%s] uses groupBy or rateIncrease, which require threshold",
+	"rules.ban_needs_group":    "rule [%s] enables ban, which requires groupBy to capture the address",
+	"rules.group_needs_regex":  "rule [%s] uses groupBy, which requires regex: true",
+	"rules.group_unknown":      "rule [%s] has no capture group %q",
+	"rules.priority_invalid":   "invalid log priority %q",
+	"rules.invalid":            "rule [%s] is invalid: %w",
+	"rules.multiline_invalid":  "invalid multiline regex %q: %w",
+	"rules.parse_unknown":      "unsupported log parse format %q (available: json, logfmt)",
+	"rules.context_negative":   "context before, after and maxBytes must not be negative",
+	"rules.context_omitted":    "… (%d context lines omitted)",
+
+	// log files
+	"logfile.no_paths":          "no paths configured",
+	"logfile.bad_pattern":       "invalid path pattern %q: %w",
+	"logfile.state_load_failed": "warning: log file monitor [%s] cannot read state file %s: %v",
+	"logfile.state_save_failed": "warning: log file monitor [%s] cannot save state file %s: %v",
+	"logfile.truncated":         "log file monitor [%s]: %s was truncated or replaced, reading from the start",
+	"logfile.read_failed":       "log file monitor [%s]: failed to read %s: %v",
+	"logfile.catch_up_gzip":     "log file monitor [%s]: catching up unread lines from compressed rotated file %s",
+	"logfile.matched":           "log file [%s] %s matched rule '%s': %s",
+	"logfile.threshold_matched": "log file [%s] %s rule '%s' matched %d times (window %s, previous window %d), latest: %s",
+
+	// HTTP probes
+	"http.url_invalid":            "invalid URL %q, must start with http:// or https://",
+	"http.status_invalid":         "invalid expected status %q",
+	"http.regex_invalid":          "invalid body regex %q: %w",
+	"http.json_path_invalid":      "invalid JSON path %q",
+	"http.redirects_unknown":      "unsupported redirect policy %q (available: follow, none)",
+	"http.client_cert_incomplete": "certFile and keyFile must be set together",
+	"http.negative":               "timeouts, redirect counts and latency thresholds must not be negative",
+	"http.ca_invalid":             "failed to load CA certificate %s: %w",
+	"http.no_certificates":        "no PEM certificates found in file",
+	"http.client_cert_invalid":    "failed to load client certificate %s: %w",
+	"http.too_many_redirects":     "stopped after %d redirects",
+	"http.request_failed":         "HTTP probe [%s] request failed: %v",
+	"http.unexpected_status":      "HTTP probe [%s] returned status %d, expected %s",
+	"http.body_mismatch":          "HTTP probe [%s] response body does not match %q",
+	"http.json_invalid":           "HTTP probe [%s] response is not valid JSON: %v",
+	"http.json_missing":           "HTTP probe [%s] response has no JSON path %s",
+	"http.json_mismatch":          "HTTP probe [%s] JSON path %s is %q, expected %q",
+	"http.slow":                   "HTTP probe [%s] took %s, over the %s threshold",
+	"http.ok":                     "HTTP probe [%s] OK, status %d in %s",
+
+	// TLS certificates
+	"tls.source_required":   "exactly one of address and files must be set",
+	"tls.address_invalid":   "invalid address %q: %w",
+	"tls.pattern_invalid":   "invalid file pattern %q: %w",
+	"tls.days_invalid":      "warningDays and criticalDays must not be negative, and warningDays must not be less than criticalDays",
+	"tls.ca_invalid":        "failed to load CA certificate %s: %w",
+	"tls.no_certificates":   "no certificates in %s",
+	"tls.no_files":          "no certificate files match %v",
+	"tls.connect_failed":    "failed to connect to %s to read its certificate: %v",
+	"tls.read_failed":       "failed to read certificate file %s: %v",
+	"tls.expired":           "certificate %s (%s) expired on %s",
+	"tls.expiring":          "certificate %s (%s) expires in %d days (%s)",
+	"tls.hostname_mismatch": "certificate %s (%s) does not match host name %s",
+	"tls.verify_failed":     "certificate %s (%s) failed chain verification: %v",
+	"tls.ok":                "certificate check [%s] OK, the earliest expiry is in %d days",
+
+	// ports
+	"port.protocol_unknown": "unsupported protocol %q (available: tcp, udp)",
+	"port.address_invalid":  "invalid address %q: %w",
+	"port.regex_invalid":    "invalid expect regex %q: %w",
+	"port.timeout_negative": "timeout must not be negative",
+	"port.connect_failed":   "port check [%s] cannot connect to %s %s: %v",
+	"port.send_failed":      "port check [%s] failed to send data: %v",
+	"port.read_failed":      "port check [%s] received no response: %v",
+	"port.expect_mismatch":  "port check [%s] response %q does not match %q",
+	"port.ok":               "port check [%s] %s %s OK in %s",
+
+	// DNS
+	"dns.query_missing":    "no query to resolve",
+	"dns.type_unknown":     "unsupported record type %q (available: %s)",
+	"dns.timeout_negative": "timeout must not be negative",
+	"dns.server_invalid":   "invalid DNS server %q: expected an address or host name with an optional port",
+	"dns.name_invalid":     "invalid domain name %q",
+	"dns.response_invalid": "invalid DNS response",
+	"dns.rcode":            "server returned %s",
+	"dns.failed":           "DNS check [%s] failed to resolve %s %s: %v",
+	"dns.empty":            "DNS check [%s] got no answers for %s %s",
+	"dns.missing":          "DNS check [%s] answers %[4]v for %[2]s %[3]s are missing %[5]v",
+	"dns.ok":               "DNS check [%s] resolved %s %s to %v in %s",
+
+	// host resources
+	"host.threshold_invalid": "invalid %s threshold: values must not be negative and warning must not exceed critical",
+	"host.pattern_invalid":   "invalid mount point pattern %q: %w",
+	"host.period_unknown":    "unsupported load period %q (available: 1m, 5m, 15m)",
+	"host.unsupported":       "this check is not supported on this system",
+	"host.mounts_failed":     "failed to read the mount list: %v",
+	"host.statfs_failed":     "failed to get usage of mount point %s: %v",
+	"host.statfs_timeout":    "no response within %s, the mount may be hung",
+	"host.disk_usage":        "mount point %s (%s) is %.1f%% full, %s left, over the %g%% threshold",
+	"host.inode_usage":       "mount point %s (%s) has used %.1f%% of its inodes, over the %g%% threshold",
+	"host.disk_ok":           "space and inode usage OK on %d mount points",
+	"host.meminfo_failed":    "failed to read /proc/meminfo: %v",
+	"host.memory_usage":      "memory usage %.1f%% (%s available of %s), over the %g%% threshold",
+	"host.swap_usage":        "swap usage %.1f%% (%s free of %s), over the %g%% threshold",
+	"host.memory_ok":         "memory usage %.1f%%, swap usage %.1f%%",
+	"host.loadavg_failed":    "failed to read /proc/loadavg: %v",
+	"host.loadavg_malformed": "cannot parse /proc/loadavg: %q",
+	"host.load_high":         "%s load average %.2f is over the %.2f threshold (%d CPUs)",
+	"host.load_ok":           "load average %.2f %.2f %.2f",
+	"host.stat_failed":       "failed to read /proc/stat: %v",
+	"host.stat_malformed":    "no cpu line in /proc/stat",
+	"host.cpu_high":          "CPU usage %.1f%%, over the %g%% threshold",
+	"host.cpu_ok":            "CPU usage %.1f%%",
+	"host.cpu_baseline":      "recorded the CPU usage baseline, usage is reported from the next check",
+
+	// units
+	"units.size_invalid": "invalid size %q, e.g. 512MiB or 2G",
+
+	// processes
+	"process.match_missing":   "at least one of process, cmdline, user and pidFile must be set",
+	"process.regex_invalid":   "invalid cmdline regex %q: %w",
+	"process.limits_invalid":  "instance counts and resource limits must not be negative, and max must not be less than min",
+	"process.user_unknown":    "unknown user %q: %w",
+	"process.scan_failed":     "process monitor [%s] failed to scan processes: %v",
+	"process.pidfile_invalid": "invalid content in pid file %s: %w",
+	"process.too_few":         "process [%s] has %d running instances, fewer than the minimum of %d",
+	"process.too_many":        "process [%s] has %d running instances, more than the maximum of %d",
+	"process.zombie":          "process [%s] pid %d is a zombie",
+	"process.rss_high":        "process [%s] pid %d uses %s of resident memory, over the %s limit",
+	"process.threads_high":    "process [%s] pid %d has %d threads, over the limit of %d",
+	"process.fds_failed":      "process [%s] pid %d: cannot count file descriptors: %v",
+	"process.fds_high":        "process [%s] pid %d has %d open file descriptors, over the limit of %d",
+	"process.ok":              "process [%s] OK with %d running instances",
+
+	// server
+	"server.listen_invalid": "invalid listen address %q: %v",
+	"server.serve_failed":   "built-in HTTP server stopped: %v",
+
+	// heartbeat
+	"heartbeat.token_invalid":     "token must be at least 8 characters of letters, digits, - and _",
+	"heartbeat.period_invalid":    "period must be greater than 0",
+	"heartbeat.grace_negative":    "grace must not be negative",
+	"heartbeat.state_load_failed": "failed to load the state of heartbeat [%s]: %v",
+	"heartbeat.state_save_failed": "failed to save the state of heartbeat [%s]: %v",
+	"heartbeat.never":             "never",
+	"heartbeat.failed":            "job [%s] reported a failure (at %s)",
+	"heartbeat.missed":            "job [%s] has not pinged within %s (grace %s, last ping: %s)",
+	"heartbeat.running_long":      "job [%s] started at %s and has not finished within %s",
+	"heartbeat.ok":                "job [%s] heartbeat OK, last ping: %s",
+
+	// exec
+	"exec.command_missing":  "command must be set",
+	"exec.env_invalid":      "environment variable %q must have the form KEY=value",
+	"exec.timeout_negative": "timeout must not be negative",
+	"exec.user_unknown":     "unknown user %s: %v",
+	"exec.timeout":          "check script [%s] did not finish within %s",
+	"exec.run_failed":       "failed to run check script [%s]: %v",
+	"exec.no_output":        "check script [%s] printed nothing, exit code %d",
+
+	// integrity
+	"integrity.paths_missing":     "at least one path must be set",
+	"integrity.pattern_invalid":   "invalid path pattern %q: %v",
+	"integrity.state_load_failed": "failed to load the baseline of [%s]: %v",
+	"integrity.state_save_failed": "failed to save the baseline of [%s]: %v",
+	"integrity.baseline_created":  "[%s] baseline created with %d files",
+	"integrity.watch_failed":      "[%s] cannot watch for file changes, changes are only found by periodic checks: %v",
+	"integrity.read_failed":       "failed to read %s: %v",
+	"integrity.added":             "file %s was added (mode %s, owner %d:%d)",
+	"integrity.removed":           "file %s was deleted",
+	"integrity.modified":          "file %s was modified: %s",
+	"integrity.change_content":    "content sha256 %s → %s",
+	"integrity.change_mode":       "mode %s → %s",
+	"integrity.change_owner":      "owner %d:%d → %d:%d",
+	"integrity.change_mtime":      "mtime %s → %s",
+	"integrity.diff_too_large":    "%s changed too much to produce a diff",
+	"integrity.ok":                "[%s] %d files unchanged",
+
+	// auth
+	"auth.limits_invalid":         "window and maxKnownIPs must not be negative",
+	"auth.network_invalid":        "invalid address or network %q: %v",
+	"auth.state_load_failed":      "failed to load the known addresses of [%s]: %v",
+	"auth.state_save_failed":      "failed to save the known addresses of [%s]: %v",
+	"auth.read_failed":            "[%s] failed to read the auth logs: %v",
+	"auth.exit_code":              "[%s] journalctl exit code %d",
+	"auth.brute_force":            "possible brute force: %s failed to authenticate %d times within %s, users tried: %s",
+	"auth.success_after_failures": "%s logged in from %s via %s after %d failures from that address within %s, the account may be compromised",
+	"auth.new_ip":                 "%s logged in from never-seen address %s via %s",
+	"auth.sudo_failed":            "%s entered a wrong password for sudo as %s: %s",
+	"auth.sudo_denied":            "%s is not in sudoers and tried to run: %s",
+	"auth.ok":                     "[%s] no suspicious authentication, %d known addresses",
+
+	// ban
+	"ban.backend_unknown":   "unsupported ban backend %q (available: nftables, iptables)",
+	"ban.duration_negative": "duration must not be negative",
+	"ban.whitelist_invalid": "invalid whitelist address or network %q: %v",
+	"ban.ipv6_disabled":     "set6 is not configured, cannot ban IPv6 addresses",
+	"ban.ipv6_skipped":      "set6 is not configured, not banning IPv6 address %s (triggered by %s); further IPv6 addresses are skipped silently",
+	"ban.state_load_failed": "failed to load the ban records: %v",
+	"ban.state_save_failed": "failed to save the ban records: %v",
+	"ban.banned":            "banned %s for %s, triggered by %s",
+	"ban.ban_failed":        "failed to ban %s (triggered by %s): %v",
+	"ban.restore_failed":    "failed to re-apply the ban on %s: %v",
+	"ban.unbanned":          "unbanned %s (triggered by %s, banned at %s)",
+	"ban.unban_failed":      "failed to unban %s: %v",
+	"ban.ok":                "%d addresses currently banned",
+
+	// lark
+	"lark.no_webhook":      "no Lark webhook URL configured, cannot send notification",
+	"lark.marshal_failed":  "failed to marshal Lark message: %w",
+	"lark.request_failed":  "failed to create Lark request (%s): %w",
+	"lark.send_failed":     "failed to send Lark message (%s): %w",
+	"lark.bad_status":      "failed to send Lark message (%s), status: %d, response: %s",
+	"lark.partial_failure": "failed to send to some or all Lark webhooks: %v",
+}
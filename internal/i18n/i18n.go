@@ -0,0 +1,117 @@
+// Package i18n 提供告警与日志消息的多语言目录。
+//
+// 所有面向用户的字符串都通过 key 在目录中查找，当前语言由配置中的
+// `language` 字段决定。某个语言缺少 key 时回退到简体中文，仍找不到时
+// 直接返回 key 本身，方便发现遗漏的翻译。
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Language 表示一种受支持的语言
+type Language string
+
+const (
+	ZhCN Language = "zh-CN" // 简体中文
+	ZhTW Language = "zh-TW" // 繁體中文
+	En   Language = "en"    // English
+)
+
+// DefaultLanguage 是未配置 language 时使用的语言
+const DefaultLanguage = ZhCN
+
+var catalogs = map[Language]map[string]string{
+	ZhCN: zhCN,
+	ZhTW: zhTW,
+	En:   en,
+}
+
+// aliases 记录常见的语言写法，统一使用小写
+var aliases = map[string]Language{
+	"zh":      ZhCN,
+	"zh-hans": ZhCN,
+	"zh-hant": ZhTW,
+	"zh-hk":   ZhTW,
+	"en-us":   En,
+	"en-gb":   En,
+}
+
+var (
+	mu      sync.RWMutex
+	current = DefaultLanguage
+)
+
+// Parse 将配置中的语言名解析为 Language，不区分大小写，`_` 与 `-` 等价。
+// 空字符串返回默认语言。
+func Parse(name string) (Language, error) {
+	if name == "" {
+		return DefaultLanguage, nil
+	}
+	norm := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", "-"))
+	for lang := range catalogs {
+		if strings.ToLower(string(lang)) == norm {
+			return lang, nil
+		}
+	}
+	if lang, ok := aliases[norm]; ok {
+		return lang, nil
+	}
+	return "", Errorf("i18n.unsupported_language", name, strings.Join(Supported(), ", "))
+}
+
+// Supported 返回所有受支持的语言名，按字母排序
+func Supported() []string {
+	names := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		names = append(names, string(lang))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetLanguage 设置全局使用的语言
+func SetLanguage(lang Language) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = lang
+}
+
+// Current 返回当前使用的语言
+func Current() Language {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// T 使用当前语言翻译 key，args 非空时按 fmt.Sprintf 格式化
+func T(key string, args ...any) string {
+	return Translate(Current(), key, args...)
+}
+
+// Translate 使用指定语言翻译 key，args 非空时按 fmt.Sprintf 格式化
+func Translate(lang Language, key string, args ...any) string {
+	msg := lookup(lang, key)
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Errorf 使用当前语言的目录条目作为格式串创建错误，支持 %w
+func Errorf(key string, args ...any) error {
+	return fmt.Errorf(lookup(Current(), key), args...)
+}
+
+func lookup(lang Language, key string) string {
+	if msg, ok := catalogs[lang][key]; ok {
+		return msg
+	}
+	if msg, ok := catalogs[DefaultLanguage][key]; ok {
+		return msg
+	}
+	return key
+}
@@ -0,0 +1,314 @@
+package i18n
+
+// zhCN 是简体中文消息目录，也是其他语言缺少条目时的回退目录
+var zhCN = map[string]string{
+	"i18n.unsupported_language": "不支持的语言 %q (可选: %s)",
+
+	// cmd/app
+	"main.config_load_failed":     "无法加载配置: %v",
+	"main.language":               "使用语言: %s",
+	"main.check_interval_loaded":  "加载的 checkInterval: %d",
+	"main.journal_setup":          "为服务 [%s] 设置 journal 日志监控, 规则数: %d",
+	"main.journal_create_failed":  "警告: 无法为服务 [%s] 创建 journal 监控器: %v",
+	"main.logfile_setup":          "为日志文件 [%s] 设置跟踪监控, 路径: %v",
+	"main.logfile_create_failed":  "警告: 无法为日志文件 [%s] 创建监控器: %v",
+	"main.started":                "运维监控工具启动...",
+	"main.check_interval_default": "checkInterval 非正数，使用默认值: %ds",
+	"main.ticker_interval":        "计时器间隔: %v",
+	"main.check_round_start":      "开始执行新一轮监控检查...",
+	"main.check_result":           "监控器 [%s]: 状态=%t, 消息=%s",
+	"main.all_ok":                 "所有服务状态正常。",
+	"main.no_receivers":           "警告: 没有配置任何通知接收方，告警只会写入日志",
+	"main.stream_started":         "串流监控器 [%s] 已在后台启动",
+	"main.monitor_setup":          "添加监控: %s",
+	"main.ban_enabled":            "已启用自动封禁: %s",
+	"main.monitor_create_failed":  "创建监控 [%s] 失败: %v",
+	"main.server_started":         "内置 HTTP 服务已监听 %s",
+	"main.server_failed":          "无法启动内置 HTTP 服务: %v",
+
+	// 告警通知
+	"alert.subject":     "🚨 服务异常告警",
+	"alert.body_header": "以下服务出现异常:",
+	"alert.lasting":     "已持续 %s",
+
+	"alert.group_sending": "分组 %s 中有 %d 条告警，准备发送聚合通知...",
+	"alert.notify_failed": "向接收方 [%s] 发送聚合通知失败: %v",
+
+	"alert.template_subject_invalid": "标题模板无效: %w",
+	"alert.template_body_invalid":    "正文模板无效: %w",
+	"alert.oversize_unknown":         "不支持的超长消息处理方式 %q (可选: summarize, split)",
+	"alert.more_lines":               "… 还有 %d 行未显示",
+
+	// 配置
+	"config.receiver_name_missing":     "第 %d 个接收方缺少 name",
+	"config.receiver_type_unknown":     "接收方 [%s] 的类型 %q 不受支持",
+	"config.receiver_invalid":          "接收方 [%s] 配置无效: %w",
+	"config.journal_invalid":           "服务 [%s] 的 journal 配置无效: %w",
+	"config.logfile_invalid":           "日志文件 [%s] 的配置无效: %w",
+	"config.http_invalid":              "HTTP 探测 [%s] 的配置无效: %w",
+	"config.tls_invalid":               "证书检查 [%s] 的配置无效: %w",
+	"config.port_invalid":              "端口检查 [%s] 的配置无效: %w",
+	"config.dns_invalid":               "DNS 检查 [%s] 的配置无效: %w",
+	"config.host_invalid":              "主机资源监控的配置无效: %w",
+	"config.process_invalid":           "进程监控 [%s] 的配置无效: %w",
+	"config.timer_invalid":             "定时任务 [%s] 的配置无效: %w",
+	"config.systemd_invalid":           "systemd 配置无效: %w",
+	"config.heartbeat_invalid":         "心跳 [%s] 的配置无效: %w",
+	"config.heartbeat_duplicate":       "心跳 [%s] 的 token 与其他心跳重复",
+	"config.heartbeat_server_missing":  "配置了心跳时需要设置 server.listen",
+	"config.exec_invalid":              "检查脚本 [%s] 的配置无效: %w",
+	"config.integrity_invalid":         "文件完整性监控 [%s] 的配置无效: %w",
+	"config.auth_invalid":              "认证日志分析 [%s] 的配置无效: %w",
+	"config.ban_invalid":               "自动封禁的配置无效: %w",
+	"config.group_negative":            "group 中的时间间隔不能为负数",
+	"config.group_all_exclusive":       "groupBy 中的 \"...\" 不能与其他标签同时使用",
+	"config.max_message_size_negative": "maxMessageSize 不能为负数",
+
+	// systemd
+	"systemd.inactive":               "服务 %s 状态异常或不存在。",
+	"systemd.active":                 "服务 %s 运行正常。",
+	"systemd.timer_unit_missing":     "timer 和 service 至少需要配置一个",
+	"systemd.timer_max_age_negative": "maxAge 不能为负数",
+	"systemd.show_failed":            "无法读取单元 %s 的状态: %v",
+	"systemd.timer_inactive":         "定时器 %s 未激活 (状态: %s)，任务不会再被触发",
+	"systemd.timer_failed":           "定时任务 %s 上次运行失败: result=%s，退出码 %s",
+	"systemd.timer_never":            "从未成功",
+	"systemd.timer_missed":           "定时任务 [%s] 超过 %s 没有成功运行 (上次成功: %s)",
+	"systemd.timer_ok":               "定时任务 [%s] 正常，上次成功: %s，下次运行: %s",
+	"systemd.pattern_invalid":        "无效的单元模式 %q: %v",
+	"systemd.list_failed":            "无法列出 systemd 单元: %v",
+	"systemd.pattern_empty":          "没有已加载的单元匹配 %s",
+	"systemd.unit_state":             "服务 %s 未运行 (状态: %s/%s)",
+	"systemd.pattern_ok":             "匹配 %s 的 %d 个单元都在运行",
+	"systemd.units_failed":           "%d 个单元处于 failed 状态:\n%s",
+	"systemd.units_ok":               "没有处于 failed 状态的单元",
+	"systemd.system_state_failed":    "无法读取系统状态: %v",
+	"systemd.system_state":           "系统状态为 %s",
+	"systemd.system_state_empty":     "systemctl 没有输出",
+	"systemd.system_ok":              "系统状态正常: %s",
+
+	// journal
+	"journal.cursor_init_failed":  "注意: 为服务 [%s] 初始化 cursor 失败 (可能是新服务无日志): %v",
+	"journal.start_failed":        "服务 [%s] 无法启动 journalctl: %v",
+	"journal.keyword_matched":     "服务 [%s] journal 日志命中规则 '%s': %s",
+	"journal.threshold_matched":   "服务 [%s] journal 规则 '%s' 命中 %d 次 (窗口 %s，上一窗口 %d 次)，最近一条: %s",
+	"journal.exit_code":           "注意: 服务 [%s] journalctl 命令退出，状态码: %d",
+	"journal.wait_failed":         "错误: 服务 [%s] journalctl 命令等待失败: %v",
+	"journal.follow_exited":       "服务 [%s] journalctl -f 已退出 (%v)，%v 后从上次的 cursor 重新启动",
+	"journal.follow_backpressure": "警告: 服务 [%s] 日志处理跟不上，%d 行缓冲区已满，暂停读取 journalctl",
+	"journal.follow_eof":          "journalctl 输出意外结束",
+	"journal.follow_down":         "服务 [%s] 的 journal 实时跟随已中断: %v",
+	"journal.follow_ok":           "服务 [%s] 的 journal 实时跟随运行正常。",
+
+	// 日志规则
+	"rules.pattern_empty":      "规则 [%s] 缺少 pattern",
+	"rules.regex_invalid":      "规则 [%s] 的正则表达式 %q 无效: %w",
+	"rules.threshold_negative": "规则 [%s] 的 threshold 和 rateIncrease 不能为负数",
+	"rules.window_missing":     "规则 [%s] 设置了 threshold，但缺少 window",
+	"rules.threshold_missing":  "规则 [%s] 使用 groupBy 或 rateIncrease 时必须设置 threshold",
+	"rules.ban_needs_group":    "规则 [%s] 启用了 ban，需要同时设置 groupBy 捕获地址",
+	"rules.group_needs_regex":  "规则 [%s] 使用 groupBy 时必须设置 regex: true",
+	"rules.group_unknown":      "规则 [%s] 的正则表达式中没有捕获组 %q",
+	"rules.priority_invalid":   "无效的日志优先级 %q",
+	"rules.invalid":            "规则 [%s] 无效: %w",
+	"rules.multiline_invalid":  "多行合并的正则表达式 %q 无效: %w",
+	"rules.parse_unknown":      "不支持的日志解析格式 %q (可选: json, logfmt)",
+	"rules.context_negative":   "上下文的 before、after 和 maxBytes 不能为负数",
+	"rules.context_omitted":    "… (省略 %d 行上下文)",
+
+	// 日志文件
+	"logfile.no_paths":          "没有配置 paths",
+	"logfile.bad_pattern":       "路径模式 %q 无效: %w",
+	"logfile.state_load_failed": "警告: 日志文件 [%s] 无法读取状态文件 %s: %v",
+	"logfile.state_save_failed": "警告: 日志文件 [%s] 无法保存状态文件 %s: %v",
+	"logfile.truncated":         "日志文件 [%s] %s 被截断或替换，从头开始读取",
+	"logfile.read_failed":       "日志文件 [%s] 读取 %s 失败: %v",
+	"logfile.catch_up_gzip":     "日志文件 [%s] 从已压缩的轮转文件 %s 补读未处理的内容",
+	"logfile.matched":           "日志文件 [%s] %s 命中规则 '%s': %s",
+	"logfile.threshold_matched": "日志文件 [%s] %s 规则 '%s' 命中 %d 次 (窗口 %s，上一窗口 %d 次)，最近一条: %s",
+
+	// HTTP 探测
+	"http.url_invalid":            "无效的 URL %q，需要 http:// 或 https:// 开头",
+	"http.status_invalid":         "无效的期望状态码 %q",
+	"http.regex_invalid":          "无效的正文正则表达式 %q: %w",
+	"http.json_path_invalid":      "无效的 JSON 路径 %q",
+	"http.redirects_unknown":      "不支持的重定向策略 %q (可选: follow, none)",
+	"http.client_cert_incomplete": "certFile 和 keyFile 需要同时配置",
+	"http.negative":               "超时、重定向次数和耗时阈值不能为负数",
+	"http.ca_invalid":             "无法加载 CA 证书 %s: %w",
+	"http.no_certificates":        "文件中没有 PEM 证书",
+	"http.client_cert_invalid":    "无法加载客户端证书 %s: %w",
+	"http.too_many_redirects":     "重定向超过 %d 次",
+	"http.request_failed":         "HTTP 探测 [%s] 请求失败: %v",
+	"http.unexpected_status":      "HTTP 探测 [%s] 返回状态码 %d，期望 %s",
+	"http.body_mismatch":          "HTTP 探测 [%s] 的响应正文不匹配 %q",
+	"http.json_invalid":           "HTTP 探测 [%s] 的响应不是有效的 JSON: %v",
+	"http.json_missing":           "HTTP 探测 [%s] 的响应中没有 JSON 路径 %s",
+	"http.json_mismatch":          "HTTP 探测 [%s] 的 JSON 路径 %s 的值为 %q，期望 %q",
+	"http.slow":                   "HTTP 探测 [%s] 耗时 %s，超过阈值 %s",
+	"http.ok":                     "HTTP 探测 [%s] 正常，状态码 %d，耗时 %s",
+
+	// TLS 证书
+	"tls.source_required":   "address 和 files 需要且只能配置其中一个",
+	"tls.address_invalid":   "无效的地址 %q: %w",
+	"tls.pattern_invalid":   "无效的文件模式 %q: %w",
+	"tls.days_invalid":      "warningDays 和 criticalDays 不能为负数，且 warningDays 不能小于 criticalDays",
+	"tls.ca_invalid":        "无法加载 CA 证书 %s: %w",
+	"tls.no_certificates":   "%s 中没有证书",
+	"tls.no_files":          "没有找到匹配 %v 的证书文件",
+	"tls.connect_failed":    "无法连接 %s 读取证书: %v",
+	"tls.read_failed":       "无法读取证书文件 %s: %v",
+	"tls.expired":           "证书 %s (%s) 已于 %s 过期",
+	"tls.expiring":          "证书 %s (%s) 将在 %d 天后过期 (%s)",
+	"tls.hostname_mismatch": "证书 %s (%s) 与主机名 %s 不匹配",
+	"tls.verify_failed":     "证书 %s (%s) 证书链校验失败: %v",
+	"tls.ok":                "证书 [%s] 正常，最近的过期时间在 %d 天后",
+
+	// 端口
+	"port.protocol_unknown": "不支持的协议 %q (可选: tcp, udp)",
+	"port.address_invalid":  "无效的地址 %q: %w",
+	"port.regex_invalid":    "无效的 expect 正则表达式 %q: %w",
+	"port.timeout_negative": "timeout 不能为负数",
+	"port.connect_failed":   "端口检查 [%s] 无法连接 %s %s: %v",
+	"port.send_failed":      "端口检查 [%s] 发送数据失败: %v",
+	"port.read_failed":      "端口检查 [%s] 没有收到响应: %v",
+	"port.expect_mismatch":  "端口检查 [%s] 的响应 %q 不匹配 %q",
+	"port.ok":               "端口检查 [%s] %s %s 正常，耗时 %s",
+
+	// DNS
+	"dns.query_missing":    "没有配置要解析的 query",
+	"dns.type_unknown":     "不支持的记录类型 %q (可选: %s)",
+	"dns.timeout_negative": "timeout 不能为负数",
+	"dns.server_invalid":   "无效的 DNS 服务器 %q: 应为地址或主机名，可以带端口",
+	"dns.name_invalid":     "无效的域名 %q",
+	"dns.response_invalid": "无效的 DNS 响应",
+	"dns.rcode":            "服务器返回 %s",
+	"dns.failed":           "DNS 检查 [%s] 解析 %s %s 失败: %v",
+	"dns.empty":            "DNS 检查 [%s] 解析 %s %s 没有结果",
+	"dns.missing":          "DNS 检查 [%s] 解析 %s %s 的结果 %v 中缺少 %v",
+	"dns.ok":               "DNS 检查 [%s] 解析 %s %s 正常: %v，耗时 %s",
+
+	// 主机资源
+	"host.threshold_invalid": "%s 的阈值无效: 不能为负数，且 warning 不能大于 critical",
+	"host.pattern_invalid":   "无效的挂载点模式 %q: %w",
+	"host.period_unknown":    "不支持的负载周期 %q (可选: 1m, 5m, 15m)",
+	"host.unsupported":       "当前系统不支持该检查",
+	"host.mounts_failed":     "无法读取挂载列表: %v",
+	"host.statfs_failed":     "无法获取挂载点 %s 的使用情况: %v",
+	"host.statfs_timeout":    "%s 内没有响应，挂载可能已挂起",
+	"host.disk_usage":        "挂载点 %s (%s) 空间使用率 %.1f%%，剩余 %s，超过阈值 %g%%",
+	"host.inode_usage":       "挂载点 %s (%s) inode 使用率 %.1f%%，超过阈值 %g%%",
+	"host.disk_ok":           "%d 个挂载点的空间和 inode 使用率正常",
+	"host.meminfo_failed":    "无法读取 /proc/meminfo: %v",
+	"host.memory_usage":      "内存使用率 %.1f%% (可用 %s，共 %s)，超过阈值 %g%%",
+	"host.swap_usage":        "交换空间使用率 %.1f%% (剩余 %s，共 %s)，超过阈值 %g%%",
+	"host.memory_ok":         "内存使用率 %.1f%%，交换空间使用率 %.1f%%",
+	"host.loadavg_failed":    "无法读取 /proc/loadavg: %v",
+	"host.loadavg_malformed": "无法解析 /proc/loadavg: %q",
+	"host.load_high":         "%s 平均负载 %.2f，超过阈值 %.2f (CPU 核数 %d)",
+	"host.load_ok":           "平均负载 %.2f %.2f %.2f",
+	"host.stat_failed":       "无法读取 /proc/stat: %v",
+	"host.stat_malformed":    "/proc/stat 中没有 cpu 行",
+	"host.cpu_high":          "CPU 使用率 %.1f%%，超过阈值 %g%%",
+	"host.cpu_ok":            "CPU 使用率 %.1f%%",
+	"host.cpu_baseline":      "已记录 CPU 使用率基准，下一轮检查开始计算",
+
+	// 单位
+	"units.size_invalid": "无效的大小 %q，例如 512MiB、2G",
+
+	// 进程
+	"process.match_missing":   "process、cmdline、user 和 pidFile 至少需要配置一个",
+	"process.regex_invalid":   "无效的 cmdline 正则表达式 %q: %w",
+	"process.limits_invalid":  "实例数和资源上限不能为负数，且 max 不能小于 min",
+	"process.user_unknown":    "未知的用户 %q: %w",
+	"process.scan_failed":     "进程监控 [%s] 扫描进程失败: %v",
+	"process.pidfile_invalid": "pid 文件 %s 的内容无效: %w",
+	"process.too_few":         "进程 [%s] 运行实例数为 %d，少于最少 %d 个",
+	"process.too_many":        "进程 [%s] 运行实例数为 %d，多于最多 %d 个",
+	"process.zombie":          "进程 [%s] pid %d 处于僵尸状态",
+	"process.rss_high":        "进程 [%s] pid %d 常驻内存 %s，超过上限 %s",
+	"process.threads_high":    "进程 [%s] pid %d 有 %d 个线程，超过上限 %d",
+	"process.fds_failed":      "进程 [%s] pid %d 无法统计文件描述符: %v",
+	"process.fds_high":        "进程 [%s] pid %d 打开了 %d 个文件描述符，超过上限 %d",
+	"process.ok":              "进程 [%s] 正常，运行实例数 %d",
+
+	// server
+	"server.listen_invalid": "无效的监听地址 %q: %v",
+	"server.serve_failed":   "内置 HTTP 服务异常退出: %v",
+
+	// heartbeat
+	"heartbeat.token_invalid":     "token 至少需要 8 个字符，只能包含字母、数字、- 和 _",
+	"heartbeat.period_invalid":    "period 必须大于 0",
+	"heartbeat.grace_negative":    "grace 不能为负数",
+	"heartbeat.state_load_failed": "无法读取心跳 [%s] 的状态: %v",
+	"heartbeat.state_save_failed": "无法保存心跳 [%s] 的状态: %v",
+	"heartbeat.never":             "从未收到",
+	"heartbeat.failed":            "任务 [%s] 报告运行失败 (时间: %s)",
+	"heartbeat.missed":            "任务 [%s] 超过 %s (宽限 %s) 没有发送心跳 (上次: %s)",
+	"heartbeat.running_long":      "任务 [%s] 于 %s 开始运行，超过 %s 仍未结束",
+	"heartbeat.ok":                "任务 [%s] 心跳正常，上次: %s",
+
+	// exec
+	"exec.command_missing":  "需要配置 command",
+	"exec.env_invalid":      "环境变量 %q 的格式应为 KEY=value",
+	"exec.timeout_negative": "timeout 不能为负数",
+	"exec.user_unknown":     "未知用户 %s: %v",
+	"exec.timeout":          "检查脚本 [%s] 超过 %s 未结束",
+	"exec.run_failed":       "无法运行检查脚本 [%s]: %v",
+	"exec.no_output":        "检查脚本 [%s] 没有输出，退出码 %d",
+
+	// integrity
+	"integrity.paths_missing":     "至少需要配置一个路径",
+	"integrity.pattern_invalid":   "无效的路径模式 %q: %v",
+	"integrity.state_load_failed": "无法读取 [%s] 的基线: %v",
+	"integrity.state_save_failed": "无法保存 [%s] 的基线: %v",
+	"integrity.baseline_created":  "[%s] 已建立基线，共 %d 个文件",
+	"integrity.watch_failed":      "[%s] 无法监听文件变化，只在定时检查时发现修改: %v",
+	"integrity.read_failed":       "无法读取 %s: %v",
+	"integrity.added":             "新增文件 %s (权限 %s，所有者 %d:%d)",
+	"integrity.removed":           "文件 %s 被删除",
+	"integrity.modified":          "文件 %s 被修改: %s",
+	"integrity.change_content":    "内容 sha256 %s → %s",
+	"integrity.change_mode":       "权限 %s → %s",
+	"integrity.change_owner":      "所有者 %d:%d → %d:%d",
+	"integrity.change_mtime":      "修改时间 %s → %s",
+	"integrity.diff_too_large":    "%s 的改动太多，无法生成差异",
+	"integrity.ok":                "[%s] %d 个文件没有变化",
+
+	// auth
+	"auth.limits_invalid":         "window 和 maxKnownIPs 不能为负数",
+	"auth.network_invalid":        "无效的地址或网段 %q: %v",
+	"auth.state_load_failed":      "无法读取 [%s] 的已知地址: %v",
+	"auth.state_save_failed":      "无法保存 [%s] 的已知地址: %v",
+	"auth.read_failed":            "[%s] 无法读取认证日志: %v",
+	"auth.exit_code":              "[%s] journalctl 退出码 %d",
+	"auth.brute_force":            "疑似暴力破解: %s 在 %[3]s 内认证失败 %[2]d 次，尝试的用户: %[4]s",
+	"auth.success_after_failures": "%s 从 %s 通过 %s 登录成功，此前 %[5]s 内该地址认证失败 %[4]d 次，账号可能已被破解",
+	"auth.new_ip":                 "%s 从未见过的地址 %s 通过 %s 登录成功",
+	"auth.sudo_failed":            "%s 以 %s 身份执行 sudo 时密码错误: %s",
+	"auth.sudo_denied":            "%s 不在 sudoers 中，尝试执行: %s",
+	"auth.ok":                     "[%s] 没有发现异常认证，已知地址 %d 个",
+
+	// ban
+	"ban.backend_unknown":   "不支持的封禁方式 %q (可用: nftables, iptables)",
+	"ban.duration_negative": "duration 不能为负数",
+	"ban.whitelist_invalid": "白名单中无效的地址或网段 %q: %v",
+	"ban.ipv6_disabled":     "没有配置 set6，无法封禁 IPv6 地址",
+	"ban.ipv6_skipped":      "没有配置 set6，不封禁 IPv6 地址 %s (由 %s 触发)，之后的 IPv6 地址不再记录",
+	"ban.state_load_failed": "无法读取封禁记录: %v",
+	"ban.state_save_failed": "无法保存封禁记录: %v",
+	"ban.banned":            "已封禁 %s，时长 %s，触发者: %s",
+	"ban.ban_failed":        "无法封禁 %s (触发者: %s): %v",
+	"ban.restore_failed":    "无法重新应用对 %s 的封禁: %v",
+	"ban.unbanned":          "已解除对 %s 的封禁 (触发者: %s，封禁于 %s)",
+	"ban.unban_failed":      "无法解除对 %s 的封禁: %v",
+	"ban.ok":                "当前封禁 %d 个地址",
+
+	// lark
+	"lark.no_webhook":      "没有配置飞书 Webhook URL，无法发送通知",
+	"lark.marshal_failed":  "序列化飞书消息失败: %w",
+	"lark.request_failed":  "创建飞书请求失败 (%s): %w",
+	"lark.send_failed":     "发送飞书消息失败 (%s): %w",
+	"lark.bad_status":      "发送飞书消息失败 (%s)，状态码: %d, 响应: %s",
+	"lark.partial_failure": "向部分或所有飞书 Webhook 发送消息失败: %v",
+}
@@ -0,0 +1,314 @@
+package i18n
+
+// zhTW 是繁體中文消息目錄
+var zhTW = map[string]string{
+	"i18n.unsupported_language": "不支援的語言 %q (可選: %s)",
+
+	// cmd/app
+	"main.config_load_failed":     "無法載入設定: %v",
+	"main.language":               "使用語言: %s",
+	"main.check_interval_loaded":  "載入的 checkInterval: %d",
+	"main.journal_setup":          "為服務 [%s] 設置 journal 日誌監控, 規則數: %d",
+	"main.journal_create_failed":  "警告: 無法為服務 [%s] 創建 journal 監控器: %v",
+	"main.logfile_setup":          "為日誌文件 [%s] 設置跟蹤監控, 路徑: %v",
+	"main.logfile_create_failed":  "警告: 無法為日誌文件 [%s] 創建監控器: %v",
+	"main.started":                "運維監控工具啟動...",
+	"main.check_interval_default": "checkInterval 非正數，使用預設值: %ds",
+	"main.ticker_interval":        "計時器間隔: %v",
+	"main.check_round_start":      "開始執行新一輪監控檢查...",
+	"main.check_result":           "監控器 [%s]: 狀態=%t, 訊息=%s",
+	"main.all_ok":                 "所有服務狀態正常。",
+	"main.no_receivers":           "警告: 沒有設定任何通知接收方，告警只會寫入日誌",
+	"main.stream_started":         "串流監控器 [%s] 已在背景啟動",
+	"main.monitor_setup":          "新增監控: %s",
+	"main.ban_enabled":            "已啟用自動封鎖: %s",
+	"main.monitor_create_failed":  "建立監控 [%s] 失敗: %v",
+	"main.server_started":         "內建 HTTP 服務已監聽 %s",
+	"main.server_failed":          "無法啟動內建 HTTP 服務: %v",
+
+	// 告警通知
+	"alert.subject":     "🚨 服務異常告警",
+	"alert.body_header": "以下服務出現異常:",
+	"alert.lasting":     "已持續 %s",
+
+	"alert.group_sending": "分組 %s 中有 %d 條告警，準備發送聚合通知...",
+	"alert.notify_failed": "向接收方 [%s] 發送聚合通知失敗: %v",
+
+	"alert.template_subject_invalid": "標題模板無效: %w",
+	"alert.template_body_invalid":    "正文模板無效: %w",
+	"alert.oversize_unknown":         "不支援的超長訊息處理方式 %q (可選: summarize, split)",
+	"alert.more_lines":               "… 還有 %d 行未顯示",
+
+	// 設定
+	"config.receiver_name_missing":     "第 %d 個接收方缺少 name",
+	"config.receiver_type_unknown":     "接收方 [%s] 的類型 %q 不受支援",
+	"config.receiver_invalid":          "接收方 [%s] 設定無效: %w",
+	"config.journal_invalid":           "服務 [%s] 的 journal 設定無效: %w",
+	"config.logfile_invalid":           "日誌文件 [%s] 的設定無效: %w",
+	"config.http_invalid":              "HTTP 探測 [%s] 的設定無效: %w",
+	"config.tls_invalid":               "憑證檢查 [%s] 的設定無效: %w",
+	"config.port_invalid":              "連接埠檢查 [%s] 的設定無效: %w",
+	"config.dns_invalid":               "DNS 檢查 [%s] 的設定無效: %w",
+	"config.host_invalid":              "主機資源監控的設定無效: %w",
+	"config.process_invalid":           "行程監控 [%s] 的設定無效: %w",
+	"config.timer_invalid":             "定時任務 [%s] 的設定無效: %w",
+	"config.systemd_invalid":           "systemd 設定無效: %w",
+	"config.heartbeat_invalid":         "心跳 [%s] 的設定無效: %w",
+	"config.heartbeat_duplicate":       "心跳 [%s] 的 token 與其他心跳重複",
+	"config.heartbeat_server_missing":  "設定了心跳時需要設定 server.listen",
+	"config.exec_invalid":              "檢查腳本 [%s] 的設定無效: %w",
+	"config.integrity_invalid":         "檔案完整性監控 [%s] 的設定無效: %w",
+	"config.auth_invalid":              "認證日誌分析 [%s] 的設定無效: %w",
+	"config.ban_invalid":               "自動封鎖的設定無效: %w",
+	"config.group_negative":            "group 中的時間間隔不能為負數",
+	"config.group_all_exclusive":       "groupBy 中的 \"...\" 不能與其他標籤同時使用",
+	"config.max_message_size_negative": "maxMessageSize 不能為負數",
+
+	// systemd
+	"systemd.inactive":               "服務 %s 狀態異常或不存在。",
+	"systemd.active":                 "服務 %s 運行正常。",
+	"systemd.timer_unit_missing":     "timer 和 service 至少需要設定一個",
+	"systemd.timer_max_age_negative": "maxAge 不能為負數",
+	"systemd.show_failed":            "無法讀取單元 %s 的狀態: %v",
+	"systemd.timer_inactive":         "計時器 %s 未啟用 (狀態: %s)，任務不會再被觸發",
+	"systemd.timer_failed":           "定時任務 %s 上次執行失敗: result=%s，結束碼 %s",
+	"systemd.timer_never":            "從未成功",
+	"systemd.timer_missed":           "定時任務 [%s] 超過 %s 沒有成功執行 (上次成功: %s)",
+	"systemd.timer_ok":               "定時任務 [%s] 正常，上次成功: %s，下次執行: %s",
+	"systemd.pattern_invalid":        "無效的單元模式 %q: %v",
+	"systemd.list_failed":            "無法列出 systemd 單元: %v",
+	"systemd.pattern_empty":          "沒有已載入的單元符合 %s",
+	"systemd.unit_state":             "服務 %s 未執行 (狀態: %s/%s)",
+	"systemd.pattern_ok":             "符合 %s 的 %d 個單元都在執行",
+	"systemd.units_failed":           "%d 個單元處於 failed 狀態:\n%s",
+	"systemd.units_ok":               "沒有處於 failed 狀態的單元",
+	"systemd.system_state_failed":    "無法讀取系統狀態: %v",
+	"systemd.system_state":           "系統狀態為 %s",
+	"systemd.system_state_empty":     "systemctl 沒有輸出",
+	"systemd.system_ok":              "系統狀態正常: %s",
+
+	// journal
+	"journal.cursor_init_failed":  "注意: 為服務 [%s] 初始化 cursor 失敗 (可能是新服務無日誌): %v",
+	"journal.start_failed":        "服務 [%s] 無法啟動 journalctl: %v",
+	"journal.keyword_matched":     "服務 [%s] journal 日誌命中規則 '%s': %s",
+	"journal.threshold_matched":   "服務 [%s] journal 規則 '%s' 命中 %d 次 (窗口 %s，上一窗口 %d 次)，最近一條: %s",
+	"journal.exit_code":           "注意: 服務 [%s] journalctl 命令退出，狀態碼: %d",
+	"journal.wait_failed":         "錯誤: 服務 [%s] journalctl 命令等待失敗: %v",
+	"journal.follow_exited":       "服務 [%s] journalctl -f 已退出 (%v)，%v 後從上次的 cursor 重新啟動",
+	"journal.follow_backpressure": "警告: 服務 [%s] 日誌處理跟不上，%d 行緩衝區已滿，暫停讀取 journalctl",
+	"journal.follow_eof":          "journalctl 輸出意外結束",
+	"journal.follow_down":         "服務 [%s] 的 journal 即時跟隨已中斷: %v",
+	"journal.follow_ok":           "服務 [%s] 的 journal 即時跟隨運行正常。",
+
+	// 日誌規則
+	"rules.pattern_empty":      "規則 [%s] 缺少 pattern",
+	"rules.regex_invalid":      "規則 [%s] 的正則表達式 %q 無效: %w",
+	"rules.threshold_negative": "規則 [%s] 的 threshold 和 rateIncrease 不能為負數",
+	"rules.window_missing":     "規則 [%s] 設置了 threshold，但缺少 window",
+	"rules.threshold_missing":  "規則 [%s] 使用 groupBy 或 rateIncrease 時必須設置 threshold",
+	"rules.ban_needs_group":    "規則 [%s] 啟用了 ban，需要同時設定 groupBy 擷取位址",
+	"rules.group_needs_regex":  "規則 [%s] 使用 groupBy 時必須設置 regex: true",
+	"rules.group_unknown":      "規則 [%s] 的正則表達式中沒有捕獲組 %q",
+	"rules.priority_invalid":   "無效的日誌優先級 %q",
+	"rules.invalid":            "規則 [%s] 無效: %w",
+	"rules.multiline_invalid":  "多行合併的正則表達式 %q 無效: %w",
+	"rules.parse_unknown":      "不支援的日誌解析格式 %q (可選: json, logfmt)",
+	"rules.context_negative":   "上下文的 before、after 和 maxBytes 不能為負數",
+	"rules.context_omitted":    "… (省略 %d 行上下文)",
+
+	// 日誌文件
+	"logfile.no_paths":          "沒有設定 paths",
+	"logfile.bad_pattern":       "路徑模式 %q 無效: %w",
+	"logfile.state_load_failed": "警告: 日誌文件 [%s] 無法讀取狀態文件 %s: %v",
+	"logfile.state_save_failed": "警告: 日誌文件 [%s] 無法保存狀態文件 %s: %v",
+	"logfile.truncated":         "日誌文件 [%s] %s 被截斷或替換，從頭開始讀取",
+	"logfile.read_failed":       "日誌文件 [%s] 讀取 %s 失敗: %v",
+	"logfile.catch_up_gzip":     "日誌文件 [%s] 從已壓縮的輪轉文件 %s 補讀未處理的內容",
+	"logfile.matched":           "日誌文件 [%s] %s 命中規則 '%s': %s",
+	"logfile.threshold_matched": "日誌文件 [%s] %s 規則 '%s' 命中 %d 次 (窗口 %s，上一窗口 %d 次)，最近一條: %s",
+
+	// HTTP 探測
+	"http.url_invalid":            "無效的 URL %q，需要以 http:// 或 https:// 開頭",
+	"http.status_invalid":         "無效的預期狀態碼 %q",
+	"http.regex_invalid":          "無效的正文正規表示式 %q: %w",
+	"http.json_path_invalid":      "無效的 JSON 路徑 %q",
+	"http.redirects_unknown":      "不支援的重新導向策略 %q (可選: follow, none)",
+	"http.client_cert_incomplete": "certFile 和 keyFile 需要同時設定",
+	"http.negative":               "逾時、重新導向次數和耗時閾值不能為負數",
+	"http.ca_invalid":             "無法載入 CA 憑證 %s: %w",
+	"http.no_certificates":        "檔案中沒有 PEM 憑證",
+	"http.client_cert_invalid":    "無法載入用戶端憑證 %s: %w",
+	"http.too_many_redirects":     "重新導向超過 %d 次",
+	"http.request_failed":         "HTTP 探測 [%s] 請求失敗: %v",
+	"http.unexpected_status":      "HTTP 探測 [%s] 回傳狀態碼 %d，預期 %s",
+	"http.body_mismatch":          "HTTP 探測 [%s] 的回應正文不符合 %q",
+	"http.json_invalid":           "HTTP 探測 [%s] 的回應不是有效的 JSON: %v",
+	"http.json_missing":           "HTTP 探測 [%s] 的回應中沒有 JSON 路徑 %s",
+	"http.json_mismatch":          "HTTP 探測 [%s] 的 JSON 路徑 %s 的值為 %q，預期 %q",
+	"http.slow":                   "HTTP 探測 [%s] 耗時 %s，超過閾值 %s",
+	"http.ok":                     "HTTP 探測 [%s] 正常，狀態碼 %d，耗時 %s",
+
+	// TLS 憑證
+	"tls.source_required":   "address 和 files 需要且只能設定其中一個",
+	"tls.address_invalid":   "無效的位址 %q: %w",
+	"tls.pattern_invalid":   "無效的檔案模式 %q: %w",
+	"tls.days_invalid":      "warningDays 和 criticalDays 不能為負數，且 warningDays 不能小於 criticalDays",
+	"tls.ca_invalid":        "無法載入 CA 憑證 %s: %w",
+	"tls.no_certificates":   "%s 中沒有憑證",
+	"tls.no_files":          "沒有找到符合 %v 的憑證檔案",
+	"tls.connect_failed":    "無法連線 %s 讀取憑證: %v",
+	"tls.read_failed":       "無法讀取憑證檔案 %s: %v",
+	"tls.expired":           "憑證 %s (%s) 已於 %s 過期",
+	"tls.expiring":          "憑證 %s (%s) 將在 %d 天後過期 (%s)",
+	"tls.hostname_mismatch": "憑證 %s (%s) 與主機名稱 %s 不符",
+	"tls.verify_failed":     "憑證 %s (%s) 憑證鏈驗證失敗: %v",
+	"tls.ok":                "憑證 [%s] 正常，最近的過期時間在 %d 天後",
+
+	// 連接埠
+	"port.protocol_unknown": "不支援的協定 %q (可選: tcp, udp)",
+	"port.address_invalid":  "無效的位址 %q: %w",
+	"port.regex_invalid":    "無效的 expect 正規表示式 %q: %w",
+	"port.timeout_negative": "timeout 不能為負數",
+	"port.connect_failed":   "連接埠檢查 [%s] 無法連線 %s %s: %v",
+	"port.send_failed":      "連接埠檢查 [%s] 傳送資料失敗: %v",
+	"port.read_failed":      "連接埠檢查 [%s] 沒有收到回應: %v",
+	"port.expect_mismatch":  "連接埠檢查 [%s] 的回應 %q 不符合 %q",
+	"port.ok":               "連接埠檢查 [%s] %s %s 正常，耗時 %s",
+
+	// DNS
+	"dns.query_missing":    "沒有設定要解析的 query",
+	"dns.type_unknown":     "不支援的記錄類型 %q (可選: %s)",
+	"dns.timeout_negative": "timeout 不能為負數",
+	"dns.server_invalid":   "無效的 DNS 伺服器 %q: 應為位址或主機名稱，可以帶連接埠",
+	"dns.name_invalid":     "無效的網域名稱 %q",
+	"dns.response_invalid": "無效的 DNS 回應",
+	"dns.rcode":            "伺服器回傳 %s",
+	"dns.failed":           "DNS 檢查 [%s] 解析 %s %s 失敗: %v",
+	"dns.empty":            "DNS 檢查 [%s] 解析 %s %s 沒有結果",
+	"dns.missing":          "DNS 檢查 [%s] 解析 %s %s 的結果 %v 中缺少 %v",
+	"dns.ok":               "DNS 檢查 [%s] 解析 %s %s 正常: %v，耗時 %s",
+
+	// 主機資源
+	"host.threshold_invalid": "%s 的閾值無效: 不能為負數，且 warning 不能大於 critical",
+	"host.pattern_invalid":   "無效的掛載點模式 %q: %w",
+	"host.period_unknown":    "不支援的負載週期 %q (可選: 1m, 5m, 15m)",
+	"host.unsupported":       "目前的系統不支援此檢查",
+	"host.mounts_failed":     "無法讀取掛載清單: %v",
+	"host.statfs_failed":     "無法取得掛載點 %s 的使用情況: %v",
+	"host.statfs_timeout":    "%s 內沒有回應，掛載可能已停滯",
+	"host.disk_usage":        "掛載點 %s (%s) 空間使用率 %.1f%%，剩餘 %s，超過閾值 %g%%",
+	"host.inode_usage":       "掛載點 %s (%s) inode 使用率 %.1f%%，超過閾值 %g%%",
+	"host.disk_ok":           "%d 個掛載點的空間和 inode 使用率正常",
+	"host.meminfo_failed":    "無法讀取 /proc/meminfo: %v",
+	"host.memory_usage":      "記憶體使用率 %.1f%% (可用 %s，共 %s)，超過閾值 %g%%",
+	"host.swap_usage":        "交換空間使用率 %.1f%% (剩餘 %s，共 %s)，超過閾值 %g%%",
+	"host.memory_ok":         "記憶體使用率 %.1f%%，交換空間使用率 %.1f%%",
+	"host.loadavg_failed":    "無法讀取 /proc/loadavg: %v",
+	"host.loadavg_malformed": "無法解析 /proc/loadavg: %q",
+	"host.load_high":         "%s 平均負載 %.2f，超過閾值 %.2f (CPU 核心數 %d)",
+	"host.load_ok":           "平均負載 %.2f %.2f %.2f",
+	"host.stat_failed":       "無法讀取 /proc/stat: %v",
+	"host.stat_malformed":    "/proc/stat 中沒有 cpu 行",
+	"host.cpu_high":          "CPU 使用率 %.1f%%，超過閾值 %g%%",
+	"host.cpu_ok":            "CPU 使用率 %.1f%%",
+	"host.cpu_baseline":      "已記錄 CPU 使用率基準，下一輪檢查開始計算",
+
+	// 單位
+	"units.size_invalid": "無效的大小 %q，例如 512MiB、2G",
+
+	// 行程
+	"process.match_missing":   "process、cmdline、user 和 pidFile 至少需要設定一個",
+	"process.regex_invalid":   "無效的 cmdline 正規表示式 %q: %w",
+	"process.limits_invalid":  "實例數和資源上限不能為負數，且 max 不能小於 min",
+	"process.user_unknown":    "未知的使用者 %q: %w",
+	"process.scan_failed":     "行程監控 [%s] 掃描行程失敗: %v",
+	"process.pidfile_invalid": "pid 檔案 %s 的內容無效: %w",
+	"process.too_few":         "行程 [%s] 執行實例數為 %d，少於最少 %d 個",
+	"process.too_many":        "行程 [%s] 執行實例數為 %d，多於最多 %d 個",
+	"process.zombie":          "行程 [%s] pid %d 處於殭屍狀態",
+	"process.rss_high":        "行程 [%s] pid %d 常駐記憶體 %s，超過上限 %s",
+	"process.threads_high":    "行程 [%s] pid %d 有 %d 個執行緒，超過上限 %d",
+	"process.fds_failed":      "行程 [%s] pid %d 無法統計檔案描述符: %v",
+	"process.fds_high":        "行程 [%s] pid %d 開啟了 %d 個檔案描述符，超過上限 %d",
+	"process.ok":              "行程 [%s] 正常，執行實例數 %d",
+
+	// server
+	"server.listen_invalid": "無效的監聽位址 %q: %v",
+	"server.serve_failed":   "內建 HTTP 服務異常結束: %v",
+
+	// heartbeat
+	"heartbeat.token_invalid":     "token 至少需要 8 個字元，只能包含字母、數字、- 和 _",
+	"heartbeat.period_invalid":    "period 必須大於 0",
+	"heartbeat.grace_negative":    "grace 不能為負數",
+	"heartbeat.state_load_failed": "無法讀取心跳 [%s] 的狀態: %v",
+	"heartbeat.state_save_failed": "無法儲存心跳 [%s] 的狀態: %v",
+	"heartbeat.never":             "從未收到",
+	"heartbeat.failed":            "任務 [%s] 回報執行失敗 (時間: %s)",
+	"heartbeat.missed":            "任務 [%s] 超過 %s (寬限 %s) 沒有傳送心跳 (上次: %s)",
+	"heartbeat.running_long":      "任務 [%s] 於 %s 開始執行，超過 %s 仍未結束",
+	"heartbeat.ok":                "任務 [%s] 心跳正常，上次: %s",
+
+	// exec
+	"exec.command_missing":  "需要設定 command",
+	"exec.env_invalid":      "環境變數 %q 的格式應為 KEY=value",
+	"exec.timeout_negative": "timeout 不能為負數",
+	"exec.user_unknown":     "未知使用者 %s: %v",
+	"exec.timeout":          "檢查腳本 [%s] 超過 %s 未結束",
+	"exec.run_failed":       "無法執行檢查腳本 [%s]: %v",
+	"exec.no_output":        "檢查腳本 [%s] 沒有輸出，結束碼 %d",
+
+	// integrity
+	"integrity.paths_missing":     "至少需要設定一個路徑",
+	"integrity.pattern_invalid":   "無效的路徑模式 %q: %v",
+	"integrity.state_load_failed": "無法讀取 [%s] 的基準: %v",
+	"integrity.state_save_failed": "無法儲存 [%s] 的基準: %v",
+	"integrity.baseline_created":  "[%s] 已建立基準，共 %d 個檔案",
+	"integrity.watch_failed":      "[%s] 無法監聽檔案變化，只在定時檢查時發現修改: %v",
+	"integrity.read_failed":       "無法讀取 %s: %v",
+	"integrity.added":             "新增檔案 %s (權限 %s，擁有者 %d:%d)",
+	"integrity.removed":           "檔案 %s 被刪除",
+	"integrity.modified":          "檔案 %s 被修改: %s",
+	"integrity.change_content":    "內容 sha256 %s → %s",
+	"integrity.change_mode":       "權限 %s → %s",
+	"integrity.change_owner":      "擁有者 %d:%d → %d:%d",
+	"integrity.change_mtime":      "修改時間 %s → %s",
+	"integrity.diff_too_large":    "%s 的改動太多，無法產生差異",
+	"integrity.ok":                "[%s] %d 個檔案沒有變化",
+
+	// auth
+	"auth.limits_invalid":         "window 和 maxKnownIPs 不能為負數",
+	"auth.network_invalid":        "無效的位址或網段 %q: %v",
+	"auth.state_load_failed":      "無法讀取 [%s] 的已知位址: %v",
+	"auth.state_save_failed":      "無法儲存 [%s] 的已知位址: %v",
+	"auth.read_failed":            "[%s] 無法讀取認證日誌: %v",
+	"auth.exit_code":              "[%s] journalctl 結束碼 %d",
+	"auth.brute_force":            "疑似暴力破解: %s 在 %[3]s 內認證失敗 %[2]d 次，嘗試的使用者: %[4]s",
+	"auth.success_after_failures": "%s 從 %s 透過 %s 登入成功，此前 %[5]s 內該位址認證失敗 %[4]d 次，帳號可能已被破解",
+	"auth.new_ip":                 "%s 從未見過的位址 %s 透過 %s 登入成功",
+	"auth.sudo_failed":            "%s 以 %s 身分執行 sudo 時密碼錯誤: %s",
+	"auth.sudo_denied":            "%s 不在 sudoers 中，嘗試執行: %s",
+	"auth.ok":                     "[%s] 沒有發現異常認證，已知位址 %d 個",
+
+	// ban
+	"ban.backend_unknown":   "不支援的封鎖方式 %q (可用: nftables, iptables)",
+	"ban.duration_negative": "duration 不能為負數",
+	"ban.whitelist_invalid": "白名單中無效的位址或網段 %q: %v",
+	"ban.ipv6_disabled":     "沒有設定 set6，無法封鎖 IPv6 位址",
+	"ban.ipv6_skipped":      "沒有設定 set6，不封鎖 IPv6 位址 %s (由 %s 觸發)，之後的 IPv6 位址不再記錄",
+	"ban.state_load_failed": "無法讀取封鎖紀錄: %v",
+	"ban.state_save_failed": "無法儲存封鎖紀錄: %v",
+	"ban.banned":            "已封鎖 %s，時長 %s，觸發者: %s",
+	"ban.ban_failed":        "無法封鎖 %s (觸發者: %s): %v",
+	"ban.restore_failed":    "無法重新套用對 %s 的封鎖: %v",
+	"ban.unbanned":          "已解除對 %s 的封鎖 (觸發者: %s，封鎖於 %s)",
+	"ban.unban_failed":      "無法解除對 %s 的封鎖: %v",
+	"ban.ok":                "目前封鎖 %d 個位址",
+
+	// lark
+	"lark.no_webhook":      "沒有設定飛書 Webhook URL，無法發送通知",
+	"lark.marshal_failed":  "序列化飛書訊息失敗: %w",
+	"lark.request_failed":  "創建飛書請求失敗 (%s): %w",
+	"lark.send_failed":     "發送飛書訊息失敗 (%s): %w",
+	"lark.bad_status":      "發送飛書訊息失敗 (%s)，狀態碼: %d, 回應: %s",
+	"lark.partial_failure": "向部分或所有飛書 Webhook 發送訊息失敗: %v",
+}
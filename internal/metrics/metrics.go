@@ -0,0 +1,160 @@
+// Package metrics 保存监控器产生的数值指标，并以 Prometheus 文本格式在内置 HTTP 服务的 /metrics 下提供。
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Registry 保存所有指标，所有方法都可以并发调用，nil 的 *Registry 会忽略写入
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// family 是同名的一组指标
+type family struct {
+	help   string
+	series map[string]series // 以格式化后的标签为键
+}
+
+// series 是一条带标签的指标
+type series struct {
+	labels map[string]string
+	value  float64
+}
+
+// NewRegistry 创建空的指标集合
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Set 设置指标的值，name 中不允许的字符会被替换为 _
+func (r *Registry) Set(name, help string, labels map[string]string, value float64) {
+	if r == nil {
+		return
+	}
+	name = SanitizeName(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{help: help, series: make(map[string]series)}
+		r.families[name] = f
+	}
+	f.series[formatLabels(labels)] = series{labels: maps.Clone(labels), value: value}
+}
+
+// DeleteMatching 删除所有包含 match 中全部标签的指标，
+// 监控器在写入新一轮结果前调用，避免已经消失的指标一直保留旧值
+func (r *Registry) DeleteMatching(match map[string]string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, f := range r.families {
+		for key, s := range f.series {
+			if containsLabels(s.labels, match) {
+				delete(f.series, key)
+			}
+		}
+		if len(f.series) == 0 {
+			delete(r.families, name)
+		}
+	}
+}
+
+// Write 以 Prometheus 文本格式输出所有指标，按名称和标签排序
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bw := bufio.NewWriter(w)
+	for _, name := range slices.Sorted(maps.Keys(r.families)) {
+		f := r.families[name]
+		if f.help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(f.help))
+		}
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", name)
+		for _, key := range slices.Sorted(maps.Keys(f.series)) {
+			fmt.Fprintf(bw, "%s%s %s\n", name, key, formatValue(f.series[key].value))
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP 实现 http.Handler
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// SanitizeName 把任意字符串转换为合法的指标或标签名
+func SanitizeName(name string) string {
+	name = invalidNameChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+func containsLabels(labels, match map[string]string) bool {
+	for k, v := range match {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// formatLabels 按名称排序格式化标签，例如 {check="disk",label="/"}
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, k := range slices.Sorted(maps.Keys(labels)) {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(SanitizeName(k))
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabel(labels[k]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	r.Set("lychee_exec_status", "Exit status.", map[string]string{"check": "disk"}, 1)
+	r.Set("lychee_exec_status", "Exit status.", map[string]string{"check": "backup"}, 0)
+	r.Set("lychee_exec_perfdata", "Performance data.", map[string]string{"check": "disk", "label": "/var"}, 0.75)
+	r.Set("lychee_exec_perfdata", "Performance data.", map[string]string{"check": "disk", "label": `a"b\c` + "\nd"}, math.Inf(1))
+	r.Set("1st-metric.name", "Multi\nline \\ help.", nil, math.NaN())
+
+	var sb strings.Builder
+	if err := r.Write(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP _1st_metric_name Multi\nline \\ help.
+# TYPE _1st_metric_name gauge
+_1st_metric_name NaN
+# HELP lychee_exec_perfdata Performance data.
+# TYPE lychee_exec_perfdata gauge
+lychee_exec_perfdata{check="disk",label="/var"} 0.75
+lychee_exec_perfdata{check="disk",label="a\"b\\c\nd"} +Inf
+# HELP lychee_exec_status Exit status.
+# TYPE lychee_exec_status gauge
+lychee_exec_status{check="backup"} 0
+lychee_exec_status{check="disk"} 1
+`
+	if got := sb.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDeleteMatching(t *testing.T) {
+	r := NewRegistry()
+	r.Set("a", "", map[string]string{"check": "disk", "label": "/"}, 1)
+	r.Set("a", "", map[string]string{"check": "load"}, 2)
+	r.Set("b", "", map[string]string{"check": "disk"}, 3)
+	r.DeleteMatching(map[string]string{"check": "disk"})
+
+	var sb strings.Builder
+	r.Write(&sb)
+	if want := "# TYPE a gauge\na{check=\"load\"} 2\n"; sb.String() != want {
+		t.Errorf("after DeleteMatching:\n%s\nwant\n%s", sb.String(), want)
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *Registry
+	r.Set("a", "", nil, 1)
+	r.DeleteMatching(map[string]string{"check": "disk"})
+}
+
+func TestServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.Set("up", "", nil, 1)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if want := "# TYPE up gauge\nup 1\n"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"lychee_up", "lychee_up"},
+		{"disk-usage./", "disk_usage__"},
+		{"9lives", "_9lives"},
+		{"", "_"},
+	}
+	for _, tt := range tests {
+		if got := SanitizeName(tt.in); got != tt.want {
+			t.Errorf("SanitizeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+// Package auth 分析 sshd 和 sudo 的 journal 日志: 把日志解析为结构化的认证事件，按来源地址聚合，
+// 检测暴力破解、多次失败后登录成功、从未见过的地址登录以及 sudo 失败。
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hashcowuwu/lychee/internal/executor"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/monitor/journal"
+	"hashcowuwu/lychee/internal/netutil"
+	"hashcowuwu/lychee/internal/state"
+	"log"
+	"maps"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 未配置时使用的默认值
+const (
+	DefaultWindow               = 10 * time.Minute
+	DefaultBruteForce           = 10
+	DefaultSuccessAfterFailures = 5
+	DefaultMaxKnownIPs          = 10000
+)
+
+// identifiers 是读取的 SYSLOG_IDENTIFIER，OpenSSH 9.8 起认证日志来自 sshd-session
+var identifiers = []string{"sshd", "sshd-session", "sudo"}
+
+// maxUsers 是告警中列出的尝试用户名的最大数量
+const maxUsers = 5
+
+// Config 描述认证日志分析，阈值为 0 时使用默认值，为负数时关闭对应的检测
+type Config struct {
+	Name                 string        `yaml:"name"`                 // 默认 auth
+	Window               time.Duration `yaml:"window"`               // 统计失败次数的时间窗口，默认 10m
+	BruteForce           int           `yaml:"bruteForce"`           // 同一来源在窗口内失败达到该次数时告警，默认 10
+	SuccessAfterFailures int           `yaml:"successAfterFailures"` // 同一来源在窗口内失败达到该次数后登录成功时告警，默认 5
+	NewIP                bool          `yaml:"newIP"`                // 从未见过的地址登录成功时告警
+	TrustedNetworks      []string      `yaml:"trustedNetworks"`      // 不参与检测的地址或网段，例如 10.0.0.0/8
+	MaxKnownIPs          int           `yaml:"maxKnownIPs"`          // 保存的已知地址数量上限，超出时删除最久未登录的，默认 10000
+}
+
+// Validate 检查配置
+func (c Config) Validate() error {
+	if c.Window < 0 || c.MaxKnownIPs < 0 {
+		return i18n.Errorf("auth.limits_invalid")
+	}
+	for _, n := range c.TrustedNetworks {
+		if _, err := netutil.ParseNetwork(n); err != nil {
+			return i18n.Errorf("auth.network_invalid", n, err)
+		}
+	}
+	return nil
+}
+
+// knownIP 是一个登录成功过的来源地址
+type knownIP struct {
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Users     []string  `json:"users"`
+}
+
+// persisted 是跨重启保留的状态
+type persisted struct {
+	Cursor string              `json:"cursor"`
+	Known  map[string]*knownIP `json:"known"`
+}
+
+// failure 是窗口内的一次认证失败
+type failure struct {
+	time time.Time
+	user string
+}
+
+// finding 是一次检查中发现的问题
+type finding struct {
+	message  string
+	severity monitor.Severity
+	event    Event
+	offender bool // 来源地址应当被封禁
+}
+
+// AuthMonitor 周期性读取新的认证日志并检测异常
+type AuthMonitor struct {
+	cfg       Config
+	name      string
+	exec      executor.Executor
+	statePath string
+	trusted   []netip.Prefix
+
+	st       persisted
+	started  bool                 // 已完成第一次读取；之后即使 cursor 仍为空也分析读到的日志
+	failures map[string][]failure // 按来源地址记录窗口内的失败
+	alerted  map[string]time.Time // 最近一次报告暴力破解的时间，窗口内不重复报告
+}
+
+// New 创建认证日志分析监控器并读取已知地址，journalctl 通过 exec 执行；statePath 为空时不持久化
+func New(cfg Config, exec executor.Executor, statePath string) (*AuthMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Window == 0 {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.BruteForce == 0 {
+		cfg.BruteForce = DefaultBruteForce
+	}
+	if cfg.SuccessAfterFailures == 0 {
+		cfg.SuccessAfterFailures = DefaultSuccessAfterFailures
+	}
+	if cfg.MaxKnownIPs == 0 {
+		cfg.MaxKnownIPs = DefaultMaxKnownIPs
+	}
+	m := &AuthMonitor{
+		cfg:       cfg,
+		name:      cmp.Or(cfg.Name, "auth"),
+		exec:      exec,
+		statePath: statePath,
+		failures:  make(map[string][]failure),
+		alerted:   make(map[string]time.Time),
+	}
+	for _, n := range cfg.TrustedNetworks {
+		p, _ := netutil.ParseNetwork(n)
+		m.trusted = append(m.trusted, p)
+	}
+	if statePath != "" {
+		if err := state.Load(statePath, &m.st); err != nil {
+			log.Print(i18n.T("auth.state_load_failed", m.name, err))
+		}
+	}
+	if m.st.Known == nil {
+		m.st.Known = make(map[string]*knownIP)
+	}
+	return m, nil
+}
+
+// Name 返回监控器名称
+func (m *AuthMonitor) Name() string {
+	return fmt.Sprintf("auth(%s)", m.name)
+}
+
+// Check 读取上次位置之后的 sshd 和 sudo 日志并分析。
+// 第一次运行时只记录当前位置，不回放历史日志；
+// 第一次读取时还没有相关日志的话，之后读到的都是新日志。
+func (m *AuthMonitor) Check() monitor.Result {
+	labels := map[string]string{"type": "auth", "service": m.name}
+	args := []string{"-o", "json", "--no-pager"}
+	for _, id := range identifiers {
+		args = append(args, "-t", id)
+	}
+	first := m.st.Cursor == "" && !m.started
+	switch {
+	case first:
+		args = append(args, "-n", "1")
+	case m.st.Cursor != "":
+		args = append(args, "--after-cursor", m.st.Cursor)
+	}
+	out, err := m.exec.Output(context.Background(), "journalctl", args...)
+	code, exited := executor.ExitCode(err)
+	switch {
+	case exited:
+		// 没有符合条件的日志时部分版本的 journalctl 以非 0 状态码退出，已输出的日志仍然有效
+		log.Print(i18n.T("auth.exit_code", m.name, code))
+	case err != nil:
+		return monitor.Result{Success: false, Message: i18n.T("auth.read_failed", m.name, err), Err: err, Labels: labels}
+	}
+
+	m.started = true
+	var findings []finding
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journal.JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		m.st.Cursor = entry.Cursor
+		if first {
+			continue
+		}
+		if e, ok := parse(entry); ok {
+			findings = append(findings, m.analyze(e)...)
+		}
+	}
+	m.prune(time.Now())
+	m.save()
+
+	if len(findings) == 0 {
+		return monitor.Result{Success: true, Message: i18n.T("auth.ok", m.name, len(m.st.Known)), Labels: labels}
+	}
+	severity := monitor.SeverityWarning
+	messages := make([]string, len(findings))
+	var lines, offenders []string
+	for i, f := range findings {
+		messages[i] = f.message
+		if f.offender {
+			offenders = append(offenders, f.event.IP)
+		}
+		// 同一条日志可能触发多个问题
+		if len(lines) == 0 || lines[len(lines)-1] != f.event.Raw {
+			lines = append(lines, f.event.Raw)
+		}
+		if f.severity == monitor.SeverityCritical {
+			severity = monitor.SeverityCritical
+		}
+	}
+	return monitor.Result{
+		Success:   false,
+		Message:   strings.Join(messages, "\n"),
+		Severity:  severity,
+		Labels:    labels,
+		LogLines:  lines,
+		Fields:    findings[len(findings)-1].event.fields(),
+		Event:     true,
+		Offenders: offenders,
+	}
+}
+
+// parse 按日志来源把 journal 条目解析为认证事件
+func parse(entry journal.JournalEntry) (Event, bool) {
+	var e Event
+	var ok bool
+	if entry.Fields["SYSLOG_IDENTIFIER"] == "sudo" {
+		e, ok = parseSudo(entry.Message)
+	} else {
+		e, ok = parseSSH(entry.Message)
+	}
+	e.Time, e.Raw = entry.Time(), entry.Message
+	return e, ok
+}
+
+// analyze 更新来源地址的统计并返回该事件触发的问题
+func (m *AuthMonitor) analyze(e Event) []finding {
+	switch {
+	case e.Service == "sudo" && e.Kind == KindDenied:
+		return []finding{{message: i18n.T("auth.sudo_denied", e.User, e.Command), severity: monitor.SeverityCritical, event: e}}
+	case e.Service == "sudo" && e.Kind == KindFailure:
+		return []finding{{message: i18n.T("auth.sudo_failed", e.User, e.Target, e.Command), severity: monitor.SeverityWarning, event: e}}
+	case e.Service != "ssh" || m.isTrusted(e.IP):
+		return nil
+	}
+
+	recent := m.recentFailures(e.IP, e.Time)
+	if e.Kind == KindFailure {
+		recent = append(recent, failure{e.Time, e.User})
+		m.failures[e.IP] = recent
+		last, alerted := m.alerted[e.IP]
+		if m.cfg.BruteForce > 0 && len(recent) >= m.cfg.BruteForce && (!alerted || e.Time.Sub(last) >= m.cfg.Window) {
+			m.alerted[e.IP] = e.Time
+			return []finding{{
+				message:  i18n.T("auth.brute_force", e.IP, len(recent), m.cfg.Window, usersOf(recent)),
+				severity: monitor.SeverityWarning,
+				event:    e,
+				offender: true,
+			}}
+		}
+		return nil
+	}
+
+	var findings []finding
+	if m.cfg.SuccessAfterFailures > 0 && len(recent) >= m.cfg.SuccessAfterFailures {
+		findings = append(findings, finding{message: i18n.T("auth.success_after_failures", e.User, e.IP, e.Method, len(recent), m.cfg.Window), severity: monitor.SeverityCritical, event: e})
+		// 已经报告过的失败不再计入之后的成功登录
+		delete(m.failures, e.IP)
+	}
+	known, ok := m.st.Known[e.IP]
+	if !ok {
+		if m.cfg.NewIP {
+			findings = append(findings, finding{message: i18n.T("auth.new_ip", e.User, e.IP, e.Method), severity: monitor.SeverityWarning, event: e})
+		}
+		known = &knownIP{FirstSeen: e.Time}
+		m.st.Known[e.IP] = known
+	}
+	known.LastSeen = e.Time
+	if !slices.Contains(known.Users, e.User) {
+		known.Users = append(known.Users, e.User)
+	}
+	return findings
+}
+
+// recentFailures 返回来源地址在 now 之前一个窗口内的失败
+func (m *AuthMonitor) recentFailures(ip string, now time.Time) []failure {
+	fs := m.failures[ip]
+	i := 0
+	for i < len(fs) && now.Sub(fs[i].time) > m.cfg.Window {
+		i++
+	}
+	return fs[i:]
+}
+
+// prune 删除窗口外的失败记录和超出上限的已知地址
+func (m *AuthMonitor) prune(now time.Time) {
+	for ip := range m.failures {
+		if recent := m.recentFailures(ip, now); len(recent) == 0 {
+			delete(m.failures, ip)
+		} else {
+			m.failures[ip] = recent
+		}
+	}
+	for ip, t := range m.alerted {
+		if now.Sub(t) > m.cfg.Window {
+			delete(m.alerted, ip)
+		}
+	}
+	if over := len(m.st.Known) - m.cfg.MaxKnownIPs; over > 0 {
+		ips := slices.SortedFunc(maps.Keys(m.st.Known), func(a, b string) int {
+			return m.st.Known[a].LastSeen.Compare(m.st.Known[b].LastSeen)
+		})
+		for _, ip := range ips[:over] {
+			delete(m.st.Known, ip)
+		}
+	}
+}
+
+// isTrusted 判断来源是否属于 trustedNetworks；sshd 开启 UseDNS 时来源可能是主机名，此时不视为可信
+func (m *AuthMonitor) isTrusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range m.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// save 保存日志位置和已知地址，失败时只记录日志
+func (m *AuthMonitor) save() {
+	if m.statePath == "" {
+		return
+	}
+	if err := state.Save(m.statePath, m.st); err != nil {
+		log.Print(i18n.T("auth.state_save_failed", m.name, err))
+	}
+}
+
+// usersOf 返回失败记录中出现过的用户名，最多 maxUsers 个
+func usersOf(fs []failure) string {
+	var users []string
+	for _, f := range fs {
+		if !slices.Contains(users, f.user) {
+			if len(users) == maxUsers {
+				users = append(users, "...")
+				break
+			}
+			users = append(users, f.user)
+		}
+	}
+	return strings.Join(users, ", ")
+}
+
+// fields 返回事件的结构化字段，供通知模板使用
+func (e Event) fields() map[string]string {
+	fields := map[string]string{"auth_service": e.Service, "auth_kind": string(e.Kind), "auth_user": e.User}
+	for k, v := range map[string]string{"auth_ip": e.IP, "auth_method": e.Method, "auth_target": e.Target, "auth_command": e.Command} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	return fields
+}
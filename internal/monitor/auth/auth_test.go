@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"fmt"
+	"hashcowuwu/lychee/internal/executor/executortest"
+	"hashcowuwu/lychee/internal/monitor"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const readCmd = "journalctl -o json --no-pager -t sshd -t sshd-session -t sudo"
+
+// entry 生成一行 journalctl -o json 输出，时间为当前时间
+func entry(cursor, identifier, message string) string {
+	return fmt.Sprintf(`{"__CURSOR":%q,"__REALTIME_TIMESTAMP":"%d","SYSLOG_IDENTIFIER":%q,"MESSAGE":%q}`+"\n",
+		cursor, time.Now().UnixMicro(), identifier, message)
+}
+
+func failures(n int, ip string) string {
+	var b strings.Builder
+	for i := range n {
+		b.WriteString(entry(fmt.Sprintf("f%d", i), "sshd", fmt.Sprintf("Failed password for root from %s port %d ssh2", ip, 40000+i)))
+	}
+	return b.String()
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		success   bool
+		severity  monitor.Severity
+		offenders []string
+	}{
+		{"nothing", "", true, "", nil},
+		{"below threshold", failures(2, "203.0.113.5"), true, "", nil},
+		{"brute force", failures(3, "203.0.113.5"), false, monitor.SeverityWarning, []string{"203.0.113.5"}},
+		{"trusted network", failures(5, "10.1.2.3"), true, "", nil},
+		{
+			name:     "success after failures",
+			output:   failures(2, "198.51.100.7") + entry("ok", "sshd", "Accepted password for root from 198.51.100.7 port 50000 ssh2"),
+			success:  false,
+			severity: monitor.SeverityCritical,
+		},
+		{
+			name:     "sudo denied",
+			output:   entry("s", "sudo", "mallory : user NOT in sudoers ; TTY=pts/0 ; PWD=/home/mallory ; USER=root ; COMMAND=/bin/sh"),
+			success:  false,
+			severity: monitor.SeverityCritical,
+		},
+		{"malformed lines", "not json\n" + failures(1, "203.0.113.5") + "{\n", true, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := executortest.New().
+				On(readCmd+" -n 1", executortest.Response{Stdout: entry("c1", "sshd", "Server listening on 0.0.0.0 port 22.")}).
+				On(readCmd+" --after-cursor c1", executortest.Response{Stdout: tt.output})
+			m, err := New(Config{BruteForce: 3, SuccessAfterFailures: 2, TrustedNetworks: []string{"10.0.0.0/8"}}, fake, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 第一次只记录位置
+			if r := m.Check(); !r.Success {
+				t.Fatalf("first check: %s", r.Message)
+			}
+			r := m.Check()
+			if r.Success != tt.success || r.Severity != tt.severity {
+				t.Errorf("got Success = %v, Severity = %q, want %v, %q (%s)", r.Success, r.Severity, tt.success, tt.severity, r.Message)
+			}
+			if !slices.Equal(r.Offenders, tt.offenders) {
+				t.Errorf("Offenders = %q, want %q", r.Offenders, tt.offenders)
+			}
+		})
+	}
+}
+
+func TestCheckExitCode(t *testing.T) {
+	// journalctl 以非 0 状态码退出时已输出的日志仍然有效
+	fake := executortest.New().
+		On(readCmd+" -n 1", executortest.Response{Stdout: entry("c1", "sshd", "start")}).
+		On(readCmd+" --after-cursor c1", executortest.Response{Stdout: failures(3, "203.0.113.5"), ExitCode: 1})
+	m, err := New(Config{BruteForce: 3}, fake, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Check()
+	if r := m.Check(); r.Success {
+		t.Error("Success = true, want false")
+	}
+	if m.st.Cursor != "f2" {
+		t.Errorf("cursor = %q, want f2", m.st.Cursor)
+	}
+}
+
+func TestCheckQuietHost(t *testing.T) {
+	// 第一次读取时还没有认证日志，之后出现的日志都要分析
+	fake := executortest.New().
+		On(readCmd+" -n 1", executortest.Response{}).
+		On(readCmd, executortest.Response{Stdout: failures(3, "203.0.113.5")})
+	m, err := New(Config{BruteForce: 3}, fake, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := m.Check(); !r.Success {
+		t.Fatalf("first check: %s", r.Message)
+	}
+	if r := m.Check(); r.Success {
+		t.Error("Success = true, want false")
+	}
+	if m.st.Cursor != "f2" {
+		t.Errorf("cursor = %q, want f2", m.st.Cursor)
+	}
+	if want := []string{readCmd + " -n 1", readCmd}; !slices.Equal(fake.Calls(), want) {
+		t.Errorf("calls = %q, want %q", fake.Calls(), want)
+	}
+}
+
+func TestSuccessAfterFailuresReported(t *testing.T) {
+	// 报告过之后同一地址再次登录成功不重复报告
+	accepted := entry("ok", "sshd", "Accepted password for root from 198.51.100.7 port 50000 ssh2")
+	fake := executortest.New().
+		On(readCmd+" -n 1", executortest.Response{Stdout: entry("c1", "sshd", "start")}).
+		On(readCmd+" --after-cursor c1", executortest.Response{Stdout: failures(2, "198.51.100.7") + accepted}).
+		On(readCmd+" --after-cursor ok", executortest.Response{Stdout: accepted})
+	m, err := New(Config{SuccessAfterFailures: 2}, fake, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Check()
+	if r := m.Check(); r.Severity != monitor.SeverityCritical {
+		t.Fatalf("Severity = %q, want %q (%s)", r.Severity, monitor.SeverityCritical, r.Message)
+	}
+	if r := m.Check(); !r.Success {
+		t.Errorf("second login reported again: %s", r.Message)
+	}
+}
+
+func TestParseSSH(t *testing.T) {
+	tests := []struct {
+		message string
+		want    Event
+		ok      bool
+	}{
+		{"Failed password for root from 203.0.113.5 port 52314 ssh2", Event{Service: "ssh", Kind: KindFailure, Method: "password", User: "root", IP: "203.0.113.5"}, true},
+		{"Failed password for invalid user admin from 203.0.113.5 port 52314 ssh2", Event{Service: "ssh", Kind: KindFailure, Method: "invalid-user", User: "admin", IP: "203.0.113.5"}, true},
+		{"Failed none for invalid user admin from 203.0.113.5 port 52314 ssh2", Event{Service: "ssh", Kind: KindFailure, Method: "invalid-user", User: "admin", IP: "203.0.113.5"}, true},
+		{"Invalid user admin from 203.0.113.5 port 52314", Event{}, false},
+		{"Accepted publickey for deploy from 2001:db8::1 port 40022 ssh2: ED25519 SHA256:abc", Event{Service: "ssh", Kind: KindSuccess, Method: "publickey", User: "deploy", IP: "2001:db8::1"}, true},
+		{"Connection closed by 203.0.113.5 port 52314", Event{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSSH(tt.message)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseSSH(%q) = %+v, %v, want %+v, %v", tt.message, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseSSHInvalidUserAttempts(t *testing.T) {
+	// 一个连接中对不存在的用户尝试 MaxAuthTries (默认 6) 次密码
+	messages := []string{"Invalid user admin from 203.0.113.5 port 52314"}
+	for range 6 {
+		messages = append(messages, "Failed password for invalid user admin from 203.0.113.5 port 52314 ssh2")
+	}
+	messages = append(messages, "Connection closed by invalid user admin 203.0.113.5 port 52314 [preauth]")
+	failures := 0
+	for _, msg := range messages {
+		if e, ok := parseSSH(msg); ok && e.Kind == KindFailure {
+			failures++
+		}
+	}
+	if failures != 6 {
+		t.Errorf("%d failures counted, want 6", failures)
+	}
+}
+
+func TestParseSudo(t *testing.T) {
+	tests := []struct {
+		message string
+		kind    EventKind
+		command string
+		ok      bool
+	}{
+		{"alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/usr/bin/id ; echo", KindSuccess, "/usr/bin/id ; echo", true},
+		{"alice : 3 incorrect password attempts ; TTY=pts/0 ; PWD=/ ; USER=root ; COMMAND=/bin/ls", KindFailure, "/bin/ls", true},
+		{"bob : user NOT in sudoers ; TTY=pts/1 ; PWD=/ ; USER=root ; COMMAND=/bin/sh", KindDenied, "/bin/sh", true},
+		{"pam_unix(sudo:session): session opened for user root", "", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSudo(tt.message)
+		if ok != tt.ok || got.Kind != tt.kind || got.Command != tt.command {
+			t.Errorf("parseSudo(%q) = %+v, %v, want kind %q, command %q, %v", tt.message, got, ok, tt.kind, tt.command, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// EventKind 是认证事件的类型
+type EventKind string
+
+const (
+	KindFailure EventKind = "failure" // 认证失败
+	KindSuccess EventKind = "success" // 登录成功或 sudo 执行命令
+	KindDenied  EventKind = "denied"  // sudo: 用户不在 sudoers 中
+)
+
+// Event 是从 sshd 或 sudo 日志中解析出的认证事件
+type Event struct {
+	Time    time.Time
+	Service string // ssh 或 sudo
+	Kind    EventKind
+	User    string // ssh 为登录用户，sudo 为执行 sudo 的用户
+	IP      string // 来源地址，只有 ssh 事件有
+	Method  string // ssh 的认证方式，例如 password、publickey；无效用户为 invalid-user
+	Target  string // sudo 的目标用户
+	Command string // sudo 执行的命令
+	Raw     string // 原始日志
+}
+
+var (
+	// Failed password for root from 203.0.113.5 port 52314 ssh2
+	// Failed password for invalid user admin from 203.0.113.5 port 52314 ssh2
+	sshFailed = regexp.MustCompile(`^Failed (\S+) for (invalid user )?(.*) from (\S+) port \d+`)
+	// Accepted publickey for deploy from 198.51.100.7 port 40022 ssh2: ED25519 SHA256:...
+	sshAccepted = regexp.MustCompile(`^Accepted (\S+) for (.*) from (\S+) port \d+`)
+	// alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/usr/bin/systemctl restart nginx
+	sudoLine = regexp.MustCompile(`^\s*(\S+) : (.*)$`)
+)
+
+// parseSSH 解析 sshd 的日志，不是认证事件时返回 false。
+// sshd 对无效用户每个连接只记录一行 "Invalid user"，而 "Failed password for invalid user"
+// 每次尝试都会记录 (最多 MaxAuthTries 次)，所以只统计后者，前者视为重复。
+func parseSSH(message string) (Event, bool) {
+	if m := sshFailed.FindStringSubmatch(message); m != nil {
+		method := m[1]
+		if m[2] != "" {
+			method = "invalid-user"
+		}
+		return Event{Service: "ssh", Kind: KindFailure, Method: method, User: m[3], IP: m[4]}, true
+	}
+	if m := sshAccepted.FindStringSubmatch(message); m != nil {
+		return Event{Service: "ssh", Kind: KindSuccess, Method: m[1], User: m[2], IP: m[3]}, true
+	}
+	return Event{}, false
+}
+
+// parseSudo 解析 sudo 记录的命令日志，PAM 等其他日志返回 false
+func parseSudo(message string) (Event, bool) {
+	m := sudoLine.FindStringSubmatch(message)
+	if m == nil {
+		return Event{}, false
+	}
+	e := Event{Service: "sudo", Kind: KindSuccess, User: m[1]}
+	for _, part := range strings.Split(m[2], " ; ") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		switch {
+		case !ok:
+			// 第一段在失败时是原因，例如 "3 incorrect password attempts"、"user NOT in sudoers"
+			switch {
+			case strings.Contains(part, "NOT in sudoers"), strings.Contains(part, "not allowed"):
+				e.Kind = KindDenied
+			case strings.Contains(part, "incorrect password"), strings.Contains(part, "password is required"):
+				e.Kind = KindFailure
+			}
+		case key == "USER":
+			e.Target = value
+		case key == "COMMAND":
+			// COMMAND 总是最后一项，命令本身可能包含 " ; "
+			_, e.Command, _ = strings.Cut(m[2], "COMMAND=")
+			return e, true
+		}
+	}
+	return Event{}, false
+}
@@ -0,0 +1,226 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout 是一次解析的超时
+const DefaultTimeout = 5 * time.Second
+
+// 支持的记录类型
+var recordTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT", "SRV", "PTR"}
+
+// Config 描述一个 DNS 解析检查
+type Config struct {
+	Name    string        `yaml:"name"`    // 默认使用 query
+	Query   string        `yaml:"query"`   // 要解析的名称，PTR 记录填写 IP 地址
+	Type    string        `yaml:"type"`    // 记录类型，默认 A
+	Server  string        `yaml:"server"`  // 指定的解析服务器，例如 1.1.1.1、10.0.0.2:53 或 [::1]:5353，查询直接发给它而不读取 /etc/hosts；默认使用系统配置 (/etc/resolv.conf)
+	Expect  []string      `yaml:"expect"`  // 解析结果中必须包含的值，未配置时只要求至少有一个结果
+	Timeout time.Duration `yaml:"timeout"` // 默认 5s
+}
+
+// Validate 检查配置，不访问网络
+func (c Config) Validate() error {
+	if c.Query == "" {
+		return i18n.Errorf("dns.query_missing")
+	}
+	if c.Type != "" && !slices.Contains(recordTypes, strings.ToUpper(c.Type)) {
+		return i18n.Errorf("dns.type_unknown", c.Type, strings.Join(recordTypes, ", "))
+	}
+	if c.Timeout < 0 {
+		return i18n.Errorf("dns.timeout_negative")
+	}
+	if c.Server != "" {
+		if _, err := serverAddr(c.Server); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serverAddr 把 server 规范化为 host:port，没有端口时使用 53。
+// 支持 1.1.1.1、dns.example.com:53、::1、[::1] 和 [::1]:53 这些写法。
+func serverAddr(server string) (string, error) {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		// 没有端口: IPv6 地址可能带有方括号
+		host, port = server, "53"
+		if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+			host = host[1 : len(host)-1]
+		}
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", i18n.Errorf("dns.server_invalid", server)
+	}
+	// 不是 IP 地址时必须是主机名
+	if net.ParseIP(host) == nil && (host == "" || strings.ContainsAny(host, ":[]/ \t")) {
+		return "", i18n.Errorf("dns.server_invalid", server)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// DNSMonitor 解析一个名称并检查结果
+type DNSMonitor struct {
+	cfg    Config
+	name   string
+	server string // 指定的解析服务器 host:port，为空时使用系统解析器
+}
+
+// New 根据配置创建 DNS 监控器
+func New(cfg Config) (*DNSMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	m := &DNSMonitor{cfg: cfg, name: cfg.Name}
+	if m.name == "" {
+		m.name = cfg.Query
+	}
+	m.cfg.Type = strings.ToUpper(cfg.Type)
+	if m.cfg.Type == "" {
+		m.cfg.Type = "A"
+	}
+	if m.cfg.Timeout == 0 {
+		m.cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Server != "" {
+		m.server, _ = serverAddr(cfg.Server)
+	}
+	return m, nil
+}
+
+// Name 返回监控器名称
+func (m *DNSMonitor) Name() string {
+	return fmt.Sprintf("dns(%s %s)", m.name, m.cfg.Type)
+}
+
+// Check 解析一次并检查是否包含期望的结果
+func (m *DNSMonitor) Check() monitor.Result {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	answers, err := m.lookup(ctx)
+	if err != nil {
+		return m.fail(i18n.T("dns.failed", m.name, m.cfg.Query, m.cfg.Type, err), err)
+	}
+	if len(answers) == 0 {
+		return m.fail(i18n.T("dns.empty", m.name, m.cfg.Query, m.cfg.Type), nil)
+	}
+	var missing []string
+	for _, want := range m.cfg.Expect {
+		if m.cfg.Type != "TXT" {
+			want = normalize(want)
+		}
+		if !slices.Contains(answers, want) {
+			missing = append(missing, want)
+		}
+	}
+	if len(missing) > 0 {
+		return m.fail(i18n.T("dns.missing", m.name, m.cfg.Query, m.cfg.Type, answers, missing), nil)
+	}
+	return monitor.Result{
+		Success: true,
+		Message: i18n.T("dns.ok", m.name, m.cfg.Query, m.cfg.Type, answers, time.Since(start).Round(time.Millisecond)),
+		Labels:  m.labels(),
+	}
+}
+
+// lookup 按记录类型解析，返回规范化后的结果。
+// 指定了 server 时直接发送 DNS 查询，net.Resolver 会先查 /etc/hosts，结果不一定来自该服务器。
+func (m *DNSMonitor) lookup(ctx context.Context) ([]string, error) {
+	r, q := net.DefaultResolver, m.cfg.Query
+	var answers []string
+	var err error
+	switch {
+	case m.server != "":
+		answers, err = query(ctx, m.server, q, m.cfg.Type)
+		if err != nil || m.cfg.Type == "TXT" {
+			return answers, err
+		}
+	case m.cfg.Type == "A", m.cfg.Type == "AAAA":
+		network := "ip4"
+		if m.cfg.Type == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupIP(ctx, network, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case m.cfg.Type == "CNAME":
+		cname, err := r.LookupCNAME(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, cname)
+	case m.cfg.Type == "MX":
+		mxs, err := r.LookupMX(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			answers = append(answers, mx.Host)
+		}
+	case m.cfg.Type == "NS":
+		nss, err := r.LookupNS(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			answers = append(answers, ns.Host)
+		}
+	case m.cfg.Type == "TXT":
+		txts, err := r.LookupTXT(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		// TXT 记录区分大小写，不做规范化
+		return txts, nil
+	case m.cfg.Type == "SRV":
+		_, srvs, err := r.LookupSRV(ctx, "", "", q)
+		if err != nil {
+			return nil, err
+		}
+		for _, srv := range srvs {
+			answers = append(answers, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))))
+		}
+	case m.cfg.Type == "PTR":
+		names, err := r.LookupAddr(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		answers = names
+	}
+	for i, a := range answers {
+		answers[i] = normalize(a)
+	}
+	return answers, nil
+}
+
+// normalize 统一名称的大小写并去掉末尾的点，IP 地址转换为标准写法
+func normalize(s string) string {
+	if ip := net.ParseIP(s); ip != nil {
+		return ip.String()
+	}
+	return strings.TrimSuffix(strings.ToLower(s), ".")
+}
+
+func (m *DNSMonitor) fail(msg string, err error) monitor.Result {
+	return monitor.Result{Success: false, Message: msg, Err: err, Labels: m.labels()}
+}
+
+// labels 返回附加在结果上的标签
+func (m *DNSMonitor) labels() map[string]string {
+	return map[string]string{"type": "dns", "service": m.name}
+}
@@ -0,0 +1,303 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// record 是模拟服务器应答中的一条记录，name 为空时指向问题中的名称
+type record struct {
+	name  string
+	typ   string
+	rdata []byte
+}
+
+func encodeName(name string) []byte {
+	var b []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+// respond 根据查询报文生成应答
+func respond(req []byte, rcode int, truncated bool, records []record) []byte {
+	_, qend, _ := readName(req, headerSize)
+	resp := append([]byte(nil), req[:qend+4]...)
+	flags := uint16(flagQR|flagRD) | uint16(rcode)
+	if truncated {
+		flags |= flagTC
+	}
+	binary.BigEndian.PutUint16(resp[2:], flags)
+	binary.BigEndian.PutUint16(resp[6:], uint16(len(records)))
+	for _, r := range records {
+		if r.name == "" {
+			resp = append(resp, 0xc0, headerSize) // 压缩指针，指向问题中的名称
+		} else {
+			resp = append(resp, encodeName(r.name)...)
+		}
+		resp = binary.BigEndian.AppendUint16(resp, typeCodes[r.typ])
+		resp = binary.BigEndian.AppendUint16(resp, 1)
+		resp = binary.BigEndian.AppendUint32(resp, 60)
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(r.rdata)))
+		resp = append(resp, r.rdata...)
+	}
+	return resp
+}
+
+// fakeServer 在本地同一端口上监听 UDP 和 TCP，udpTruncated 为 true 时 UDP 应答只返回截断标志
+func fakeServer(t *testing.T, rcode int, udpTruncated bool, records []record) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if udpTruncated {
+				pc.WriteTo(respond(buf[:n], rcode, true, nil), addr)
+			} else {
+				pc.WriteTo(respond(buf[:n], rcode, false, records), addr)
+			}
+		}
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var size [2]byte
+			io.ReadFull(conn, size[:])
+			req := make([]byte, binary.BigEndian.Uint16(size[:]))
+			io.ReadFull(conn, req)
+			resp := respond(req, rcode, false, records)
+			conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...))
+			conn.Close()
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestCheckServer(t *testing.T) {
+	txt := append([]byte{5}, "hello"...)
+	txt = append(txt, append([]byte{6}, " world"...)...)
+	srv := append([]byte{0, 10, 0, 5, 0x01, 0xbb}, encodeName("sip.example.com")...)
+	tests := []struct {
+		name      string
+		cfg       Config
+		rcode     int
+		truncated bool
+		records   []record
+		want      []string
+		success   bool
+	}{
+		{
+			// /etc/hosts 中的 localhost 不会被使用
+			name:    "A bypasses hosts file",
+			cfg:     Config{Query: "localhost"},
+			records: []record{{typ: "A", rdata: []byte{192, 0, 2, 1}}},
+			want:    []string{"192.0.2.1"},
+			success: true,
+		},
+		{
+			name: "A through CNAME",
+			cfg:  Config{Query: "www.example.com", Expect: []string{"192.0.2.2"}},
+			records: []record{
+				{typ: "CNAME", rdata: encodeName("web.example.com")},
+				{name: "web.example.com", typ: "A", rdata: []byte{192, 0, 2, 2}},
+			},
+			want:    []string{"192.0.2.2"},
+			success: true,
+		},
+		{
+			name:    "expected answer missing",
+			cfg:     Config{Query: "www.example.com", Expect: []string{"192.0.2.9"}},
+			records: []record{{typ: "A", rdata: []byte{192, 0, 2, 2}}},
+			want:    []string{"192.0.2.2"},
+			success: false,
+		},
+		{
+			name:    "MX",
+			cfg:     Config{Query: "example.com", Type: "mx", Expect: []string{"Mail.Example.com."}},
+			records: []record{{typ: "MX", rdata: append([]byte{0, 10}, encodeName("mail.example.com")...)}},
+			want:    []string{"mail.example.com"},
+			success: true,
+		},
+		{
+			name:    "TXT",
+			cfg:     Config{Query: "example.com", Type: "TXT"},
+			records: []record{{typ: "TXT", rdata: txt}},
+			want:    []string{"hello world"},
+			success: true,
+		},
+		{
+			name:    "SRV",
+			cfg:     Config{Query: "_sip._tcp.example.com", Type: "SRV"},
+			records: []record{{typ: "SRV", rdata: srv}},
+			want:    []string{"sip.example.com:443"},
+			success: true,
+		},
+		{
+			name:    "PTR",
+			cfg:     Config{Query: "192.0.2.1", Type: "PTR"},
+			records: []record{{typ: "PTR", rdata: encodeName("host.example.com")}},
+			want:    []string{"host.example.com"},
+			success: true,
+		},
+		{
+			name:      "truncated falls back to TCP",
+			cfg:       Config{Query: "www.example.com"},
+			truncated: true,
+			records:   []record{{typ: "A", rdata: []byte{192, 0, 2, 3}}},
+			want:      []string{"192.0.2.3"},
+			success:   true,
+		},
+		{name: "NXDOMAIN", cfg: Config{Query: "missing.example.com"}, rcode: 3, success: false},
+		{name: "no answers", cfg: Config{Query: "www.example.com"}, success: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Server = fakeServer(t, tt.rcode, tt.truncated, tt.records)
+			tt.cfg.Timeout = time.Second
+			m, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r := m.Check(); r.Success != tt.success {
+				t.Errorf("Success = %v, want %v (%s)", r.Success, tt.success, r.Message)
+			}
+			if tt.want != nil {
+				got, err := m.lookup(t.Context())
+				if err != nil || !slices.Equal(got, tt.want) {
+					t.Errorf("lookup = %q, %v, want %q", got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReverseName(t *testing.T) {
+	tests := map[string]string{
+		"192.0.2.1":   "1.2.0.192.in-addr.arpa.",
+		"2001:db8::1": "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+	}
+	for ip, want := range tests {
+		if got := reverseName(net.ParseIP(ip)); got != want {
+			t.Errorf("reverseName(%s) = %s, want %s", ip, got, want)
+		}
+	}
+}
+
+// udpServer 启动一个 UDP 服务，对第 n 个 (从 0 开始) 请求依次发送 reply 返回的报文
+func udpServer(t *testing.T, reply func(n int, req []byte) [][]byte) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for n := 0; ; n++ {
+			size, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			for _, resp := range reply(n, buf[:size]) {
+				pc.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestQueryUDP(t *testing.T) {
+	a := []record{{typ: "A", rdata: []byte{192, 0, 2, 1}}}
+	other := func(req []byte, edit func([]byte)) []byte {
+		resp := respond(req, 0, false, []record{{typ: "A", rdata: []byte{198, 51, 100, 1}}})
+		edit(resp)
+		return resp
+	}
+	tests := []struct {
+		name  string
+		reply func(n int, req []byte) [][]byte
+		want  []string
+	}{
+		{"first packet lost", func(n int, req []byte) [][]byte {
+			if n == 0 {
+				return nil
+			}
+			return [][]byte{respond(req, 0, false, a)}
+		}, []string{"192.0.2.1"}},
+		{"wrong id skipped", func(n int, req []byte) [][]byte {
+			return [][]byte{other(req, func(b []byte) { b[0]++ }), respond(req, 0, false, a)}
+		}, []string{"192.0.2.1"}},
+		{"other question skipped", func(n int, req []byte) [][]byte {
+			return [][]byte{other(req, func(b []byte) { b[headerSize+1]++ }), respond(req, 0, false, a)}
+		}, []string{"192.0.2.1"}},
+		{"case of the name ignored", func(n int, req []byte) [][]byte {
+			resp := respond(req, 0, false, a)
+			resp[headerSize+1] -= 'a' - 'A'
+			return [][]byte{resp}
+		}, []string{"192.0.2.1"}},
+		{"no answer", func(n int, req []byte) [][]byte { return nil }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(t.Context(), 600*time.Millisecond)
+			defer cancel()
+			got, err := query(ctx, udpServer(t, tt.reply), "www.example.com", "A")
+			if (err == nil) != (tt.want != nil) || !slices.Equal(got, tt.want) {
+				t.Errorf("query = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerAddr(t *testing.T) {
+	tests := []struct {
+		server string
+		want   string
+	}{
+		{"1.1.1.1", "1.1.1.1:53"},
+		{"10.0.0.2:5353", "10.0.0.2:5353"},
+		{"dns.example.com", "dns.example.com:53"},
+		{"::1", "[::1]:53"},
+		{"[::1]", "[::1]:53"},
+		{"[::1]:5353", "[::1]:5353"},
+		{"10.0.0.2:dns", ""},
+		{"10.0.0.2:0", ""},
+		{"10.0.0.2:", ""},
+		{"[dns.example.com", ""},
+		{"dns example", ""},
+	}
+	for _, tt := range tests {
+		got, err := serverAddr(tt.server)
+		if got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("serverAddr(%q) = %q, %v, want %q", tt.server, got, err, tt.want)
+		}
+		if err := (Config{Query: "example.com", Server: tt.server}).Validate(); (err == nil) != (tt.want != "") {
+			t.Errorf("Validate with server %q = %v", tt.server, err)
+		}
+	}
+}
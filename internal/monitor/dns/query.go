@@ -0,0 +1,302 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hashcowuwu/lychee/internal/i18n"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 记录类型对应的 DNS 类型码
+var typeCodes = map[string]uint16{
+	"A": 1, "NS": 2, "CNAME": 5, "PTR": 12, "MX": 15, "TXT": 16, "AAAA": 28, "SRV": 33,
+}
+
+// rcodeNames 是常见响应码的名称
+var rcodeNames = map[int]string{1: "FORMERR", 2: "SERVFAIL", 3: "NXDOMAIN", 4: "NOTIMP", 5: "REFUSED"}
+
+const (
+	headerSize = 12
+	flagRD     = 1 << 8 // 请求递归解析
+	flagTC     = 1 << 9 // 响应被截断，需要改用 TCP
+	flagQR     = 1 << 15
+)
+
+// udpAttempts 是 UDP 查询的最多发送次数，与系统解析器一样在丢包时重发
+const udpAttempts = 3
+
+// query 直接向 server 发送一次查询，返回与 typ 类型相同的应答记录。
+// 它不经过 net.Resolver，所以不会读取 /etc/hosts，结果一定来自指定的服务器。
+// UDP 响应被截断时改用 TCP 重新查询。
+func query(ctx context.Context, server, name, typ string) ([]string, error) {
+	qtype := typeCodes[typ]
+	if typ == "PTR" {
+		if ip := net.ParseIP(name); ip != nil {
+			name = reverseName(ip)
+		}
+	}
+	msg, err := buildQuery(name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := exchangeUDP(ctx, server, msg)
+	if err == nil && binary.BigEndian.Uint16(resp[2:])&flagTC != 0 {
+		resp, err = exchangeTCP(ctx, server, msg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseResponse(resp, qtype)
+}
+
+// buildQuery 生成一条只有一个问题的查询报文
+func buildQuery(name string, qtype uint16) ([]byte, error) {
+	msg := make([]byte, headerSize, 512)
+	binary.BigEndian.PutUint16(msg[0:], uint16(rand.Uint32()))
+	binary.BigEndian.PutUint16(msg[2:], flagRD)
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, i18n.Errorf("dns.name_invalid", name)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, 1) // class IN
+	return msg, nil
+}
+
+// answers 判断 resp 是否是对查询 req 的响应: ID 相同、带有 QR 标志且问题相同。
+// 服务器可能改变名称的大小写，所以名称不区分大小写比较。
+func answers(resp, req []byte) bool {
+	h := binary.BigEndian
+	if len(resp) < len(req) || h.Uint16(resp[0:]) != h.Uint16(req[0:]) ||
+		h.Uint16(resp[2:])&flagQR == 0 || h.Uint16(resp[4:]) != 1 {
+		return false
+	}
+	nameEnd := len(req) - 4
+	return bytes.EqualFold(resp[headerSize:nameEnd], req[headerSize:nameEnd]) &&
+		bytes.Equal(resp[nameEnd:len(req)], req[nameEnd:])
+}
+
+// exchangeUDP 通过 UDP 发送查询并等待对应的响应。
+// 剩余时间在各次发送之间平分，超时没有响应时重发；
+// ID 或问题不符的报文 (例如伪造或迟到的其他查询的响应) 被丢弃，继续等待。
+func exchangeUDP(ctx context.Context, server string, msg []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+
+	buf := make([]byte, 65535)
+	for attempt := range udpAttempts {
+		if _, err := conn.Write(msg); err != nil {
+			return nil, err
+		}
+		conn.SetReadDeadline(time.Now().Add(time.Until(deadline) / time.Duration(udpAttempts-attempt)))
+		for {
+			n, err := conn.Read(buf)
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() && attempt < udpAttempts-1 {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if answers(buf[:n], msg) {
+				return buf[:n], nil
+			}
+		}
+	}
+	return nil, i18n.Errorf("dns.response_invalid")
+}
+
+// exchangeTCP 通过 TCP 发送查询并读取响应，报文带 2 字节长度前缀
+func exchangeTCP(ctx context.Context, server string, msg []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(DefaultTimeout))
+	}
+
+	if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(msg))), msg...)); err != nil {
+		return nil, err
+	}
+	var size [2]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	if !answers(buf, msg) {
+		return nil, i18n.Errorf("dns.response_invalid")
+	}
+	return buf, nil
+}
+
+// parseResponse 检查响应码并取出与 qtype 类型相同的应答记录，msg 已经由 answers 确认是对应的响应
+func parseResponse(msg []byte, qtype uint16) ([]string, error) {
+	h := binary.BigEndian
+	flags := h.Uint16(msg[2:])
+	if rcode := int(flags & 0xf); rcode != 0 {
+		name, ok := rcodeNames[rcode]
+		if !ok {
+			name = strconv.Itoa(rcode)
+		}
+		return nil, i18n.Errorf("dns.rcode", name)
+	}
+	qdcount, ancount := int(h.Uint16(msg[4:])), int(h.Uint16(msg[6:]))
+
+	off := headerSize
+	for range qdcount {
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next + 4
+	}
+
+	var answers []string
+	for range ancount {
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		if next+10 > len(msg) {
+			return nil, i18n.Errorf("dns.response_invalid")
+		}
+		rtype, rdlen := h.Uint16(msg[next:]), int(h.Uint16(msg[next+8:]))
+		start, end := next+10, next+10+rdlen
+		if end > len(msg) {
+			return nil, i18n.Errorf("dns.response_invalid")
+		}
+		off = end
+		// A 查询的应答中可能带有 CNAME 链，只保留查询的类型
+		if rtype != qtype {
+			continue
+		}
+		answer, err := parseRData(msg, start, end, rtype)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, answer)
+	}
+	return answers, nil
+}
+
+// parseRData 按类型解析一条记录的数据，格式与 net.Resolver 的结果一致
+func parseRData(msg []byte, start, end int, rtype uint16) (string, error) {
+	rdata := msg[start:end]
+	switch rtype {
+	case typeCodes["A"], typeCodes["AAAA"]:
+		if len(rdata) != net.IPv4len && len(rdata) != net.IPv6len {
+			return "", i18n.Errorf("dns.response_invalid")
+		}
+		return net.IP(rdata).String(), nil
+	case typeCodes["CNAME"], typeCodes["NS"], typeCodes["PTR"]:
+		name, _, err := readName(msg, start)
+		return name, err
+	case typeCodes["MX"]:
+		if len(rdata) < 3 {
+			return "", i18n.Errorf("dns.response_invalid")
+		}
+		name, _, err := readName(msg, start+2)
+		return name, err
+	case typeCodes["SRV"]:
+		if len(rdata) < 7 {
+			return "", i18n.Errorf("dns.response_invalid")
+		}
+		port := binary.BigEndian.Uint16(rdata[4:])
+		name, _, err := readName(msg, start+6)
+		return net.JoinHostPort(strings.TrimSuffix(name, "."), strconv.Itoa(int(port))), err
+	case typeCodes["TXT"]:
+		// 一条 TXT 记录可以由多个字符串组成，与 net.Resolver 一样拼接在一起
+		var b strings.Builder
+		for i := 0; i < len(rdata); {
+			n := int(rdata[i])
+			if i+1+n > len(rdata) {
+				return "", i18n.Errorf("dns.response_invalid")
+			}
+			b.Write(rdata[i+1 : i+1+n])
+			i += 1 + n
+		}
+		return b.String(), nil
+	}
+	return "", i18n.Errorf("dns.response_invalid")
+}
+
+// readName 读取 off 处的域名 (支持压缩指针)，返回带末尾点的名称和名称之后的偏移量
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	next, jumps := -1, 0
+	for {
+		if off >= len(msg) {
+			return "", 0, i18n.Errorf("dns.response_invalid")
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case n&0xc0 == 0xc0:
+			// 限制跟随压缩指针的次数，防止指针成环
+			if off+1 >= len(msg) || jumps > 10 {
+				return "", 0, i18n.Errorf("dns.response_invalid")
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			jumps++
+		default:
+			if off+1+n > len(msg) {
+				return "", 0, i18n.Errorf("dns.response_invalid")
+			}
+			labels = append(labels, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
+
+// reverseName 返回 IP 地址对应的 PTR 查询名称
+func reverseName(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return strconv.Itoa(int(ip4[3])) + "." + strconv.Itoa(int(ip4[2])) + "." +
+			strconv.Itoa(int(ip4[1])) + "." + strconv.Itoa(int(ip4[0])) + ".in-addr.arpa."
+	}
+	const hexDigits = "0123456789abcdef"
+	var b strings.Builder
+	for i := len(ip) - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[ip[i]&0xf])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[ip[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString("ip6.arpa.")
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package heartbeat
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaxBody 是 ping 附带内容的最大字节数，超出部分被丢弃
+const MaxBody = 10 * 1024
+
+// Handler 处理 ping 请求，挂载在内置 HTTP 服务的 /ping/ 下:
+//
+//	/ping/<token>          成功
+//	/ping/<token>/start    开始运行
+//	/ping/<token>/fail     失败
+//	/ping/<token>/log      只记录内容
+//	/ping/<token>/<退出码> 0 为成功，其他为失败，便于在 cron 中使用 $?
+//
+// 请求体 (POST) 作为内容记录，在告警中显示
+type Handler struct {
+	monitors map[string]*HeartbeatMonitor
+	mux      *http.ServeMux
+}
+
+// NewHandler 创建处理 monitors 的 ping 请求的处理器
+func NewHandler(monitors []*HeartbeatMonitor) *Handler {
+	h := &Handler{monitors: make(map[string]*HeartbeatMonitor), mux: http.NewServeMux()}
+	for _, m := range monitors {
+		h.monitors[m.Token()] = m
+	}
+	h.mux.HandleFunc("/ping/{token}", h.ping)
+	h.mux.HandleFunc("/ping/{token}/{action}", h.ping)
+	return h
+}
+
+// ServeHTTP 实现 http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) ping(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	m, ok := h.monitors[r.PathValue("token")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	kind, ok := parseAction(r.PathValue("action"))
+	if !ok {
+		http.Error(w, "unknown action", http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.Ping(kind, string(body), time.Now())
+	io.WriteString(w, "OK\n")
+}
+
+// parseAction 把地址的最后一段转换为 ping 类型，为空时表示成功
+func parseAction(action string) (Kind, bool) {
+	switch action {
+	case "", "success":
+		return KindSuccess, true
+	case "fail", "start", "log":
+		return Kind(action), true
+	}
+	code, err := strconv.Atoi(action)
+	if err != nil || code < 0 || code > 255 {
+		return "", false
+	}
+	if code == 0 {
+		return KindSuccess, true
+	}
+	return KindFail, true
+}
@@ -0,0 +1,168 @@
+// Package heartbeat 实现被动的心跳监控: 定时任务在运行结束后请求 lychee 的 ping 地址，
+// 超过周期加宽限时间没有收到请求时告警。
+package heartbeat
+
+import (
+	"cmp"
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/state"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGrace 是未配置 grace 时使用的宽限时间
+const DefaultGrace = 5 * time.Minute
+
+var tokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,}$`)
+
+// Config 描述一个心跳
+type Config struct {
+	Name   string        `yaml:"name"`   // 默认使用 token
+	Token  string        `yaml:"token"`  // ping 地址中的令牌，至少 8 个字母、数字、- 或 _
+	Period time.Duration `yaml:"period"` // 任务的运行周期，例如 24h
+	Grace  time.Duration `yaml:"grace"`  // 超过周期后额外等待的时间，也是 /start 之后允许运行的时长，默认 5m
+}
+
+// Validate 检查配置
+func (c Config) Validate() error {
+	if !tokenPattern.MatchString(c.Token) {
+		return i18n.Errorf("heartbeat.token_invalid")
+	}
+	if c.Period <= 0 {
+		return i18n.Errorf("heartbeat.period_invalid")
+	}
+	if c.Grace < 0 {
+		return i18n.Errorf("heartbeat.grace_negative")
+	}
+	return nil
+}
+
+// Kind 是一次 ping 的类型
+type Kind string
+
+const (
+	KindSuccess Kind = "success" // 任务成功完成
+	KindFail    Kind = "fail"    // 任务失败
+	KindStart   Kind = "start"   // 任务开始运行
+	KindLog     Kind = "log"     // 只记录内容，不改变状态
+)
+
+// status 是需要跨重启保留的心跳状态
+type status struct {
+	Since    time.Time `json:"since"`    // 开始监控的时间，从未收到 ping 时从此计算超时
+	LastPing time.Time `json:"lastPing"` // 最近一次 success 或 fail
+	Failed   bool      `json:"failed"`   // 最近一次结果是否为 fail
+	Started  time.Time `json:"started"`  // 最近一次 start，任务完成后清零
+	Body     string    `json:"body"`     // 最近一次 ping 附带的内容
+}
+
+// HeartbeatMonitor 记录收到的 ping 并在 Check 时判断是否超时
+type HeartbeatMonitor struct {
+	cfg       Config
+	name      string
+	statePath string
+
+	mu sync.Mutex
+	st status
+}
+
+// New 创建心跳监控器，statePath 为空时不持久化状态
+func New(cfg Config, statePath string) (*HeartbeatMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Grace == 0 {
+		cfg.Grace = DefaultGrace
+	}
+	m := &HeartbeatMonitor{cfg: cfg, name: cmp.Or(cfg.Name, cfg.Token), statePath: statePath}
+	if statePath != "" {
+		if err := state.Load(statePath, &m.st); err != nil {
+			log.Print(i18n.T("heartbeat.state_load_failed", m.name, err))
+		}
+	}
+	if m.st.Since.IsZero() {
+		m.st.Since = time.Now()
+	}
+	return m, nil
+}
+
+// Name 返回监控器名称
+func (m *HeartbeatMonitor) Name() string {
+	return fmt.Sprintf("heartbeat(%s)", m.name)
+}
+
+// Token 返回 ping 地址中的令牌
+func (m *HeartbeatMonitor) Token() string {
+	return m.cfg.Token
+}
+
+// Ping 记录一次 ping，body 为请求附带的内容
+func (m *HeartbeatMonitor) Ping(kind Kind, body string, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch kind {
+	case KindSuccess, KindFail:
+		m.st.LastPing = now
+		m.st.Failed = kind == KindFail
+		m.st.Started = time.Time{}
+		m.st.Body = body
+	case KindStart:
+		m.st.Started = now
+	case KindLog:
+		m.st.Body = body
+	}
+	if m.statePath != "" {
+		if err := state.Save(m.statePath, m.st); err != nil {
+			log.Print(i18n.T("heartbeat.state_save_failed", m.name, err))
+		}
+	}
+}
+
+// Check 检查最近一次结果、是否超时未收到 ping 以及开始后是否运行过久
+func (m *HeartbeatMonitor) Check() monitor.Result {
+	m.mu.Lock()
+	st := m.st
+	m.mu.Unlock()
+
+	now := time.Now()
+	last := i18n.T("heartbeat.never")
+	if !st.LastPing.IsZero() {
+		last = st.LastPing.Format(time.DateTime)
+	}
+	var messages []string
+	if st.Failed {
+		messages = append(messages, i18n.T("heartbeat.failed", m.name, last))
+	}
+	since := st.LastPing
+	if since.IsZero() {
+		since = st.Since
+	}
+	if now.Sub(since) > m.cfg.Period+m.cfg.Grace {
+		messages = append(messages, i18n.T("heartbeat.missed", m.name, m.cfg.Period, m.cfg.Grace, last))
+	}
+	if !st.Started.IsZero() && now.Sub(st.Started) > m.cfg.Grace {
+		messages = append(messages, i18n.T("heartbeat.running_long", m.name, st.Started.Format(time.DateTime), m.cfg.Grace))
+	}
+
+	result := monitor.Result{Success: len(messages) == 0, Labels: m.labels()}
+	if body := strings.TrimSpace(st.Body); body != "" {
+		result.LogLines = strings.Split(body, "\n")
+	}
+	if result.Success {
+		result.Message = i18n.T("heartbeat.ok", m.name, last)
+		return result
+	}
+	result.Message = strings.Join(messages, "\n")
+	result.Severity = monitor.SeverityCritical
+	return result
+}
+
+// labels 返回附加在结果上的标签
+func (m *HeartbeatMonitor) labels() map[string]string {
+	return map[string]string{"type": "heartbeat", "service": m.name}
+}
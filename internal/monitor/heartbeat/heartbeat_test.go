@@ -0,0 +1,140 @@
+package heartbeat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const token = "backup-job-token"
+
+func newMonitor(t *testing.T, statePath string) *HeartbeatMonitor {
+	t.Helper()
+	m, err := New(Config{Name: "backup", Token: token, Period: time.Hour, Grace: 10 * time.Minute}, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		status  int
+		success bool // 请求之后检查的结果
+	}{
+		{"success", http.MethodGet, "/ping/" + token, "", http.StatusOK, true},
+		{"explicit success", http.MethodPost, "/ping/" + token + "/success", "done", http.StatusOK, true},
+		{"exit code 0", http.MethodGet, "/ping/" + token + "/0", "", http.StatusOK, true},
+		{"exit code 2", http.MethodPost, "/ping/" + token + "/2", "disk full", http.StatusOK, false},
+		{"fail", http.MethodPost, "/ping/" + token + "/fail", "disk full", http.StatusOK, false},
+		{"start", http.MethodGet, "/ping/" + token + "/start", "", http.StatusOK, true},
+		{"log", http.MethodPost, "/ping/" + token + "/log", "50% done", http.StatusOK, true},
+		{"unknown token", http.MethodGet, "/ping/unknown-token", "", http.StatusNotFound, true},
+		{"unknown action", http.MethodGet, "/ping/" + token + "/restart", "", http.StatusBadRequest, true},
+		{"exit code out of range", http.MethodGet, "/ping/" + token + "/256", "", http.StatusBadRequest, true},
+		{"method not allowed", http.MethodDelete, "/ping/" + token, "", http.StatusMethodNotAllowed, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMonitor(t, "")
+			s := httptest.NewServer(NewHandler([]*HeartbeatMonitor{m}))
+			defer s.Close()
+
+			req, err := http.NewRequest(tt.method, s.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			r := m.Check()
+			if r.Success != tt.success {
+				t.Errorf("Success = %v, want %v (%s)", r.Success, tt.success, r.Message)
+			}
+			if tt.status == http.StatusOK && tt.body != "" && !slices.Equal(r.LogLines, []string{tt.body}) {
+				t.Errorf("LogLines = %q, want %q", r.LogLines, tt.body)
+			}
+		})
+	}
+}
+
+func TestHandlerBodyLimit(t *testing.T) {
+	m := newMonitor(t, "")
+	s := httptest.NewServer(NewHandler([]*HeartbeatMonitor{m}))
+	defer s.Close()
+	resp, err := http.Post(s.URL+"/ping/"+token+"/log", "text/plain", strings.NewReader(strings.Repeat("x", 2*MaxBody)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if got := len(m.st.Body); got != MaxBody {
+		t.Errorf("stored %d bytes, want %d", got, MaxBody)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		pings   []Kind
+		ago     time.Duration // 最后一次 ping 距现在的时间
+		success bool
+	}{
+		{"never pinged within period", nil, 0, true},
+		{"recent success", []Kind{KindSuccess}, 30 * time.Minute, true},
+		{"within grace", []Kind{KindSuccess}, 65 * time.Minute, true},
+		{"overdue", []Kind{KindSuccess}, 75 * time.Minute, false},
+		{"recent failure", []Kind{KindFail}, time.Minute, false},
+		{"recovered", []Kind{KindFail, KindSuccess}, time.Minute, true},
+		{"started recently", []Kind{KindSuccess, KindStart}, 5 * time.Minute, true},
+		{"running too long", []Kind{KindSuccess, KindStart}, 15 * time.Minute, false},
+		{"log keeps status", []Kind{KindFail, KindLog}, time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMonitor(t, "")
+			for _, kind := range tt.pings {
+				m.Ping(kind, "", now.Add(-tt.ago))
+			}
+			if r := m.Check(); r.Success != tt.success {
+				t.Errorf("Success = %v, want %v (%s)", r.Success, tt.success, r.Message)
+			}
+		})
+	}
+}
+
+func TestNeverPinged(t *testing.T) {
+	m := newMonitor(t, "")
+	m.st.Since = time.Now().Add(-2 * time.Hour)
+	if r := m.Check(); r.Success {
+		t.Errorf("no ping since %v was not reported", m.st.Since)
+	}
+}
+
+func TestStatePersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heartbeat.json")
+	m := newMonitor(t, path)
+	m.Ping(KindFail, "exit 1", time.Now())
+
+	// 重启后仍然记得上次的失败
+	restored := newMonitor(t, path)
+	r := restored.Check()
+	if r.Success || !slices.Equal(r.LogLines, []string{"exit 1"}) {
+		t.Errorf("after restart: Success = %v, LogLines = %q, want the failure", r.Success, r.LogLines)
+	}
+}
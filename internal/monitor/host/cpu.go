@@ -0,0 +1,84 @@
+package host
+
+import (
+	"bufio"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cpuTimes 是 /proc/stat 中所有 CPU 的累计时间 (单位为 jiffies)
+type cpuTimes struct {
+	idle, total uint64
+}
+
+// CPUMonitor 根据两次检查之间 /proc/stat 的变化计算 CPU 使用率
+type CPUMonitor struct {
+	threshold Threshold
+	last      *cpuTimes
+}
+
+// Name 返回监控器名称
+func (m *CPUMonitor) Name() string {
+	return "host-cpu"
+}
+
+// Check 计算自上次检查以来的 CPU 使用率，第一次检查只记录基准
+func (m *CPUMonitor) Check() monitor.Result {
+	now, err := readCPUTimes(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return failure("cpu", i18n.T("host.stat_failed", err), err)
+	}
+	last := m.last
+	m.last = &now
+	if last == nil || now.total <= last.total {
+		return result("cpu", nil, i18n.T("host.cpu_baseline"))
+	}
+
+	// iowait 是估计值，内核可能让它倒退，所以空闲时间的差值可能为负
+	total := now.total - last.total
+	idle := min(max(int64(now.idle)-int64(last.idle), 0), int64(total))
+	pct := percent(total-uint64(idle), total)
+	var problems []problem
+	if severity, limit, ok := m.threshold.level(pct); ok {
+		problems = append(problems, problem{i18n.T("host.cpu_high", pct, limit), severity})
+	}
+	return result("cpu", problems, i18n.T("host.cpu_ok", pct))
+}
+
+// readCPUTimes 读取 /proc/stat 的第一行 "cpu user nice system idle iowait irq softirq steal ..."
+func readCPUTimes(path string) (cpuTimes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cpuTimes{}, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		var t cpuTimes
+		// guest 和 guest_nice 已经计入 user 和 nice，不重复累加
+		for i, s := range fields[1:min(len(fields), 9)] {
+			v, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return cpuTimes{}, err
+			}
+			t.total += v
+			// idle 和 iowait 视为空闲
+			if i == 3 || i == 4 {
+				t.idle += v
+			}
+		}
+		return t, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return cpuTimes{}, err
+	}
+	return cpuTimes{}, i18n.Errorf("host.stat_malformed")
+}
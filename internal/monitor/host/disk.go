@@ -0,0 +1,193 @@
+package host
+
+import (
+	"bufio"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/units"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultExcludeFSTypes 是默认跳过的文件系统类型:
+// 只读镜像 (例如 snap 包) 的使用率总是 100%，对 autofs 调用 statfs 会触发自动挂载
+var DefaultExcludeFSTypes = []string{"squashfs", "iso9660", "udf", "autofs"}
+
+// statfsTimeout 是单个挂载点 statfs 的超时时间，挂起的 NFS/CIFS 挂载不会阻塞其他检查
+const statfsTimeout = 5 * time.Second
+
+// DiskConfig 描述挂载点的空间和 inode 使用率检查
+type DiskConfig struct {
+	Usage          Threshold `yaml:"usage"`          // 空间使用率百分比
+	Inodes         Threshold `yaml:"inodes"`         // inode 使用率百分比
+	Include        []string  `yaml:"include"`        // 只检查匹配的挂载点 (glob)，为空时检查全部
+	Exclude        []string  `yaml:"exclude"`        // 跳过匹配的挂载点 (glob)
+	ExcludeFSTypes []string  `yaml:"excludeFSTypes"` // 跳过的文件系统类型，默认 DefaultExcludeFSTypes
+}
+
+// usage 是一个挂载点的使用情况
+type usage struct {
+	total, avail, free uint64 // 字节
+	files, filesFree   uint64
+}
+
+type statfsResult struct {
+	usage usage
+	err   error
+}
+
+// DiskMonitor 检查各挂载点的空间和 inode 使用率
+type DiskMonitor struct {
+	cfg     DiskConfig
+	statfs  func(path string) (usage, error)
+	timeout time.Duration
+	pending map[string]chan statfsResult // 超时后仍在运行的 statfs 调用
+}
+
+func newDisk(cfg DiskConfig) *DiskMonitor {
+	if len(cfg.ExcludeFSTypes) == 0 {
+		cfg.ExcludeFSTypes = DefaultExcludeFSTypes
+	}
+	return &DiskMonitor{cfg: cfg, statfs: statfs, timeout: statfsTimeout, pending: make(map[string]chan statfsResult)}
+}
+
+// Name 返回监控器名称
+func (m *DiskMonitor) Name() string {
+	return "host-disk"
+}
+
+// Check 读取挂载列表并逐个检查使用率
+func (m *DiskMonitor) Check() monitor.Result {
+	mounts, err := readMounts(filepath.Join(procRoot, "self", "mounts"))
+	if err != nil {
+		return failure("disk", i18n.T("host.mounts_failed", err), err)
+	}
+	var problems []problem
+	checked := 0
+	for _, mnt := range mounts {
+		if !m.wanted(mnt) {
+			continue
+		}
+		u, err := m.stat(mnt.path)
+		if err != nil {
+			// 挂载点可能已被卸载或没有权限，记录后继续检查其他挂载点
+			problems = append(problems, problem{i18n.T("host.statfs_failed", mnt.path, err), monitor.SeverityWarning})
+			continue
+		}
+		if u.total == 0 {
+			// 伪文件系统
+			continue
+		}
+		checked++
+		// 与 df 一致: 已用 / (已用 + 普通用户可用)
+		used := u.total - u.free
+		pct := percent(used, used+u.avail)
+		if severity, limit, ok := m.cfg.Usage.level(pct); ok {
+			problems = append(problems, problem{i18n.T("host.disk_usage", mnt.path, mnt.device, pct, units.FormatBytes(u.avail), limit), severity})
+		}
+		if u.files > 0 {
+			pct := percent(u.files-u.filesFree, u.files)
+			if severity, limit, ok := m.cfg.Inodes.level(pct); ok {
+				problems = append(problems, problem{i18n.T("host.inode_usage", mnt.path, mnt.device, pct, limit), severity})
+			}
+		}
+	}
+	return result("disk", problems, i18n.T("host.disk_ok", checked))
+}
+
+// stat 在超时时间内对 path 调用 statfs。超时的调用无法取消，会继续在后台运行，
+// 下次检查时等待它的结果而不是再启动一个，所以挂起的挂载点最多占用一个 goroutine
+func (m *DiskMonitor) stat(path string) (usage, error) {
+	ch, ok := m.pending[path]
+	if !ok {
+		ch = make(chan statfsResult, 1)
+		go func() {
+			u, err := m.statfs(path)
+			ch <- statfsResult{u, err}
+		}()
+	}
+	timer := time.NewTimer(m.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-ch:
+		delete(m.pending, path)
+		return r.usage, r.err
+	case <-timer.C:
+		m.pending[path] = ch
+		return usage{}, i18n.Errorf("host.statfs_timeout", m.timeout)
+	}
+}
+
+// wanted 按文件系统类型和 include/exclude 过滤挂载点
+func (m *DiskMonitor) wanted(mnt mount) bool {
+	if slices.Contains(m.cfg.ExcludeFSTypes, mnt.fstype) {
+		return false
+	}
+	if len(m.cfg.Include) > 0 && !matchAny(m.cfg.Include, mnt.path) {
+		return false
+	}
+	return !matchAny(m.cfg.Exclude, mnt.path)
+}
+
+func matchAny(patterns []string, path string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mount 是挂载列表中的一项
+type mount struct {
+	device, path, fstype string
+}
+
+// readMounts 解析 /proc/self/mounts，同一挂载点只保留最后一次挂载
+func readMounts(path string) ([]mount, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var mounts []mount
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		mnt := mount{device: unescape(fields[0]), path: unescape(fields[1]), fstype: fields[2]}
+		if i, ok := index[mnt.path]; ok {
+			mounts[i] = mnt
+			continue
+		}
+		index[mnt.path] = len(mounts)
+		mounts = append(mounts, mnt)
+	}
+	return mounts, scanner.Err()
+}
+
+// unescape 还原挂载列表中以 \040 形式转义的空格等字符
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
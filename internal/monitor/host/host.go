@@ -0,0 +1,163 @@
+package host
+
+import (
+	"bufio"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procRoot 是 proc 文件系统的挂载位置，测试时指向 testdata
+var procRoot = "/proc"
+
+// Threshold 是一组告警阈值，0 表示不检查该级别
+type Threshold struct {
+	Warning  float64 `yaml:"warning"`
+	Critical float64 `yaml:"critical"`
+}
+
+// Enabled 判断是否设置了任一阈值
+func (t Threshold) Enabled() bool {
+	return t.Warning > 0 || t.Critical > 0
+}
+
+func (t Threshold) validate(name string) error {
+	if t.Warning < 0 || t.Critical < 0 || (t.Critical > 0 && t.Warning > t.Critical) {
+		return i18n.Errorf("host.threshold_invalid", name)
+	}
+	return nil
+}
+
+// level 返回 v 达到的告警级别及对应的阈值，未达到任何阈值时返回 false
+func (t Threshold) level(v float64) (monitor.Severity, float64, bool) {
+	switch {
+	case t.Critical > 0 && v >= t.Critical:
+		return monitor.SeverityCritical, t.Critical, true
+	case t.Warning > 0 && v >= t.Warning:
+		return monitor.SeverityWarning, t.Warning, true
+	}
+	return "", 0, false
+}
+
+// Config 描述主机资源监控，每一项设置了阈值才会启用
+type Config struct {
+	Disk   DiskConfig   `yaml:"disk"`
+	Memory MemoryConfig `yaml:"memory"`
+	Load   LoadConfig   `yaml:"load"`
+	CPU    Threshold    `yaml:"cpu"` // CPU 使用率百分比
+}
+
+// Validate 检查配置
+func (c Config) Validate() error {
+	for name, t := range map[string]Threshold{
+		"disk.usage":   c.Disk.Usage,
+		"disk.inodes":  c.Disk.Inodes,
+		"memory.usage": c.Memory.Usage,
+		"memory.swap":  c.Memory.Swap,
+		"load":         c.Load.threshold(),
+		"cpu":          c.CPU,
+	} {
+		if err := t.validate(name); err != nil {
+			return err
+		}
+	}
+	for _, p := range append(append([]string(nil), c.Disk.Include...), c.Disk.Exclude...) {
+		if _, err := filepath.Match(p, "/"); err != nil {
+			return i18n.Errorf("host.pattern_invalid", p, err)
+		}
+	}
+	if _, err := c.Load.field(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// New 创建所有已启用的主机资源监控器
+func New(cfg Config) ([]monitor.Monitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var monitors []monitor.Monitor
+	if cfg.Disk.Usage.Enabled() || cfg.Disk.Inodes.Enabled() {
+		monitors = append(monitors, newDisk(cfg.Disk))
+	}
+	if cfg.Memory.Usage.Enabled() || cfg.Memory.Swap.Enabled() {
+		monitors = append(monitors, &MemoryMonitor{cfg: cfg.Memory})
+	}
+	if cfg.Load.threshold().Enabled() {
+		monitors = append(monitors, &LoadMonitor{cfg: cfg.Load})
+	}
+	if cfg.CPU.Enabled() {
+		monitors = append(monitors, &CPUMonitor{threshold: cfg.CPU})
+	}
+	return monitors, nil
+}
+
+// problem 是一次检查中超过阈值的一项
+type problem struct {
+	message  string
+	severity monitor.Severity
+}
+
+// result 把检查结果转换为 monitor.Result，严重程度取所有问题中最高的
+func result(resource string, problems []problem, ok string) monitor.Result {
+	labels := map[string]string{"type": "host", "service": resource}
+	if len(problems) == 0 {
+		return monitor.Result{Success: true, Message: ok, Labels: labels}
+	}
+	severity := monitor.SeverityWarning
+	messages := make([]string, len(problems))
+	for i, p := range problems {
+		messages[i] = p.message
+		if p.severity == monitor.SeverityCritical {
+			severity = monitor.SeverityCritical
+		}
+	}
+	return monitor.Result{Success: false, Message: strings.Join(messages, "\n"), Severity: severity, Labels: labels}
+}
+
+// failure 返回读取系统信息失败时的结果
+func failure(resource, msg string, err error) monitor.Result {
+	return monitor.Result{
+		Success: false,
+		Message: msg,
+		Err:     err,
+		Labels:  map[string]string{"type": "host", "service": resource},
+	}
+}
+
+// readKeyValues 读取 /proc/meminfo 这类 "Key: value" 格式的文件，值取第一个数字
+func readKeyValues(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+			values[key] = v
+		}
+	}
+	return values, scanner.Err()
+}
+
+// percent 计算 used 占 total 的百分比，total 为 0 时返回 0
+func percent(used, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used) * 100 / float64(total)
+}
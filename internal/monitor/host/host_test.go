@@ -0,0 +1,193 @@
+package host
+
+import (
+	"fmt"
+	"hashcowuwu/lychee/internal/monitor"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useProc 让监控器在测试期间读取 dir 下的 proc 文件
+func useProc(t *testing.T, dir string) {
+	t.Helper()
+	old := procRoot
+	procRoot = dir
+	t.Cleanup(func() { procRoot = old })
+}
+
+func TestReadCPUTimes(t *testing.T) {
+	got, err := readCPUTimes("testdata/proc/stat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// guest 和 guest_nice 不计入总时间
+	want := cpuTimes{idle: 3699176 + 23060, total: 4705 + 356 + 584 + 3699176 + 23060 + 0 + 277 + 0}
+	if got != want {
+		t.Errorf("readCPUTimes() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCPUMonitor(t *testing.T) {
+	dir := t.TempDir()
+	useProc(t, dir)
+	write := func(user, idle, iowait uint64) {
+		line := fmt.Sprintf("cpu  %d 0 0 %d %d 0 0 0 0 0\n", user, idle, iowait)
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := &CPUMonitor{threshold: Threshold{Warning: 50, Critical: 90}}
+	tests := []struct {
+		name               string
+		user, idle, iowait uint64
+		success            bool
+		severity           monitor.Severity
+	}{
+		{"baseline", 100, 1000, 0, true, ""},
+		{"idle", 110, 1090, 0, true, ""},
+		{"busy", 170, 1130, 0, false, monitor.SeverityWarning},
+		{"saturated", 265, 1135, 0, false, monitor.SeverityCritical},
+		{"iowait counts as idle", 270, 1180, 50, true, ""},
+		// idle + iowait 倒退时不能按无符号数回绕成空闲
+		{"idle going backwards", 370, 1200, 0, false, monitor.SeverityCritical},
+	}
+	for _, tt := range tests {
+		write(tt.user, tt.idle, tt.iowait)
+		r := m.Check()
+		if r.Success != tt.success || r.Severity != tt.severity {
+			t.Errorf("%s: got Success = %v, Severity = %q, want %v, %q (%s)", tt.name, r.Success, r.Severity, tt.success, tt.severity, r.Message)
+		}
+	}
+}
+
+func TestMemoryMonitor(t *testing.T) {
+	useProc(t, "testdata/proc")
+	// testdata 中内存使用率为 87.5%，交换空间使用率为 25%
+	tests := []struct {
+		name     string
+		cfg      MemoryConfig
+		success  bool
+		severity monitor.Severity
+	}{
+		{"below", MemoryConfig{Usage: Threshold{Warning: 90}}, true, ""},
+		{"usage warning", MemoryConfig{Usage: Threshold{Warning: 80, Critical: 95}}, false, monitor.SeverityWarning},
+		{"usage critical", MemoryConfig{Usage: Threshold{Critical: 85}}, false, monitor.SeverityCritical},
+		{"swap warning", MemoryConfig{Swap: Threshold{Warning: 20}}, false, monitor.SeverityWarning},
+		{"worst wins", MemoryConfig{Usage: Threshold{Critical: 85}, Swap: Threshold{Warning: 20}}, false, monitor.SeverityCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := (&MemoryMonitor{cfg: tt.cfg}).Check()
+			if r.Success != tt.success || r.Severity != tt.severity {
+				t.Errorf("got Success = %v, Severity = %q, want %v, %q (%s)", r.Success, r.Severity, tt.success, tt.severity, r.Message)
+			}
+		})
+	}
+}
+
+func TestLoadMonitor(t *testing.T) {
+	useProc(t, "testdata/proc")
+	// testdata 中的平均负载为 3.50 2.25 1.10
+	tests := []struct {
+		name     string
+		cfg      LoadConfig
+		success  bool
+		severity monitor.Severity
+	}{
+		{"default 5m below", LoadConfig{Warning: 3}, true, ""},
+		{"1m warning", LoadConfig{Warning: 3, Critical: 5, Period: "1m"}, false, monitor.SeverityWarning},
+		{"5m critical", LoadConfig{Warning: 1, Critical: 2}, false, monitor.SeverityCritical},
+		{"15m below", LoadConfig{Warning: 2, Period: "15m"}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := (&LoadMonitor{cfg: tt.cfg}).Check()
+			if r.Success != tt.success || r.Severity != tt.severity {
+				t.Errorf("got Success = %v, Severity = %q, want %v, %q (%s)", r.Success, r.Severity, tt.success, tt.severity, r.Message)
+			}
+		})
+	}
+}
+
+func TestReadMounts(t *testing.T) {
+	got, err := readMounts("testdata/proc/self/mounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []mount{
+		{"/dev/sda1", "/", "ext4"},
+		{"proc", "/proc", "proc"},
+		{"/dev/loop0", "/snap/core/123", "squashfs"},
+		{"/dev/sdb1", "/mnt/my disk", "ext4"},
+		// 同一挂载点只保留最后一次挂载
+		{"/dev/sdc1", "/run", "xfs"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("readMounts() = %q, want %q", got, want)
+	}
+}
+
+func TestDiskWanted(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  DiskConfig
+		want []string
+	}{
+		{"default", DiskConfig{}, []string{"/", "/proc", "/mnt/my disk", "/run"}},
+		{"include", DiskConfig{Include: []string{"/", "/mnt/*"}}, []string{"/", "/mnt/my disk"}},
+		{"exclude", DiskConfig{Exclude: []string{"/proc", "/run"}}, []string{"/", "/mnt/my disk"}},
+		{"fstypes", DiskConfig{ExcludeFSTypes: []string{"proc", "xfs"}}, []string{"/", "/snap/core/123", "/mnt/my disk"}},
+	}
+	mounts, err := readMounts("testdata/proc/self/mounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		m := newDisk(tt.cfg)
+		var got []string
+		for _, mnt := range mounts {
+			if m.wanted(mnt) {
+				got = append(got, mnt.path)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: wanted mounts = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiskStatfsTimeout(t *testing.T) {
+	useProc(t, "testdata/proc")
+	release := make(chan struct{})
+	defer close(release)
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	m := newDisk(DiskConfig{Include: []string{"/", "/run"}})
+	m.timeout = 20 * time.Millisecond
+	m.statfs = func(path string) (usage, error) {
+		mu.Lock()
+		calls[path]++
+		mu.Unlock()
+		if path == "/run" {
+			<-release
+		}
+		return usage{total: 100, avail: 50, free: 50}, nil
+	}
+	for i := range 2 {
+		r := m.Check()
+		if r.Success || r.Severity != monitor.SeverityWarning || !strings.Contains(r.Message, "/run") {
+			t.Fatalf("check %d: got Success = %v, Severity = %q, want a warning for /run (%s)", i, r.Success, r.Severity, r.Message)
+		}
+	}
+	// 挂起的调用不会被重复启动
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["/"] != 2 || calls["/run"] != 1 {
+		t.Errorf("statfs calls = %v, want / twice and /run once", calls)
+	}
+}
@@ -0,0 +1,81 @@
+package host
+
+import (
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// LoadConfig 描述平均负载检查
+type LoadConfig struct {
+	Warning  float64 `yaml:"warning"`
+	Critical float64 `yaml:"critical"`
+	Period   string  `yaml:"period"` // 比较的平均负载: 1m / 5m (默认) / 15m
+	PerCPU   bool    `yaml:"perCPU"` // 阈值按每个 CPU 计算，即负载除以 CPU 核数后再比较
+}
+
+func (c LoadConfig) threshold() Threshold {
+	return Threshold{Warning: c.Warning, Critical: c.Critical}
+}
+
+// field 返回 /proc/loadavg 中对应 Period 的列
+func (c LoadConfig) field() (int, error) {
+	switch c.Period {
+	case "1m":
+		return 0, nil
+	case "", "5m":
+		return 1, nil
+	case "15m":
+		return 2, nil
+	}
+	return 0, i18n.Errorf("host.period_unknown", c.Period)
+}
+
+// LoadMonitor 读取 /proc/loadavg 检查平均负载
+type LoadMonitor struct {
+	cfg LoadConfig
+}
+
+// Name 返回监控器名称
+func (m *LoadMonitor) Name() string {
+	return "host-load"
+}
+
+// Check 比较平均负载与阈值
+func (m *LoadMonitor) Check() monitor.Result {
+	data, err := os.ReadFile(filepath.Join(procRoot, "loadavg"))
+	if err != nil {
+		return failure("load", i18n.T("host.loadavg_failed", err), err)
+	}
+	fields := strings.Fields(string(data))
+	var loads [3]float64
+	for i := range loads {
+		if i >= len(fields) {
+			err = i18n.Errorf("host.loadavg_malformed", string(data))
+			return failure("load", i18n.T("host.loadavg_failed", err), err)
+		}
+		if loads[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return failure("load", i18n.T("host.loadavg_failed", err), err)
+		}
+	}
+
+	field, _ := m.cfg.field()
+	period := []string{"1m", "5m", "15m"}[field]
+	load, cpus := loads[field], runtime.NumCPU()
+	value := load
+	if m.cfg.PerCPU {
+		value = load / float64(cpus)
+	}
+	var problems []problem
+	if severity, limit, ok := m.cfg.threshold().level(value); ok {
+		if m.cfg.PerCPU {
+			limit *= float64(cpus)
+		}
+		problems = append(problems, problem{i18n.T("host.load_high", period, load, limit, cpus), severity})
+	}
+	return result("load", problems, i18n.T("host.load_ok", loads[0], loads[1], loads[2]))
+}
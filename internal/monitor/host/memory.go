@@ -0,0 +1,46 @@
+package host
+
+import (
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/units"
+	"path/filepath"
+)
+
+// MemoryConfig 描述内存和交换空间使用率检查
+type MemoryConfig struct {
+	Usage Threshold `yaml:"usage"` // 内存使用率百分比，按 MemAvailable 计算
+	Swap  Threshold `yaml:"swap"`  // 交换空间使用率百分比
+}
+
+// MemoryMonitor 读取 /proc/meminfo 检查内存和交换空间
+type MemoryMonitor struct {
+	cfg MemoryConfig
+}
+
+// Name 返回监控器名称
+func (m *MemoryMonitor) Name() string {
+	return "host-memory"
+}
+
+// Check 计算内存和交换空间的使用率
+func (m *MemoryMonitor) Check() monitor.Result {
+	info, err := readKeyValues(filepath.Join(procRoot, "meminfo"))
+	if err != nil {
+		return failure("memory", i18n.T("host.meminfo_failed", err), err)
+	}
+	// meminfo 中的单位是 KiB
+	total, available := info["MemTotal"]*1024, info["MemAvailable"]*1024
+	swapTotal, swapFree := info["SwapTotal"]*1024, info["SwapFree"]*1024
+
+	var problems []problem
+	memPct := percent(total-min(available, total), total)
+	if severity, limit, ok := m.cfg.Usage.level(memPct); ok {
+		problems = append(problems, problem{i18n.T("host.memory_usage", memPct, units.FormatBytes(available), units.FormatBytes(total), limit), severity})
+	}
+	swapPct := percent(swapTotal-min(swapFree, swapTotal), swapTotal)
+	if severity, limit, ok := m.cfg.Swap.level(swapPct); ok {
+		problems = append(problems, problem{i18n.T("host.swap_usage", swapPct, units.FormatBytes(swapFree), units.FormatBytes(swapTotal), limit), severity})
+	}
+	return result("memory", problems, i18n.T("host.memory_ok", memPct, swapPct))
+}
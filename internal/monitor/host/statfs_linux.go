@@ -0,0 +1,21 @@
+//go:build linux
+
+package host
+
+import "syscall"
+
+// statfs 读取挂载点的块和 inode 使用情况
+func statfs(path string) (usage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return usage{}, err
+	}
+	bsize := uint64(st.Bsize)
+	return usage{
+		total:     st.Blocks * bsize,
+		avail:     st.Bavail * bsize,
+		free:      st.Bfree * bsize,
+		files:     st.Files,
+		filesFree: st.Ffree,
+	}, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package host
+
+import "hashcowuwu/lychee/internal/i18n"
+
+// statfs 在非 Linux 系统上不可用
+func statfs(path string) (usage, error) {
+	return usage{}, i18n.Errorf("host.unsupported")
+}
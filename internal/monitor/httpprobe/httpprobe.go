@@ -0,0 +1,318 @@
+package httpprobe
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 探测的默认值
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultMaxRedirects = 10
+	// maxBodySize 是读取响应正文的上限，正文断言只作用于这部分内容
+	maxBodySize = 1 << 20
+)
+
+// 重定向策略
+const (
+	RedirectFollow = "follow" // 跟随重定向 (默认)，最多 maxRedirects 次
+	RedirectNone   = "none"   // 不跟随，直接检查 3xx 响应
+)
+
+// Config 描述一个 HTTP(S) 探测
+type Config struct {
+	Name         string            `yaml:"name"`   // 默认使用 URL
+	Method       string            `yaml:"method"` // 默认 GET
+	URL          string            `yaml:"url"`
+	Headers      map[string]string `yaml:"headers"`
+	Body         string            `yaml:"body"`
+	ExpectStatus []string          `yaml:"expectStatus"` // 期望的状态码，支持 "200"、"2xx" 和 "200-299"，默认 2xx
+	BodyRegex    string            `yaml:"bodyRegex"`    // 响应正文需要匹配的正则表达式
+	JSON         []JSONAssertion   `yaml:"json"`         // 对 JSON 响应的断言
+	Timeout      time.Duration     `yaml:"timeout"`      // 默认 10s
+	Redirects    string            `yaml:"redirects"`    // follow / none
+	MaxRedirects int               `yaml:"maxRedirects"` // 最多跟随的重定向次数，超过时请求失败；0 表示默认的 10，不跟随重定向请用 redirects: none
+
+	CAFile             string `yaml:"caFile"`   // 额外信任的 CA 证书 (PEM)
+	CertFile           string `yaml:"certFile"` // 客户端证书，与 keyFile 一起使用
+	KeyFile            string `yaml:"keyFile"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+
+	LatencyWarning  time.Duration `yaml:"latencyWarning"`  // 响应耗时超过该值时产生 warning 告警
+	LatencyCritical time.Duration `yaml:"latencyCritical"` // 响应耗时超过该值时产生 critical 告警
+}
+
+// JSONAssertion 检查 JSON 响应中 path 处的值，value 为空时只检查路径存在
+type JSONAssertion struct {
+	Path  string `yaml:"path"` // 例如 $.status、data.items[0].name
+	Value string `yaml:"value"`
+}
+
+// Validate 检查配置，不访问文件和网络
+func (c Config) Validate() error {
+	u, err := url.Parse(c.URL)
+	if c.URL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return i18n.Errorf("http.url_invalid", c.URL)
+	}
+	if _, err := parseStatus(c.ExpectStatus); err != nil {
+		return err
+	}
+	if c.BodyRegex != "" {
+		if _, err := regexp.Compile(c.BodyRegex); err != nil {
+			return i18n.Errorf("http.regex_invalid", c.BodyRegex, err)
+		}
+	}
+	for _, a := range c.JSON {
+		if _, err := parsePath(a.Path); err != nil {
+			return err
+		}
+	}
+	if c.Redirects != "" && c.Redirects != RedirectFollow && c.Redirects != RedirectNone {
+		return i18n.Errorf("http.redirects_unknown", c.Redirects)
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return i18n.Errorf("http.client_cert_incomplete")
+	}
+	if c.Timeout < 0 || c.MaxRedirects < 0 || c.LatencyWarning < 0 || c.LatencyCritical < 0 {
+		return i18n.Errorf("http.negative")
+	}
+	return nil
+}
+
+// HTTPMonitor 定期请求一个 URL 并检查响应
+type HTTPMonitor struct {
+	cfg      Config
+	name     string
+	client   *http.Client
+	status   []statusRange
+	bodyRe   *regexp.Regexp
+	jsonPath [][]pathStep
+}
+
+// New 根据配置创建 HTTP 探测，会读取 CA 和客户端证书文件
+func New(cfg Config) (*HTTPMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	m := &HTTPMonitor{cfg: cfg, name: cfg.Name}
+	if m.name == "" {
+		m.name = cfg.URL
+	}
+	if m.cfg.Method == "" {
+		m.cfg.Method = http.MethodGet
+	}
+	m.status, _ = parseStatus(cfg.ExpectStatus)
+	if cfg.BodyRegex != "" {
+		m.bodyRe = regexp.MustCompile(cfg.BodyRegex)
+	}
+	for _, a := range cfg.JSON {
+		steps, _ := parsePath(a.Path)
+		m.jsonPath = append(m.jsonPath, steps)
+	}
+
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	maxRedirects := cfg.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = DefaultMaxRedirects
+	}
+	m.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			TLSClientConfig:   tlsConfig,
+			DisableKeepAlives: true, // 每次探测都重新建立连接，耗时才包含握手
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if cfg.Redirects == RedirectNone {
+				return http.ErrUseLastResponse
+			}
+			// via 包含之前的所有请求，第 n 次重定向时长度为 n
+			if len(via) > maxRedirects {
+				return i18n.Errorf("http.too_many_redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+	return m, nil
+}
+
+// tlsConfig 加载自定义 CA 和客户端证书
+func (c Config) tlsConfig() (*tls.Config, error) {
+	tc := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, i18n.Errorf("http.ca_invalid", c.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, i18n.Errorf("http.ca_invalid", c.CAFile, i18n.Errorf("http.no_certificates"))
+		}
+		tc.RootCAs = pool
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, i18n.Errorf("http.client_cert_invalid", c.CertFile, err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
+
+// Name 返回监控器名称
+func (m *HTTPMonitor) Name() string {
+	return fmt.Sprintf("http(%s)", m.name)
+}
+
+// Check 发送一次请求并依次检查状态码、正文、JSON 断言和耗时
+func (m *HTTPMonitor) Check() monitor.Result {
+	req, err := http.NewRequest(m.cfg.Method, m.cfg.URL, strings.NewReader(m.cfg.Body))
+	if err != nil {
+		return m.fail(i18n.T("http.request_failed", m.name, err), err)
+	}
+	for k, v := range m.cfg.Headers {
+		if strings.EqualFold(k, "host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return m.fail(i18n.T("http.request_failed", m.name, err), err)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	resp.Body.Close()
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		return m.fail(i18n.T("http.request_failed", m.name, err), err)
+	}
+
+	if !matchStatus(m.status, resp.StatusCode) {
+		return m.fail(i18n.T("http.unexpected_status", m.name, resp.StatusCode, describeStatus(m.cfg.ExpectStatus)), nil)
+	}
+	if m.bodyRe != nil && !m.bodyRe.Match(body) {
+		return m.fail(i18n.T("http.body_mismatch", m.name, m.cfg.BodyRegex), nil)
+	}
+	if len(m.jsonPath) > 0 {
+		if msg := m.checkJSON(body); msg != "" {
+			return m.fail(msg, nil)
+		}
+	}
+
+	switch {
+	case m.cfg.LatencyCritical > 0 && latency > m.cfg.LatencyCritical:
+		return m.fail(i18n.T("http.slow", m.name, latency, m.cfg.LatencyCritical), nil)
+	case m.cfg.LatencyWarning > 0 && latency > m.cfg.LatencyWarning:
+		r := m.fail(i18n.T("http.slow", m.name, latency, m.cfg.LatencyWarning), nil)
+		r.Severity = monitor.SeverityWarning
+		return r
+	}
+	return monitor.Result{
+		Success: true,
+		Message: i18n.T("http.ok", m.name, resp.StatusCode, latency),
+		Labels:  m.labels(),
+	}
+}
+
+// checkJSON 执行 JSON 断言，全部通过时返回空字符串
+func (m *HTTPMonitor) checkJSON(body []byte) string {
+	doc, err := decodeJSON(body)
+	if err != nil {
+		return i18n.T("http.json_invalid", m.name, err)
+	}
+	for i, a := range m.cfg.JSON {
+		v, ok := lookup(doc, m.jsonPath[i])
+		if !ok {
+			return i18n.T("http.json_missing", m.name, a.Path)
+		}
+		if a.Value != "" && jsonString(v) != a.Value {
+			return i18n.T("http.json_mismatch", m.name, a.Path, jsonString(v), a.Value)
+		}
+	}
+	return ""
+}
+
+func (m *HTTPMonitor) fail(msg string, err error) monitor.Result {
+	return monitor.Result{Success: false, Message: msg, Err: err, Severity: monitor.SeverityCritical, Labels: m.labels()}
+}
+
+// labels 返回附加在结果上的标签
+func (m *HTTPMonitor) labels() map[string]string {
+	return map[string]string{"type": "http", "service": m.name}
+}
+
+// statusRange 是一段闭区间的状态码
+type statusRange struct{ lo, hi int }
+
+// parseStatus 解析 expectStatus，为空时表示 2xx
+func parseStatus(specs []string) ([]statusRange, error) {
+	if len(specs) == 0 {
+		return []statusRange{{200, 299}}, nil
+	}
+	var ranges []statusRange
+	for _, s := range specs {
+		s = strings.ToLower(strings.TrimSpace(s))
+		var r statusRange
+		var err error
+		switch {
+		case len(s) == 3 && strings.HasSuffix(s, "xx"):
+			var d int
+			d, err = strconv.Atoi(s[:1])
+			r = statusRange{d * 100, d*100 + 99}
+		case strings.Contains(s, "-"):
+			lo, hi, _ := strings.Cut(s, "-")
+			if r.lo, err = strconv.Atoi(lo); err == nil {
+				r.hi, err = strconv.Atoi(hi)
+			}
+		default:
+			r.lo, err = strconv.Atoi(s)
+			r.hi = r.lo
+		}
+		if err != nil || r.lo < 100 || r.hi > 599 || r.lo > r.hi {
+			return nil, i18n.Errorf("http.status_invalid", s)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func matchStatus(ranges []statusRange, code int) bool {
+	for _, r := range ranges {
+		if code >= r.lo && code <= r.hi {
+			return true
+		}
+	}
+	return false
+}
+
+func describeStatus(specs []string) string {
+	if len(specs) == 0 {
+		return "2xx"
+	}
+	return strings.Join(specs, ", ")
+}
@@ -0,0 +1,73 @@
+package httpprobe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"ok","data":{"items":[{"name":"a"}]}}`)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	// /redirect/n 经过 n 次重定向后到达 /ok
+	mux.HandleFunc("/redirect/{n}", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.PathValue("n"))
+		if n <= 1 {
+			http.Redirect(w, r, "/ok", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/redirect/%d", n-1), http.StatusFound)
+	})
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestCheck(t *testing.T) {
+	s := newServer(t)
+	tests := []struct {
+		name    string
+		cfg     Config
+		success bool
+	}{
+		{"ok", Config{URL: s.URL + "/ok"}, true},
+		{"unexpected status", Config{URL: s.URL + "/missing"}, false},
+		{"expected status", Config{URL: s.URL + "/missing", ExpectStatus: []string{"404"}}, true},
+		{"status range", Config{URL: s.URL + "/ok", ExpectStatus: []string{"300-399"}}, false},
+		{"body matched", Config{URL: s.URL + "/ok", BodyRegex: `"status":\s*"ok"`}, true},
+		{"body mismatched", Config{URL: s.URL + "/ok", BodyRegex: "healthy"}, false},
+		{"json", Config{URL: s.URL + "/ok", JSON: []JSONAssertion{{Path: "$.data.items[0].name", Value: "a"}}}, true},
+		{"json mismatched", Config{URL: s.URL + "/ok", JSON: []JSONAssertion{{Path: "$.status", Value: "down"}}}, false},
+		{"redirects within limit", Config{URL: s.URL + "/redirect/2", MaxRedirects: 3}, true},
+		{"redirects at limit", Config{URL: s.URL + "/redirect/3", MaxRedirects: 3}, true},
+		{"too many redirects", Config{URL: s.URL + "/redirect/4", MaxRedirects: 3}, false},
+		{"single redirect allowed", Config{URL: s.URL + "/redirect/1", MaxRedirects: 1}, true},
+		{"default limit", Config{URL: s.URL + "/redirect/11"}, false},
+		{"redirects not followed", Config{URL: s.URL + "/redirect/1", Redirects: RedirectNone, ExpectStatus: []string{"302"}}, true},
+		{"timeout", Config{URL: s.URL + "/slow", Timeout: 50 * time.Millisecond}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r := m.Check(); r.Success != tt.success {
+				t.Errorf("Success = %v, want %v (%s)", r.Success, tt.success, r.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package httpprobe
+
+import (
+	"bytes"
+	"encoding/json"
+	"hashcowuwu/lychee/internal/i18n"
+	"strconv"
+	"strings"
+)
+
+// pathStep 是 JSON 路径中的一段: 对象的键或数组下标
+type pathStep struct {
+	key   string
+	index int // key 为空时使用
+}
+
+// parsePath 解析简化的 JSON 路径，例如 $.data.items[0].name 或 data.items.0.name
+func parsePath(path string) ([]pathStep, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if p == "" {
+		return nil, i18n.Errorf("http.json_path_invalid", path)
+	}
+	var steps []pathStep
+	for _, part := range strings.Split(p, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name != "" {
+			if i, err := strconv.Atoi(name); err == nil {
+				steps = append(steps, pathStep{index: i})
+			} else {
+				steps = append(steps, pathStep{key: name})
+			}
+		} else if rest == "" {
+			return nil, i18n.Errorf("http.json_path_invalid", path)
+		}
+		// 处理 name 之后的一个或多个 [n]
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			i, err := strconv.Atoi(idx)
+			if !ok || err != nil || i < 0 {
+				return nil, i18n.Errorf("http.json_path_invalid", path)
+			}
+			steps = append(steps, pathStep{index: i})
+			if after == "" {
+				break
+			}
+			if !strings.HasPrefix(after, "[") {
+				return nil, i18n.Errorf("http.json_path_invalid", path)
+			}
+			rest = after[1:]
+		}
+	}
+	return steps, nil
+}
+
+// decodeJSON 解析 JSON，数字保留原始文本以便与期望值比较
+func decodeJSON(body []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// lookup 按路径取值，路径不存在时返回 false
+func lookup(doc any, steps []pathStep) (any, bool) {
+	cur := doc
+	for _, s := range steps {
+		switch v := cur.(type) {
+		case map[string]any:
+			if s.key == "" {
+				s.key = strconv.Itoa(s.index)
+			}
+			next, ok := v[s.key]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			if s.key != "" || s.index >= len(v) {
+				return nil, false
+			}
+			cur = v[s.index]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// jsonString 把 JSON 值转换为用于比较的字符串: 字符串取原值，其余使用 JSON 编码
+func jsonString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return "null"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,146 @@
+package integrity
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContext 是统一格式差异中每处修改前后保留的行数
+	diffContext = 3
+	// maxDiffCells 限制 LCS 表的大小，超过时不生成差异
+	maxDiffCells = 4 << 20
+)
+
+// edit 是差异中的一行: ' ' 未变，'-' 删除，'+' 新增
+type edit struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff 生成 a 到 b 的统一格式差异，文件过大无法比较时返回 false
+func unifiedDiff(path, a, b string) ([]string, bool) {
+	edits, ok := diffLines(splitLines(a), splitLines(b))
+	if !ok {
+		return nil, false
+	}
+	out := []string{"--- " + path, "+++ " + path}
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		// 找到一组相距不超过 2*diffContext 行的修改，连同上下文作为一个 hunk
+		start := max(0, i-diffContext)
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].op != ' ' {
+				end = j
+			} else if j-end > 2*diffContext {
+				break
+			}
+		}
+		end = min(len(edits), end+diffContext+1)
+		out = append(out, hunk(edits, start, end)...)
+		i = end
+	}
+	return out, true
+}
+
+// hunk 格式化 edits[start:end]，行号从整个差异的开头计算
+func hunk(edits []edit, start, end int) []string {
+	aLine, bLine := 1, 1
+	for _, e := range edits[:start] {
+		if e.op != '+' {
+			aLine++
+		}
+		if e.op != '-' {
+			bLine++
+		}
+	}
+	var aCount, bCount int
+	lines := make([]string, 0, end-start+1)
+	lines = append(lines, "")
+	for _, e := range edits[start:end] {
+		if e.op != '+' {
+			aCount++
+		}
+		if e.op != '-' {
+			bCount++
+		}
+		lines = append(lines, string(e.op)+e.text)
+	}
+	// 与 diff -u 一致: 没有行时起始行号指向前一行
+	if aCount == 0 {
+		aLine--
+	}
+	if bCount == 0 {
+		bLine--
+	}
+	lines[0] = fmt.Sprintf("@@ -%d,%d +%d,%d @@", aLine, aCount, bLine, bCount)
+	return lines
+}
+
+// diffLines 用最长公共子序列计算逐行差异
+func diffLines(a, b []string) ([]edit, bool) {
+	// 去掉相同的开头和结尾，减小 LCS 表
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(ma), len(mb)
+	if (n+1)*(m+1) > maxDiffCells {
+		return nil, false
+	}
+
+	// lcs[i][j] 是 ma[i:] 和 mb[j:] 的最长公共子序列长度
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	edits := make([]edit, 0, len(a)+m)
+	for _, s := range a[:prefix] {
+		edits = append(edits, edit{' ', s})
+	}
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && ma[i] == mb[j]:
+			edits = append(edits, edit{' ', ma[i]})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', ma[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', mb[j]})
+			j++
+		}
+	}
+	for _, s := range a[len(a)-suffix:] {
+		edits = append(edits, edit{' ', s})
+	}
+	return edits, true
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,380 @@
+// Package integrity 监控重要文件的完整性: 记录内容哈希、权限、所有者和修改时间作为基线，
+// 文件被修改、新增或删除时告警，小的文本文件附带统一格式的差异。
+package integrity
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/state"
+	"hashcowuwu/lychee/internal/units"
+	"io"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultDiffMaxSize 是未配置 diffMaxSize 时生成差异的文件大小上限
+const DefaultDiffMaxSize = "64KiB"
+
+// Config 描述一组需要监控完整性的文件
+type Config struct {
+	Name        string   `yaml:"name"`        // 默认使用第一个路径
+	Paths       []string `yaml:"paths"`       // 文件、目录 (递归) 或 glob
+	Exclude     []string `yaml:"exclude"`     // 跳过的文件，glob 与完整路径或文件名比较
+	DiffMaxSize string   `yaml:"diffMaxSize"` // 不超过该大小的文本文件会保存内容用于生成差异，默认 64KiB
+	// Diff 为 false 时不生成差异，基线中只保存哈希。文件可能包含密钥等敏感内容时应关闭，
+	// 否则内容会写入状态文件并随告警发送到通知渠道。默认开启
+	Diff        *bool    `yaml:"diff"`
+	DiffExclude []string `yaml:"diffExclude"` // 不生成差异、只保存哈希的文件，glob 与完整路径或文件名比较
+}
+
+// Validate 检查配置，不访问文件系统
+func (c Config) Validate() error {
+	if len(c.Paths) == 0 {
+		return i18n.Errorf("integrity.paths_missing")
+	}
+	for _, p := range slices.Concat(c.Paths, c.Exclude, c.DiffExclude) {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return i18n.Errorf("integrity.pattern_invalid", p, err)
+		}
+	}
+	if c.DiffMaxSize != "" {
+		if _, err := units.ParseBytes(c.DiffMaxSize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileInfo 是基线中一个文件的记录
+type fileInfo struct {
+	Hash    string      `json:"hash"` // 内容的 sha256，符号链接为链接目标的 sha256
+	Mode    fs.FileMode `json:"mode"`
+	UID     uint32      `json:"uid"`
+	GID     uint32      `json:"gid"`
+	Size    int64       `json:"size"`
+	ModTime time.Time   `json:"modTime"`
+	Text    bool        `json:"text,omitempty"`    // 是否保存了内容
+	Content string      `json:"content,omitempty"` // 小文本文件的内容，用于生成差异
+}
+
+// baseline 是持久化的基线
+type baseline struct {
+	Files map[string]fileInfo `json:"files"`
+}
+
+// IntegrityMonitor 比较文件的当前状态与基线。报告变化后新的状态成为基线，每次变化只告警一次。
+type IntegrityMonitor struct {
+	cfg       Config
+	name      string
+	statePath string
+	diffMax   uint64
+
+	mu       sync.Mutex
+	baseline *baseline // 为 nil 时下一次扫描建立基线
+}
+
+// New 创建文件完整性监控器并读取已保存的基线，statePath 为空时不持久化
+func New(cfg Config, statePath string) (*IntegrityMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	m := &IntegrityMonitor{cfg: cfg, name: cmp.Or(cfg.Name, cfg.Paths[0]), statePath: statePath}
+	m.diffMax, _ = units.ParseBytes(cmp.Or(cfg.DiffMaxSize, DefaultDiffMaxSize))
+	if statePath != "" {
+		var b baseline
+		if err := state.Load(statePath, &b); err != nil {
+			log.Print(i18n.T("integrity.state_load_failed", m.name, err))
+		} else if b.Files != nil {
+			m.baseline = &b
+			m.stripContent()
+		}
+	}
+	return m, nil
+}
+
+// stripContent 删除基线中不再需要生成差异的文件内容，例如之后才配置了 diff: false 或 diffExclude
+func (m *IntegrityMonitor) stripContent() {
+	stripped := false
+	for path, fi := range m.baseline.Files {
+		if fi.Content != "" && !m.diffs(path) {
+			fi.Text, fi.Content = false, ""
+			m.baseline.Files[path] = fi
+			stripped = true
+		}
+	}
+	if stripped {
+		m.save()
+	}
+}
+
+// Name 返回监控器名称
+func (m *IntegrityMonitor) Name() string {
+	return fmt.Sprintf("integrity(%s)", m.name)
+}
+
+// Check 扫描所有文件并与基线比较，在 fsnotify 漏掉事件或无法监听时兜底
+func (m *IntegrityMonitor) Check() monitor.Result {
+	return m.compare()
+}
+
+// compare 扫描文件，报告与基线的差异并更新基线
+func (m *IntegrityMonitor) compare() monitor.Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, failed := m.scan()
+	var problems []string
+	for _, path := range slices.Sorted(maps.Keys(failed)) {
+		problems = append(problems, i18n.T("integrity.read_failed", path, failed[path]))
+	}
+
+	if m.baseline == nil {
+		m.baseline = &baseline{Files: current}
+		m.save()
+		log.Print(i18n.T("integrity.baseline_created", m.name, len(current)))
+		return m.result(problems, nil, nil, false)
+	}
+
+	old := m.baseline.Files
+	// 读取失败的文件保留原来的记录，不当作删除
+	for path := range failed {
+		if fi, ok := old[path]; ok {
+			current[path] = fi
+		}
+	}
+	var changes, diff []string
+	critical := false
+	for _, path := range slices.Sorted(maps.Keys(old)) {
+		if _, ok := current[path]; !ok {
+			changes = append(changes, i18n.T("integrity.removed", path))
+			critical = true
+		}
+	}
+	for _, path := range slices.Sorted(maps.Keys(current)) {
+		cur := current[path]
+		prev, ok := old[path]
+		if !ok {
+			changes = append(changes, i18n.T("integrity.added", path, cur.Mode, cur.UID, cur.GID))
+			critical = true
+			continue
+		}
+		details, contentChanged := describe(prev, cur)
+		if len(details) == 0 {
+			continue
+		}
+		changes = append(changes, i18n.T("integrity.modified", path, strings.Join(details, ", ")))
+		if contentChanged || prev.Mode != cur.Mode || prev.UID != cur.UID || prev.GID != cur.GID {
+			critical = true
+		}
+		if contentChanged && prev.Text && cur.Text {
+			if lines, ok := unifiedDiff(path, prev.Content, cur.Content); ok {
+				diff = append(diff, lines...)
+			} else {
+				diff = append(diff, i18n.T("integrity.diff_too_large", path))
+			}
+		}
+	}
+
+	if len(changes) > 0 {
+		m.baseline.Files = current
+		m.save()
+	}
+	return m.result(problems, changes, diff, critical)
+}
+
+// result 生成检查结果: 文件变化是一次性事件，读取失败是持续的警告
+func (m *IntegrityMonitor) result(problems, changes, diff []string, critical bool) monitor.Result {
+	labels := map[string]string{"type": "integrity", "service": m.name}
+	if len(changes) == 0 && len(problems) == 0 {
+		return monitor.Result{Success: true, Message: i18n.T("integrity.ok", m.name, len(m.baseline.Files)), Labels: labels}
+	}
+	severity := monitor.SeverityWarning
+	if critical {
+		severity = monitor.SeverityCritical
+	}
+	return monitor.Result{
+		Success:  false,
+		Message:  strings.Join(append(changes, problems...), "\n"),
+		Severity: severity,
+		Labels:   labels,
+		LogLines: diff,
+		Event:    len(changes) > 0,
+	}
+}
+
+// describe 列出两次记录之间的差异，第二个返回值表示内容是否改变
+func describe(prev, cur fileInfo) ([]string, bool) {
+	var details []string
+	contentChanged := prev.Hash != cur.Hash
+	if contentChanged {
+		details = append(details, i18n.T("integrity.change_content", shortHash(prev.Hash), shortHash(cur.Hash)))
+	}
+	if prev.Mode != cur.Mode {
+		details = append(details, i18n.T("integrity.change_mode", prev.Mode, cur.Mode))
+	}
+	if prev.UID != cur.UID || prev.GID != cur.GID {
+		details = append(details, i18n.T("integrity.change_owner", prev.UID, prev.GID, cur.UID, cur.GID))
+	}
+	if !prev.ModTime.Equal(cur.ModTime) {
+		details = append(details, i18n.T("integrity.change_mtime", prev.ModTime.Format(time.DateTime), cur.ModTime.Format(time.DateTime)))
+	}
+	return details, contentChanged
+}
+
+func shortHash(h string) string {
+	return h[:min(len(h), 12)]
+}
+
+// save 保存基线，失败时只记录日志
+func (m *IntegrityMonitor) save() {
+	if m.statePath == "" {
+		return
+	}
+	if err := state.Save(m.statePath, m.baseline); err != nil {
+		log.Print(i18n.T("integrity.state_save_failed", m.name, err))
+	}
+}
+
+// scan 读取所有配置的文件，返回当前记录和读取失败的文件
+func (m *IntegrityMonitor) scan() (map[string]fileInfo, map[string]error) {
+	files := make(map[string]fileInfo)
+	failed := make(map[string]error)
+	add := func(path string, fi fs.FileInfo) {
+		if m.excluded(path) {
+			return
+		}
+		info, err := m.read(path, fi)
+		if err != nil {
+			failed[path] = err
+			return
+		}
+		files[path] = info
+	}
+	for _, p := range m.expand() {
+		fi, err := os.Lstat(p)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				failed[p] = err
+			}
+			continue
+		}
+		if !fi.IsDir() {
+			add(p, fi)
+			continue
+		}
+		filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				failed[path] = err
+				return nil
+			}
+			if d.IsDir() {
+				if path != p && m.excluded(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			fi, err := d.Info()
+			if err != nil {
+				if !os.IsNotExist(err) {
+					failed[path] = err
+				}
+				return nil
+			}
+			add(path, fi)
+			return nil
+		})
+	}
+	return files, failed
+}
+
+// expand 展开 glob，非 glob 的路径原样返回，不存在的文件由调用方处理
+func (m *IntegrityMonitor) expand() []string {
+	var paths []string
+	for _, p := range m.cfg.Paths {
+		if !hasMeta(p) {
+			paths = append(paths, filepath.Clean(p))
+			continue
+		}
+		matches, _ := filepath.Glob(p)
+		paths = append(paths, matches...)
+	}
+	return paths
+}
+
+// excluded 判断完整路径或文件名是否匹配 exclude
+func (m *IntegrityMonitor) excluded(path string) bool {
+	return matchAny(m.cfg.Exclude, path)
+}
+
+// diffs 判断是否为 path 保存内容并生成差异
+func (m *IntegrityMonitor) diffs(path string) bool {
+	if m.cfg.Diff != nil && !*m.cfg.Diff {
+		return false
+	}
+	return !matchAny(m.cfg.DiffExclude, path)
+}
+
+// matchAny 判断完整路径或文件名是否匹配 patterns 中的任一项
+func matchAny(patterns []string, path string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// read 生成文件的记录。只处理普通文件和符号链接，符号链接不跟随
+func (m *IntegrityMonitor) read(path string, fi fs.FileInfo) (fileInfo, error) {
+	uid, gid := owner(fi)
+	info := fileInfo{Mode: fi.Mode(), UID: uid, GID: gid, Size: fi.Size(), ModTime: fi.ModTime()}
+	h := sha256.New()
+	switch {
+	case fi.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return info, err
+		}
+		io.WriteString(h, target)
+	case fi.Mode().IsRegular():
+		f, err := os.Open(path)
+		if err != nil {
+			return info, err
+		}
+		defer f.Close()
+		if uint64(fi.Size()) <= m.diffMax && m.diffs(path) {
+			data, err := io.ReadAll(f)
+			if err != nil {
+				return info, err
+			}
+			h.Write(data)
+			if !bytes.Contains(data, []byte{0}) && utf8.Valid(data) {
+				info.Text, info.Content = true, string(data)
+			}
+		} else if _, err := io.Copy(h, f); err != nil {
+			return info, err
+		}
+	}
+	info.Hash = hex.EncodeToString(h.Sum(nil))
+	return info, nil
+}
+
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
@@ -0,0 +1,117 @@
+package integrity
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "app.conf")
+	write(t, conf, "listen 80\n")
+	m, err := New(Config{Paths: []string{dir}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := m.Check(); !r.Success {
+		t.Fatalf("baseline: %s", r.Message)
+	}
+
+	write(t, conf, "listen 443\n")
+	r := m.Check()
+	if r.Success || !r.Event {
+		t.Fatalf("modification not reported: %+v", r)
+	}
+	if diff := strings.Join(r.LogLines, "\n"); !strings.Contains(diff, "-listen 80") || !strings.Contains(diff, "+listen 443") {
+		t.Errorf("diff = %q", diff)
+	}
+	// 变化只报告一次
+	if r := m.Check(); !r.Success {
+		t.Errorf("change reported twice: %s", r.Message)
+	}
+
+	added := filepath.Join(dir, "new.conf")
+	write(t, added, "x\n")
+	if r := m.Check(); r.Success || !strings.Contains(r.Message, added) {
+		t.Errorf("added file not reported: %s", r.Message)
+	}
+	os.Remove(added)
+	if r := m.Check(); r.Success || !strings.Contains(r.Message, added) {
+		t.Errorf("removed file not reported: %s", r.Message)
+	}
+}
+
+func TestNoDiff(t *testing.T) {
+	off := false
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"diff disabled", Config{Diff: &off}},
+		{"diff excluded", Config{DiffExclude: []string{"*.key"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			secret := filepath.Join(dir, "tls.key")
+			write(t, secret, "secret-1\n")
+			tt.cfg.Paths = []string{dir}
+			statePath := filepath.Join(t.TempDir(), "integrity.json")
+			m, err := New(tt.cfg, statePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.Check()
+
+			write(t, secret, "secret-2\n")
+			r := m.Check()
+			if r.Success {
+				t.Fatal("modification not reported")
+			}
+			// 内容不出现在告警和状态文件中
+			if len(r.LogLines) > 0 || strings.Contains(r.Message, "secret") {
+				t.Errorf("content leaked into the result: %q %q", r.Message, r.LogLines)
+			}
+			data, err := os.ReadFile(statePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), "secret") {
+				t.Errorf("content stored in the baseline: %s", data)
+			}
+		})
+	}
+}
+
+func TestStripContent(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "tls.key")
+	write(t, secret, "secret\n")
+	statePath := filepath.Join(t.TempDir(), "integrity.json")
+	m, err := New(Config{Paths: []string{dir}}, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Check()
+
+	// 之后关闭差异，已保存的内容在加载时删除
+	if _, err := New(Config{Paths: []string{dir}, DiffExclude: []string{"*.key"}}, statePath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("content kept in the baseline: %s", data)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package integrity
+
+import "io/fs"
+
+// owner 在非 Unix 系统上不可用，总是返回 0
+func owner(fi fs.FileInfo) (uint32, uint32) {
+	return 0, 0
+}
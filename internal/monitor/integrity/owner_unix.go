@@ -0,0 +1,16 @@
+//go:build unix
+
+package integrity
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// owner 返回文件的 uid 和 gid
+func owner(fi fs.FileInfo) (uint32, uint32) {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return st.Uid, st.Gid
+	}
+	return 0, 0
+}
@@ -0,0 +1,144 @@
+package integrity
+
+import (
+	"context"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// settleDelay 是收到文件事件后等待的时间，编辑器保存或包管理器升级通常会连续产生多个事件
+const settleDelay = time.Second
+
+// Stream 用 fsnotify 监听文件所在的目录，发生变化时立即比较基线，实现 monitor.Streamer。
+// 监听失败时只记录日志，文件变化仍会在定时的 Check 中发现。
+func (m *IntegrityMonitor) Stream(ctx context.Context, emit func(monitor.Result)) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Print(i18n.T("integrity.watch_failed", m.name, err))
+		return
+	}
+	defer watcher.Close()
+	for _, dir := range m.watchDirs() {
+		// 编辑器通常写入临时文件再改名，所以监听目录而不是文件本身
+		if err := watcher.Add(dir); err != nil {
+			log.Print(i18n.T("integrity.watch_failed", m.name, err))
+		}
+	}
+
+	timer := time.NewTimer(settleDelay)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			// 被监控目录中新建的子目录也需要监听
+			if ev.Has(fsnotify.Create) && m.underDir(ev.Name) {
+				if fi, err := os.Lstat(ev.Name); err == nil && fi.IsDir() {
+					m.watchTree(watcher, ev.Name)
+				}
+			}
+			if m.relevant(ev.Name) {
+				timer.Reset(settleDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Print(i18n.T("integrity.watch_failed", m.name, err))
+		case <-timer.C:
+			if r := m.compare(); r.Event {
+				emit(r)
+			}
+		}
+	}
+}
+
+// watchDirs 返回需要监听的目录: 文件和 glob 所在的目录，以及配置的目录及其子目录
+func (m *IntegrityMonitor) watchDirs() []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, p := range m.cfg.Paths {
+		p = filepath.Clean(p)
+		if hasMeta(p) {
+			// 只监听不含通配符的最深一级目录
+			dir := filepath.Dir(p)
+			for hasMeta(dir) {
+				dir = filepath.Dir(dir)
+			}
+			add(dir)
+			continue
+		}
+		if fi, err := os.Stat(p); err == nil && fi.IsDir() {
+			filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+				if err == nil && d.IsDir() {
+					add(path)
+				}
+				return nil
+			})
+			continue
+		}
+		add(filepath.Dir(p))
+	}
+	return dirs
+}
+
+// watchTree 监听 dir 及其所有子目录
+func (m *IntegrityMonitor) watchTree(watcher *fsnotify.Watcher, dir string) {
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			if err := watcher.Add(path); err != nil {
+				log.Print(i18n.T("integrity.watch_failed", m.name, err))
+			}
+		}
+		return nil
+	})
+}
+
+// relevant 判断事件中的路径是否属于被监控的文件
+func (m *IntegrityMonitor) relevant(path string) bool {
+	if m.excluded(path) {
+		return false
+	}
+	for _, p := range m.cfg.Paths {
+		p = filepath.Clean(p)
+		if path == p || isUnder(path, p) {
+			return true
+		}
+		if ok, _ := filepath.Match(p, path); ok && hasMeta(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// underDir 判断路径是否位于某个配置的目录之下
+func (m *IntegrityMonitor) underDir(path string) bool {
+	for _, p := range m.cfg.Paths {
+		if !hasMeta(p) && isUnder(path, filepath.Clean(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isUnder(path, dir string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
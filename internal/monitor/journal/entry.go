@@ -0,0 +1,86 @@
+package journal
+
+import (
+	"encoding/json"
+	"hashcowuwu/lychee/internal/monitor/rules"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JournalEntry 對應 journalctl -o json 輸出的單條日誌結構。
+// 常用的 __CURSOR、MESSAGE 和時間戳單獨保存，其餘字段保存在 Fields 中。
+type JournalEntry struct {
+	Cursor  string
+	Message string
+	// RealtimeTimestamp 是日誌寫入時間 (微秒)，用於閾值規則的滑動窗口
+	RealtimeTimestamp string
+	// Fields 保存除 "__" 開頭的內部字段和 MESSAGE 之外的全部字段，
+	// 例如 PRIORITY、SYSLOG_IDENTIFIER、_COMM、_PID、_BOOT_ID
+	Fields map[string]string
+}
+
+// UnmarshalJSON 解析一行 journalctl JSON 輸出。
+// journald 對非 UTF-8 或二進制內容輸出字節數組，對重複字段輸出字符串數組，這裡都轉換成字符串。
+func (e *JournalEntry) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	e.Fields = make(map[string]string, len(raw))
+	for k, v := range raw {
+		value := decodeValue(v)
+		switch {
+		case k == "__CURSOR":
+			e.Cursor = value
+		case k == "__REALTIME_TIMESTAMP":
+			e.RealtimeTimestamp = value
+		case k == "MESSAGE":
+			e.Message = value
+		case strings.HasPrefix(k, "__"):
+		default:
+			e.Fields[k] = value
+		}
+	}
+	return nil
+}
+
+// decodeValue 將 journald 的字段值轉換成字符串
+func decodeValue(v json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return s
+	}
+	var b []byte
+	var nums []int
+	if err := json.Unmarshal(v, &nums); err == nil {
+		b = make([]byte, len(nums))
+		for i, n := range nums {
+			b[i] = byte(n)
+		}
+		return string(b)
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(v, &list); err == nil {
+		values := make([]string, len(list))
+		for i, item := range list {
+			values[i] = decodeValue(item)
+		}
+		return strings.Join(values, ",")
+	}
+	return strings.TrimSpace(string(v))
+}
+
+// Time 返回日誌的寫入時間，無法解析時返回當前時間
+func (e JournalEntry) Time() time.Time {
+	us, err := strconv.ParseInt(e.RealtimeTimestamp, 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.UnixMicro(us)
+}
+
+// ruleEntry 轉換為規則引擎使用的 Entry
+func (e JournalEntry) ruleEntry() rules.Entry {
+	return rules.Entry{Message: e.Message, Fields: e.Fields, Time: e.Time()}
+}
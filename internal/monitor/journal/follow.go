@@ -0,0 +1,250 @@
+package journal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"hashcowuwu/lychee/internal/executor"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/monitor/rules"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultBufferSize 是串流模式下等待規則處理的日誌行數上限
+	DefaultBufferSize = 1024
+	// maxBatch 是一次告警最多合併的日誌行數，避免突發日誌產生過大的訊息
+	maxBatch = 200
+	// 重啟 journalctl 的退避時間
+	minRestartDelay = time.Second
+	maxRestartDelay = 30 * time.Second
+)
+
+// Follower 是串流模式的 JournalMonitor，只有它實現 monitor.Streamer，
+// 輪詢模式的監控器不會被當作串流監控器啟動。
+type Follower struct {
+	*JournalMonitor
+}
+
+// NewFollower 創建一個串流模式的 JournalMonitor。
+// 它常駐一個 `journalctl -f` 進程，日誌一到就經過規則處理並立即產生告警；
+// 進程退出後會從最後讀到的 cursor 重新啟動。bufferSize <= 0 時使用 DefaultBufferSize。
+func NewFollower(source Source, cfg rules.EngineConfig, exec executor.Executor, bufferSize int) (*Follower, error) {
+	m, err := New(source, cfg, exec)
+	if err != nil {
+		return nil, err
+	}
+	jm := m.(*JournalMonitor)
+	jm.follow = true
+	jm.bufferSize = bufferSize
+	if jm.bufferSize <= 0 {
+		jm.bufferSize = DefaultBufferSize
+	}
+	return &Follower{jm}, nil
+}
+
+// Stream 持續跟隨 journal 直到 ctx 結束，實現 monitor.Streamer
+func (f *Follower) Stream(ctx context.Context, emit func(monitor.Result)) {
+	jm := f.JournalMonitor
+	lines := make(chan []byte, jm.bufferSize)
+	go jm.process(ctx, lines, emit)
+
+	delay := minRestartDelay
+	for {
+		started := time.Now()
+		err := jm.followOnce(ctx, lines)
+		if ctx.Err() != nil {
+			close(lines)
+			return
+		}
+		jm.setFollowErr(err)
+		log.Print(i18n.T("journal.follow_exited", jm.source.String(), err, delay))
+		jm.waitDrained(ctx)
+
+		// 穩定運行過一段時間後重置退避時間
+		if time.Since(started) > maxRestartDelay {
+			delay = minRestartDelay
+		}
+		select {
+		case <-ctx.Done():
+			close(lines)
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRestartDelay)
+	}
+}
+
+// followOnce 啟動一個 journalctl -f 進程，把輸出逐行送入 lines，直到進程退出
+func (jm *JournalMonitor) followOnce(ctx context.Context, lines chan<- []byte) error {
+	args := append(jm.source.args(), "-f", "-o", "json", "--no-pager")
+	if cursor := jm.getCursor(); cursor != "" {
+		args = append(args, "--after-cursor", cursor)
+	} else {
+		// 沒有 cursor 時只跟隨新日誌，不回放歷史
+		args = append(args, "-n", "0")
+	}
+
+	proc, err := jm.exec.Start(ctx, "journalctl", args...)
+	if err != nil {
+		return err
+	}
+	jm.setFollowErr(nil)
+
+	scanner := bufio.NewScanner(proc)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lastWarn time.Time
+	for scanner.Scan() {
+		line := append([]byte(nil), scanner.Bytes()...)
+		jm.addPending()
+		select {
+		case lines <- line:
+		default:
+			// 緩衝區已滿: 阻塞等待規則處理，journalctl 會隨之暫停輸出，不會丟失日誌
+			if time.Since(lastWarn) > time.Minute {
+				log.Print(i18n.T("journal.follow_backpressure", jm.source.String(), cap(lines)))
+				lastWarn = time.Now()
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				jm.donePending()
+				proc.Wait()
+				return ctx.Err()
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		proc.Wait()
+		return err
+	}
+	if err := proc.Wait(); err != nil {
+		return err
+	}
+	return i18n.Errorf("journal.follow_eof")
+}
+
+// process 從 lines 讀取日誌並執行規則。
+// 每次命中後會把緩衝區中已到達的日誌一起處理，合併成一條結果再發出。
+// 配置了多行合併時，還會定期處理等待續行超時的記錄。
+func (jm *JournalMonitor) process(ctx context.Context, lines <-chan []byte, emit func(monitor.Result)) {
+	var flush <-chan time.Time
+	if interval := jm.engine.FlushInterval(); interval > 0 {
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		flush = ticker.C
+	}
+	for {
+		var matched batch
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			jm.handleLine(line, &matched)
+		case now := <-flush:
+			matched.add(jm, jm.engine.Flush(now, false))
+		}
+	drain:
+		for len(matched.messages) > 0 && len(matched.messages) < maxBatch {
+			select {
+			case next, ok := <-lines:
+				if !ok {
+					break drain
+				}
+				jm.handleLine(next, &matched)
+			default:
+				break drain
+			}
+		}
+		if len(matched.messages) > 0 && ctx.Err() == nil {
+			emit(jm.result(matched))
+		}
+	}
+}
+
+// waitDrained 等待已送入緩衝區的日誌全部處理完畢 (包括正在處理的一行)，
+// 使重啟時使用的 cursor 是最新的，避免重複告警
+func (jm *JournalMonitor) waitDrained(ctx context.Context) {
+	jm.mu.Lock()
+	drained := jm.drained
+	jm.mu.Unlock()
+	if drained == nil {
+		return
+	}
+	select {
+	case <-drained:
+	case <-ctx.Done():
+	}
+}
+
+// addPending 記錄一行送入緩衝區的日誌
+func (jm *JournalMonitor) addPending() {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	if jm.pending == 0 {
+		jm.drained = make(chan struct{})
+	}
+	jm.pending++
+}
+
+// donePending 記錄一行日誌處理完畢，全部處理完時喚醒 waitDrained
+func (jm *JournalMonitor) donePending() {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	jm.pending--
+	if jm.pending == 0 {
+		close(jm.drained)
+		jm.drained = nil
+	}
+}
+
+// handleLine 解析一行 JSON 日誌，更新 cursor 並執行規則
+func (jm *JournalMonitor) handleLine(line []byte, b *batch) {
+	defer jm.donePending()
+	var entry JournalEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		// 忽略無法解析的行
+		return
+	}
+	if entry.Cursor != "" {
+		jm.setCursor(entry.Cursor)
+	}
+	jm.scan(entry, b)
+}
+
+// health 返回串流模式下監控器自身的狀態
+func (jm *JournalMonitor) health() monitor.Result {
+	jm.mu.Lock()
+	err := jm.followErr
+	jm.mu.Unlock()
+	if err != nil {
+		return monitor.Result{
+			Success: false,
+			Message: i18n.T("journal.follow_down", jm.source.String(), err),
+			Err:     err,
+			Labels:  jm.labels(),
+		}
+	}
+	return monitor.Result{Success: true, Message: i18n.T("journal.follow_ok", jm.source.String()), Labels: jm.labels()}
+}
+
+func (jm *JournalMonitor) getCursor() string {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	return jm.cursor
+}
+
+func (jm *JournalMonitor) setCursor(cursor string) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	jm.cursor = cursor
+}
+
+func (jm *JournalMonitor) setFollowErr(err error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	jm.followErr = err
+}
@@ -2,50 +2,67 @@ package journal
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
+	"hashcowuwu/lychee/internal/executor"
+	"hashcowuwu/lychee/internal/i18n"
 	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/monitor/rules"
 	"log"
-	"os/exec"
-	"regexp" // 導入 regexp 包
 	"strings"
+	"sync"
+	"time"
 )
 
-// JournalEntry 對應 journalctl -o json 輸出的單條日誌結構。
-// 我們只需要 __CURSOR 和 MESSAGE 兩個字段。
-type JournalEntry struct {
-	Cursor  string `json:"__CURSOR"`
-	Message string `json:"MESSAGE"`
-}
-
 // JournalMonitor 從 systemd journal 中讀取特定服務的日志
 // 它通過執行 journalctl 命令並管理 cursor 來實現，完全不依賴 CGO。
 type JournalMonitor struct {
-	serviceName string
-	keywords    []string
+	source Source
+	// exec 用於執行 journalctl，測試時可以替換
+	exec executor.Executor
+	// engine 包含在 New 中預先編譯好的匹配規則和多行合併、正文解析等預處理
+	engine *rules.Engine
 	// cursor 用於記錄上次讀取到的日誌位置，以便下次只讀取新的日誌。
 	cursor string
+
+	// 以下字段只在串流模式 (NewFollower) 下使用
+	follow     bool
+	bufferSize int
+	mu         sync.Mutex    // 保護 cursor、followErr、pending 和 drained
+	followErr  error         // journalctl 最近一次異常退出的原因，正常運行時為 nil
+	pending    int           // 已送入緩衝區但還沒處理完 (含更新 cursor) 的日誌行數
+	drained    chan struct{} // pending 歸零時關閉，沒有待處理的日誌時為 nil
 }
 
-// New 創建一個新的 JournalMonitor 實例
-func New(serviceName string, keywords []string) (monitor.Monitor, error) {
+// New 創建一個新的 JournalMonitor 實例，journalctl 通過 exec 執行。來源或規則無效時返回錯誤
+func New(source Source, cfg rules.EngineConfig, exec executor.Executor) (monitor.Monitor, error) {
+	if err := source.Validate(); err != nil {
+		return nil, err
+	}
+	engine, err := rules.NewEngine(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// 創建一個基礎的 monitor 實例
 	jm := &JournalMonitor{
-		serviceName: serviceName,
-		keywords:    keywords,
+		source: source,
+		exec:   exec,
+		engine: engine,
 	}
 
 	// 初始化 cursor，將其設置為當前服務最新一條日誌的位置。
 	// 這相當於原代碼中的 j.SeekTail() + j.Next()。
 	// 我們只獲取最新的一條 (-n 1) 來拿到它的 cursor。
-	cmd := exec.Command("journalctl", "-u", serviceName, "-n", "1", "-o", "json", "--no-pager")
-	output, err := cmd.Output()
+	args := append(source.args(), "-n", "1", "-o", "json", "--no-pager")
+	output, err := exec.Output(context.Background(), "journalctl", args...)
 	if err != nil {
 		// 如果命令執行失敗（例如服務不存在或還沒有任何日誌），我們不將其視為致命錯誤。
 		// cursor 將為空，第一次 Check() 會從頭讀取（或讀取最近的日誌）。
 		// 這裡可以根據您的需求決定是否返回錯誤。
 		// 作為監控，允許服務初期沒有日誌是合理的。
-		log.Printf("注意: 為服務 [%s] 初始化 cursor 失敗 (可能是新服務無日誌): %v", serviceName, err)
+		log.Print(i18n.T("journal.cursor_init_failed", source, err))
 	} else {
 		// 從輸出中解析最後一條日誌的 cursor
 		scanner := bufio.NewScanner(strings.NewReader(string(output)))
@@ -62,33 +79,80 @@ func New(serviceName string, keywords []string) (monitor.Monitor, error) {
 
 // Name 返回監控器的名稱
 func (jm *JournalMonitor) Name() string {
-	return fmt.Sprintf("journal-%s", jm.serviceName)
+	return fmt.Sprintf("journal-%s", jm.source)
+}
+
+// batch 收集一批日誌中命中規則的結果
+type batch struct {
+	messages []string
+	// 命中關鍵字的原始日誌行及其上下文，供通知模板使用
+	lines []string
+	// 最近一條命中日誌的字段
+	fields map[string]string
+	// 需要封禁的來源地址
+	offenders []string
+}
+
+// scan 把日誌交給規則引擎，命中時記錄到 b 中
+func (jm *JournalMonitor) scan(entry JournalEntry, b *batch) {
+	b.add(jm, jm.engine.Process(entry.ruleEntry()))
+}
+
+// add 記錄規則引擎返回的命中
+func (b *batch) add(jm *JournalMonitor, matches []rules.Match) {
+	for _, m := range matches {
+		msg := jm.describe(m.Hit, m.Entry.Message)
+		// 配置了上下文時，在訊息後附上命中前後的日誌
+		if context := m.FormatContext(); context != "" {
+			msg += "\n" + context
+		}
+		b.messages = append(b.messages, msg)
+		b.lines = append(b.lines, m.Lines()...)
+		b.fields = m.Entry.Fields
+		if m.Hit.Ban {
+			b.offenders = append(b.offenders, m.Hit.Key)
+		}
+	}
+}
+
+// result 將一批命中轉換為監控結果，沒有命中時返回成功
+func (jm *JournalMonitor) result(b batch) monitor.Result {
+	if len(b.messages) > 0 {
+		return monitor.Result{
+			Success:   false, // 發現關鍵字通常表示非成功狀態
+			Message:   strings.Join(b.messages, "\n"),
+			Labels:    jm.labels(),
+			LogLines:  b.lines,
+			Fields:    b.fields,
+			Event:     true,
+			Offenders: b.offenders,
+		}
+	}
+	return monitor.Result{Success: true, Labels: jm.labels()}
 }
 
 // Check 從上次的位置開始，檢查新的日誌條目
 func (jm *JournalMonitor) Check() monitor.Result {
-	var matchedMessages []string
+	if jm.follow {
+		return jm.health()
+	}
+
+	var matched batch
 
 	// 準備 journalctl 命令的參數
-	args := []string{"-u", jm.serviceName, "-o", "json", "--no-pager"}
+	args := append(jm.source.args(), "-o", "json", "--no-pager")
 	if jm.cursor != "" {
 		// 如果我們有 cursor，就只讀取它之後的日誌
 		args = append(args, "--after-cursor", jm.cursor)
 	}
 
-	cmd := exec.Command("journalctl", args...)
-
-	stdout, err := cmd.StdoutPipe()
+	proc, err := jm.exec.Start(context.Background(), "journalctl", args...)
 	if err != nil {
-		return monitor.Result{Success: false, Message: fmt.Sprintf("服務 [%s] 無法創建命令管道: %v", jm.serviceName, err)}
-	}
-
-	if err := cmd.Start(); err != nil {
-		return monitor.Result{Success: false, Message: fmt.Sprintf("服務 [%s] 無法啟動 journalctl: %v", jm.serviceName, err)}
+		return monitor.Result{Success: false, Message: i18n.T("journal.start_failed", jm.source.String(), err), Labels: jm.labels()}
 	}
 
 	// 逐行讀取新日誌
-	scanner := bufio.NewScanner(stdout)
+	scanner := bufio.NewScanner(proc)
 	var lastReadCursor string
 	for scanner.Scan() {
 		line := scanner.Bytes()
@@ -102,37 +166,22 @@ func (jm *JournalMonitor) Check() monitor.Result {
 		// 更新我們在此次檢查中讀到的最後一個 cursor
 		lastReadCursor = entry.Cursor
 
-		// 檢查關鍵字 - 使用正則表達式
-		for _, keyword := range jm.keywords {
-			// 编译正则表达式。(?i) 表示不區分大小寫匹配。
-			// 如果您的關鍵字可能包含正則表達式特殊字符，但您希望它們被字面匹配，
-			// 則應使用 regexp.QuoteMeta(keyword) 進行轉義。
-			// 例如：re, err := regexp.Compile("(?i)" + regexp.QuoteMeta(keyword))
-			re, err := regexp.Compile("(?i)" + keyword)
-			if err != nil {
-				log.Printf("警告: 服務 [%s] 關鍵字 '%s' 正則表達式編譯失敗: %v", jm.serviceName, keyword, err)
-				continue // 跳過當前無效的關鍵字，繼續處理下一個
-			}
-
-			if re.MatchString(entry.Message) {
-				msg := fmt.Sprintf("服務 [%s] journal 日誌發現關鍵字 '%s': %s", jm.serviceName, keyword, entry.Message)
-				matchedMessages = append(matchedMessages, msg)
-				break // 找到一個關鍵字就足夠了，處理下一條日誌
-			}
-		}
+		jm.scan(entry, &matched)
 	}
+	// 等待續行已超時的多行記錄在本輪處理，其餘的留到下一輪
+	matched.add(jm, jm.engine.Flush(time.Now(), false))
 
 	// 等待命令結束
-	if err := cmd.Wait(); err != nil {
+	if err := proc.Wait(); err != nil {
 		// journalctl 在沒有新日誌時可能會以非 0 狀態碼退出，這裡可以更寬容地處理
 		// 但如果管道讀取正常，通常可以忽略 wait 的錯誤
 		// 只有當 err 不是 ExitError 且不是 0 狀態碼時才記錄為錯誤
-		if exitErr, ok := err.(*exec.ExitError); ok {
+		if code, ok := executor.ExitCode(err); ok {
 			// 如果是 journalctl 正常退出但沒有新日誌，其退出碼可能為非零
 			// 這裡可以根據需要調整錯誤處理邏輯
-			log.Printf("注意: 服務 [%s] journalctl 命令退出，狀態碼: %d", jm.serviceName, exitErr.ExitCode())
+			log.Print(i18n.T("journal.exit_code", jm.source.String(), code))
 		} else {
-			log.Printf("錯誤: 服務 [%s] journalctl 命令等待失敗: %v", jm.serviceName, err)
+			log.Print(i18n.T("journal.wait_failed", jm.source.String(), err))
 		}
 	}
 
@@ -141,12 +190,22 @@ func (jm *JournalMonitor) Check() monitor.Result {
 		jm.cursor = lastReadCursor
 	}
 
-	if len(matchedMessages) > 0 {
-		return monitor.Result{
-			Success: false, // 發現關鍵字通常表示非成功狀態
-			Message: strings.Join(matchedMessages, "\n"),
-		}
+	return jm.result(matched)
+}
+
+// describe 生成命中規則時的告警訊息
+func (jm *JournalMonitor) describe(hit rules.Hit, message string) string {
+	if !hit.Threshold() {
+		return i18n.T("journal.keyword_matched", jm.source.String(), hit.Rule, message)
 	}
+	rule := hit.Rule
+	if hit.Key != "" {
+		rule = fmt.Sprintf("%s[%s]", hit.Rule, hit.Key)
+	}
+	return i18n.T("journal.threshold_matched", jm.source.String(), rule, hit.Count, hit.Window, hit.Previous, message)
+}
 
-	return monitor.Result{Success: true}
+// labels 返回附加在結果上的標籤
+func (jm *JournalMonitor) labels() map[string]string {
+	return map[string]string{"type": "journal", "service": jm.source.String()}
 }
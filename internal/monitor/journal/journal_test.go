@@ -0,0 +1,308 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"hashcowuwu/lychee/internal/executor/executortest"
+	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/monitor/rules"
+	"slices"
+	"testing"
+	"time"
+)
+
+const (
+	tailCmd = "journalctl -u app.service -n 1 -o json --no-pager"
+	readCmd = "journalctl -u app.service -o json --no-pager"
+)
+
+// entry 生成一行 journalctl -o json 輸出
+func entry(cursor, message string) string {
+	return fmt.Sprintf(`{"__CURSOR":%q,"__REALTIME_TIMESTAMP":"1704078000000000","MESSAGE":%q,"PRIORITY":"3"}`+"\n", cursor, message)
+}
+
+func newMonitor(t *testing.T, fake *executortest.Fake) *JournalMonitor {
+	t.Helper()
+	m, err := New(Source{Unit: "app.service"}, rules.EngineConfig{Rules: []rules.Config{{Pattern: "ERROR"}}}, fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m.(*JournalMonitor)
+}
+
+func TestCheck(t *testing.T) {
+	tail := executortest.Response{Stdout: entry("c1", "started")}
+	tests := []struct {
+		name       string
+		tail       executortest.Response // New 讀取最新一條日誌的結果
+		read       executortest.Response // Check 讀取新日誌的結果
+		wantCall   string
+		wantCursor string
+		success    bool
+	}{
+		{
+			name:       "resume after tail cursor",
+			tail:       tail,
+			read:       executortest.Response{Stdout: entry("c2", "hello")},
+			wantCall:   readCmd + " --after-cursor c1",
+			wantCursor: "c2",
+			success:    true,
+		},
+		{
+			name:       "tail failed",
+			tail:       executortest.Response{ExitCode: 1},
+			read:       executortest.Response{Stdout: entry("c2", "hello")},
+			wantCall:   readCmd,
+			wantCursor: "c2",
+			success:    true,
+		},
+		{
+			name:       "tail malformed",
+			tail:       executortest.Response{Stdout: "-- No entries --\n"},
+			read:       executortest.Response{Stdout: entry("c2", "hello")},
+			wantCall:   readCmd,
+			wantCursor: "c2",
+			success:    true,
+		},
+		{
+			name:       "no new entries",
+			tail:       tail,
+			read:       executortest.Response{},
+			wantCall:   readCmd + " --after-cursor c1",
+			wantCursor: "c1",
+			success:    true,
+		},
+		{
+			name:       "keyword matched",
+			tail:       tail,
+			read:       executortest.Response{Stdout: entry("c2", "ERROR disk full") + entry("c3", "recovered")},
+			wantCall:   readCmd + " --after-cursor c1",
+			wantCursor: "c3",
+			success:    false,
+		},
+		{
+			name:       "malformed lines skipped",
+			tail:       tail,
+			read:       executortest.Response{Stdout: "not json\n" + entry("c2", "ERROR disk full") + `{"__CURSOR": "c3", "MESSAGE"` + "\n"},
+			wantCall:   readCmd + " --after-cursor c1",
+			wantCursor: "c2",
+			success:    false,
+		},
+		{
+			name:       "only malformed lines",
+			tail:       tail,
+			read:       executortest.Response{Stdout: "garbage\n[1, 2]\n\n"},
+			wantCall:   readCmd + " --after-cursor c1",
+			wantCursor: "c1",
+			success:    true,
+		},
+		{
+			name:       "binary message",
+			tail:       tail,
+			read:       executortest.Response{Stdout: `{"__CURSOR":"c2","MESSAGE":[69,82,82,79,82,0]}` + "\n"},
+			wantCall:   readCmd + " --after-cursor c1",
+			wantCursor: "c2",
+			success:    false,
+		},
+		{
+			name:       "non-zero exit",
+			tail:       tail,
+			read:       executortest.Response{Stdout: entry("c2", "ERROR disk full"), ExitCode: 1},
+			wantCall:   readCmd + " --after-cursor c1",
+			wantCursor: "c2",
+			success:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := executortest.New().On(tailCmd, tt.tail).On(tt.wantCall, tt.read)
+			m := newMonitor(t, fake)
+			r := m.Check()
+			if r.Success != tt.success {
+				t.Errorf("Success = %v, want %v (%s)", r.Success, tt.success, r.Message)
+			}
+			if m.cursor != tt.wantCursor {
+				t.Errorf("cursor = %q, want %q", m.cursor, tt.wantCursor)
+			}
+			if want := []string{tailCmd, tt.wantCall}; !slices.Equal(fake.Calls(), want) {
+				t.Errorf("calls = %q, want %q", fake.Calls(), want)
+			}
+		})
+	}
+}
+
+func TestCheckAdvancesCursor(t *testing.T) {
+	fake := executortest.New().
+		On(tailCmd, executortest.Response{Stdout: entry("c1", "started")}).
+		On(readCmd+" --after-cursor c1", executortest.Response{Stdout: entry("c2", "a") + entry("c3", "b")}).
+		On(readCmd+" --after-cursor c3", executortest.Response{Stdout: entry("c4", "ERROR")})
+	m := newMonitor(t, fake)
+	if r := m.Check(); !r.Success {
+		t.Fatalf("first check: %s", r.Message)
+	}
+	r := m.Check()
+	if r.Success || len(r.LogLines) == 0 {
+		t.Errorf("second check: Success = %v, LogLines = %q", r.Success, r.LogLines)
+	}
+	if m.cursor != "c4" {
+		t.Errorf("cursor = %q, want c4", m.cursor)
+	}
+}
+
+func TestCheckStartFailed(t *testing.T) {
+	// 沒有錄製讀取命令，Start 返回錯誤
+	fake := executortest.New().On(tailCmd, executortest.Response{Stdout: entry("c1", "started")})
+	m := newMonitor(t, fake)
+	if r := m.Check(); r.Success {
+		t.Error("Success = true, want false")
+	}
+	if m.cursor != "c1" {
+		t.Errorf("cursor = %q, want c1", m.cursor)
+	}
+}
+
+func TestStreamer(t *testing.T) {
+	fake := executortest.New().On(tailCmd, executortest.Response{})
+	cfg := rules.EngineConfig{Rules: []rules.Config{{Pattern: "ERROR"}}}
+	// 輪詢模式不能被當作串流監控器啟動，否則會同時輪詢和跟隨
+	m, err := New(Source{Unit: "app.service"}, cfg, fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.(monitor.Streamer); ok {
+		t.Error("polling monitor implements monitor.Streamer")
+	}
+	f, err := NewFollower(Source{Unit: "app.service"}, cfg, fake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ monitor.Streamer = f
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source Source
+		args   []string
+		str    string
+	}{
+		{"all", Source{}, nil, "all"},
+		{"unit", Source{Unit: "nginx.service"}, []string{"-u", "nginx.service"}, "nginx.service"},
+		{"user unit", Source{UserUnit: "sync.service"}, []string{"--user-unit", "sync.service"}, "user:sync.service"},
+		{"kernel", Source{Kernel: true, Priority: "warning"}, []string{"-k", "-p", "4"}, "kernel"},
+		{"identifier", Source{Identifier: "sshd", Priority: "3"}, []string{"-t", "sshd", "-p", "3"}, "id:sshd"},
+		{"combined", Source{Unit: "app.service", Identifier: "app", Priority: "err"}, []string{"-u", "app.service", "-t", "app", "-p", "3"}, "app.service+id:app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.source.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.source.args(); !slices.Equal(got, tt.args) {
+				t.Errorf("args() = %q, want %q", got, tt.args)
+			}
+			if got := tt.source.String(); got != tt.str {
+				t.Errorf("String() = %q, want %q", got, tt.str)
+			}
+		})
+	}
+	if err := (Source{Priority: "loud"}).Validate(); err == nil {
+		t.Error("invalid priority accepted")
+	}
+}
+
+func TestCheckPriorityFilter(t *testing.T) {
+	// journalctl 已按 -p 過濾；規則上的 priority 和 fields 再對每條日誌過濾
+	source := Source{Unit: "app.service", Priority: "warning"}
+	tail := "journalctl -u app.service -p 4 -n 1 -o json --no-pager"
+	read := "journalctl -u app.service -p 4 -o json --no-pager --after-cursor c1"
+	line := func(cursor, message, priority, comm string) string {
+		return fmt.Sprintf(`{"__CURSOR":%q,"MESSAGE":%q,"PRIORITY":%q,"_COMM":%q}`+"\n", cursor, message, priority, comm)
+	}
+	fake := executortest.New().
+		On(tail, executortest.Response{Stdout: entry("c1", "started")}).
+		On(read, executortest.Response{Stdout: line("c2", "disk slow", "4", "app") + line("c3", "disk failed", "2", "helper") + line("c4", "disk failed", "3", "app")})
+	cfg := rules.EngineConfig{Rules: []rules.Config{{Pattern: "disk", Priority: "err", Fields: map[string]string{"_comm": "app"}}}}
+	m, err := New(source, cfg, fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := m.Check()
+	if r.Success || !slices.Equal(r.LogLines, []string{"disk failed"}) {
+		t.Errorf("got Success = %v, LogLines = %q, want only the app error", r.Success, r.LogLines)
+	}
+}
+
+func TestFollowerStream(t *testing.T) {
+	follow := "journalctl -u app.service -f -o json --no-pager"
+	fake := executortest.New().
+		On(tailCmd, executortest.Response{ExitCode: 1}).
+		// 沒有 cursor 時只跟隨新日誌
+		On(follow+" -n 0", executortest.Response{Stdout: entry("c2", "ERROR first") + entry("c3", "ok")}).
+		// journalctl 退出後從最後讀到的 cursor 重新啟動
+		On(follow+" --after-cursor c3", executortest.Response{Stdout: entry("c4", "ERROR second"), ExitCode: 1})
+	f, err := NewFollower(Source{Unit: "app.service"}, rules.EngineConfig{Rules: []rules.Config{{Pattern: "ERROR"}}}, fake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan monitor.Result, 10)
+	done := make(chan struct{})
+	go func() {
+		f.Stream(ctx, func(r monitor.Result) { results <- r })
+		close(done)
+	}()
+	for _, want := range []string{"ERROR first", "ERROR second"} {
+		select {
+		case r := <-results:
+			if r.Success || !slices.Equal(r.LogLines, []string{want}) {
+				t.Errorf("got Success = %v, LogLines = %q, want %q", r.Success, r.LogLines, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no result for %q, calls = %q", want, fake.Calls())
+		}
+	}
+	// 異常退出的原因反映在監控器自身的狀態中
+	for deadline := time.Now().Add(5 * time.Second); f.health().Success; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("exit error not reported in health")
+		}
+	}
+	cancel()
+	<-done
+	if f.getCursor() != "c4" {
+		t.Errorf("cursor = %q, want c4", f.getCursor())
+	}
+}
+
+func TestWaitDrained(t *testing.T) {
+	m := newMonitor(t, executortest.New())
+	// 日誌行已離開緩衝區，但消費者還沒處理完，cursor 尚未更新
+	m.addPending()
+	drained := make(chan struct{})
+	go func() {
+		m.waitDrained(context.Background())
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		t.Fatal("waitDrained returned before the line was handled")
+	case <-time.After(50 * time.Millisecond):
+	}
+	m.handleLine([]byte(entry("c5", "ok")), &batch{})
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("waitDrained did not return after the line was handled")
+	}
+	if m.getCursor() != "c5" {
+		t.Errorf("cursor = %q, want c5", m.getCursor())
+	}
+
+	// 停止時不再等待還沒處理的日誌
+	m.addPending()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m.waitDrained(ctx)
+}
@@ -0,0 +1,70 @@
+package journal
+
+import (
+	"hashcowuwu/lychee/internal/monitor/rules"
+	"strconv"
+	"strings"
+)
+
+// Source 描述要讀取的 journal 範圍，對應 journalctl 的過濾參數。
+// 多個條件同時設置時由 journalctl 取交集。
+type Source struct {
+	Unit       string // 系統服務 (-u)
+	UserUnit   string // 使用者服務 (--user-unit)
+	Kernel     bool   // 僅核心訊息 (-k)
+	Identifier string // SYSLOG_IDENTIFIER (-t)
+	Priority   string // 只讀取該優先級及更嚴重的日誌 (-p)，例如 err
+}
+
+// Validate 檢查 Source 中的優先級是否有效
+func (s Source) Validate() error {
+	if s.Priority == "" {
+		return nil
+	}
+	_, err := rules.ParsePriority(s.Priority)
+	return err
+}
+
+// args 返回對應的 journalctl 參數
+func (s Source) args() []string {
+	var args []string
+	if s.Unit != "" {
+		args = append(args, "-u", s.Unit)
+	}
+	if s.UserUnit != "" {
+		args = append(args, "--user-unit", s.UserUnit)
+	}
+	if s.Kernel {
+		args = append(args, "-k")
+	}
+	if s.Identifier != "" {
+		args = append(args, "-t", s.Identifier)
+	}
+	if s.Priority != "" {
+		// Validate 已保證可以解析
+		p, _ := rules.ParsePriority(s.Priority)
+		args = append(args, "-p", strconv.Itoa(p))
+	}
+	return args
+}
+
+// String 返回用於監控器名稱和日誌的簡短描述
+func (s Source) String() string {
+	var parts []string
+	if s.Unit != "" {
+		parts = append(parts, s.Unit)
+	}
+	if s.UserUnit != "" {
+		parts = append(parts, "user:"+s.UserUnit)
+	}
+	if s.Kernel {
+		parts = append(parts, "kernel")
+	}
+	if s.Identifier != "" {
+		parts = append(parts, "id:"+s.Identifier)
+	}
+	if len(parts) == 0 {
+		return "all"
+	}
+	return strings.Join(parts, "+")
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package logfile
+
+import "os"
+
+// inode 在不支持 inode 的平台上返回 0，此时只能依靠指纹识别轮转
+func inode(fi os.FileInfo) uint64 {
+	return 0
+}
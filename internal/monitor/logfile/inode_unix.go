@@ -0,0 +1,16 @@
+//go:build unix
+
+package logfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// inode 返回文件的 inode 号，用于识别 rename 方式的日志轮转
+func inode(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
@@ -0,0 +1,492 @@
+// Package logfile 实现普通日志文件的跟踪监控，与 journal 监控共用同一套规则引擎。
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/monitor/rules"
+	"hashcowuwu/lychee/internal/state"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fingerprintSize 是用于识别文件的开头字节数。
+// 文件被 copytruncate 清空或被 gzip 压缩后，靠它判断是否还是同一份内容。
+const fingerprintSize = 1024
+
+// fileState 是单个文件的读取进度，会被持久化到状态文件
+type fileState struct {
+	Inode          uint64 `json:"inode"`
+	Offset         int64  `json:"offset"`
+	Fingerprint    string `json:"fingerprint"`    // 文件开头 FingerprintLen 字节的 sha256
+	FingerprintLen int64  `json:"fingerprintLen"` // 计算指纹时的字节数，最多 fingerprintSize
+}
+
+// persisted 是状态文件的内容
+type persisted struct {
+	Files map[string]*fileState `json:"files"`
+}
+
+// FileMonitor 跟踪匹配 glob 的日志文件，按 inode 和偏移量记录读取进度，
+// 支持 rename 和 copytruncate 两种轮转方式，并在重启后从已压缩的轮转文件中补读。
+type FileMonitor struct {
+	name      string
+	patterns  []string
+	cfg       rules.EngineConfig
+	engines   map[string]*rules.Engine // 每个文件单独的规则引擎，多行合并、上下文和阈值窗口不会混入其他文件的日志，阈值也不会跨文件累计
+	statePath string
+	files     map[string]*fileState
+}
+
+// ValidatePatterns 检查 glob 模式的语法
+func ValidatePatterns(patterns []string) error {
+	if len(patterns) == 0 {
+		return i18n.Errorf("logfile.no_paths")
+	}
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return i18n.Errorf("logfile.bad_pattern", p, err)
+		}
+	}
+	return nil
+}
+
+// New 创建一个新的 FileMonitor。
+// statePath 中已有记录的文件从上次的偏移量继续读取；首次看到的现有文件从末尾开始，
+// 之后新出现的文件从头读取。
+func New(name string, patterns []string, cfg rules.EngineConfig, statePath string) (*FileMonitor, error) {
+	if err := ValidatePatterns(patterns); err != nil {
+		return nil, err
+	}
+	if _, err := rules.NewEngine(cfg); err != nil {
+		return nil, err
+	}
+
+	m := &FileMonitor{
+		name:      name,
+		patterns:  patterns,
+		cfg:       cfg,
+		engines:   make(map[string]*rules.Engine),
+		statePath: statePath,
+		files:     make(map[string]*fileState),
+	}
+	var saved persisted
+	if err := state.Load(statePath, &saved); err != nil {
+		log.Print(i18n.T("logfile.state_load_failed", name, statePath, err))
+	}
+	if saved.Files != nil {
+		m.files = saved.Files
+	}
+
+	for _, path := range m.expand() {
+		if _, ok := m.files[path]; ok {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		st := &fileState{Inode: inode(fi), Offset: fi.Size()}
+		updateFingerprint(path, st)
+		m.files[path] = st
+	}
+	m.save()
+	return m, nil
+}
+
+// Name 返回监控器的名称
+func (m *FileMonitor) Name() string {
+	return fmt.Sprintf("logfile-%s", m.name)
+}
+
+// batch 收集一轮读取中命中规则的结果
+type batch struct {
+	messages  []string
+	lines     []string
+	fields    map[string]string
+	offenders []string
+}
+
+func (m *FileMonitor) add(b *batch, matches []rules.Match) {
+	for _, match := range matches {
+		msg := m.describe(match)
+		if context := match.FormatContext(); context != "" {
+			msg += "\n" + context
+		}
+		b.messages = append(b.messages, msg)
+		b.lines = append(b.lines, match.Lines()...)
+		b.fields = match.Entry.Fields
+		if match.Hit.Ban {
+			b.offenders = append(b.offenders, match.Hit.Key)
+		}
+	}
+}
+
+// Check 读取所有匹配文件中新增的行并执行规则
+func (m *FileMonitor) Check() monitor.Result {
+	var matched batch
+	current := make(map[string]bool)
+	paths := m.expand()
+	adopted := m.adopt(paths)
+
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		current[path] = true
+		ino := inode(fi)
+
+		st, ok := m.files[path]
+		switch {
+		case !ok:
+			// 启动后新出现的文件，从头读取
+			st = &fileState{Inode: ino}
+			m.files[path] = st
+		case st.Inode != ino:
+			// rename 轮转: 原文件已被改名，先从轮转后的文件读完剩余内容
+			if !adopted[path] {
+				m.catchUp(path, st, &matched)
+			}
+			st = &fileState{Inode: ino}
+			m.files[path] = st
+		case fi.Size() < st.Offset || !sameHead(path, st):
+			// copytruncate 轮转或文件被覆盖: 先从复制出的文件读完剩余内容，再从头读取
+			log.Print(i18n.T("logfile.truncated", m.name, path))
+			if !adopted[path] {
+				m.catchUp(path, st, &matched)
+			}
+			*st = fileState{Inode: ino}
+		}
+
+		if err := m.read(path, st, &matched); err != nil {
+			log.Print(i18n.T("logfile.read_failed", m.name, path, err))
+		}
+	}
+
+	// 不再匹配的文件 (被删除或改名后没有新文件)，补读后不再跟踪
+	now := time.Now()
+	for path, st := range m.files {
+		if !current[path] {
+			if !adopted[path] {
+				m.catchUp(path, st, &matched)
+			}
+			delete(m.files, path)
+			if e, ok := m.engines[path]; ok {
+				m.add(&matched, e.Flush(now, true))
+				delete(m.engines, path)
+			}
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(m.engines)) {
+		m.add(&matched, m.engines[path].Flush(now, false))
+	}
+	m.save()
+
+	if len(matched.messages) > 0 {
+		return monitor.Result{
+			Success:   false,
+			Message:   strings.Join(matched.messages, "\n"),
+			Labels:    m.labels(),
+			LogLines:  matched.lines,
+			Fields:    matched.fields,
+			Event:     true,
+			Offenders: matched.offenders,
+		}
+	}
+	return monitor.Result{Success: true, Labels: m.labels()}
+}
+
+// adopt 为新出现的文件查找它轮转前的记录: 轮转出的文件仍然匹配 glob 时 (例如 *.log* 匹配到 app.log.1)，
+// 它会沿用原文件的读取进度而不是从头读取。返回剩余内容已由新路径负责读取、不需要再补读的原路径。
+func (m *FileMonitor) adopt(paths []string) map[string]bool {
+	adopted := make(map[string]bool)
+	for _, path := range paths {
+		if _, ok := m.files[path]; ok {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		ino := inode(fi)
+		for old, st := range m.files {
+			if adopted[old] || !rotated(old, st) {
+				continue
+			}
+			// rename 轮转后 inode 不变；copytruncate 复制出的文件开头与原文件相同
+			sameFile := ino != 0 && st.Inode == ino
+			sameCopy := st.FingerprintLen > 0 && fi.Size() >= st.Offset && sameHead(path, st)
+			if !sameFile && !sameCopy {
+				continue
+			}
+			copied := *st
+			copied.Inode = ino
+			m.files[path] = &copied
+			adopted[old] = true
+			break
+		}
+	}
+	return adopted
+}
+
+// rotated 判断记录为 st 的 path 是否已被轮转: 文件不存在、inode 变化或被清空覆盖
+func rotated(path string, st *fileState) bool {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	return inode(fi) != st.Inode || fi.Size() < st.Offset || !sameHead(path, st)
+}
+
+// engine 返回 path 专用的规则引擎，不存在时创建
+func (m *FileMonitor) engine(path string) *rules.Engine {
+	e, ok := m.engines[path]
+	if !ok {
+		// 配置已在 New 中校验过
+		e, _ = rules.NewEngine(m.cfg)
+		m.engines[path] = e
+	}
+	return e
+}
+
+// expand 返回所有 glob 匹配的普通文件，已压缩的文件不参与跟踪
+func (m *FileMonitor) expand() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, p := range m.patterns {
+		matches, _ := filepath.Glob(p)
+		for _, path := range matches {
+			if seen[path] || strings.HasSuffix(path, ".gz") {
+				continue
+			}
+			if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+				continue
+			}
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// read 从 st.Offset 开始读取 path 中完整的新行
+func (m *FileMonitor) read(path string, st *fileState, b *batch) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(st.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	n, err := m.consume(f, path, b, false)
+	st.Offset += n
+	if st.FingerprintLen < fingerprintSize {
+		updateFingerprint(path, st)
+	}
+	return err
+}
+
+// catchUp 在 path 已被轮转后，从轮转出的文件 (包括 .gz) 中读完 st.Offset 之后的内容。
+// rename 轮转按 inode 查找，copytruncate 轮转按文件开头的指纹查找。
+func (m *FileMonitor) catchUp(path string, st *fileState, b *batch) {
+	for _, c := range rotatedCandidates(path) {
+		if strings.HasSuffix(c, ".gz") {
+			if m.catchUpGzip(c, path, st, b) {
+				return
+			}
+			continue
+		}
+		fi, err := os.Stat(c)
+		if err != nil {
+			continue
+		}
+		// rename 轮转后的文件 inode 不变；copytruncate 复制出的文件开头与原文件相同
+		sameFile := st.Inode != 0 && inode(fi) == st.Inode
+		sameCopy := st.FingerprintLen > 0 && fi.Size() >= st.Offset && sameHead(c, st)
+		if !sameFile && !sameCopy {
+			continue
+		}
+		f, err := os.Open(c)
+		if err != nil {
+			continue
+		}
+		if _, err := f.Seek(st.Offset, io.SeekStart); err == nil {
+			m.consume(f, path, b, true)
+		}
+		f.Close()
+		return
+	}
+}
+
+// rotatedCandidates 返回与 path 同目录、以 path 的文件名开头的其他文件，最新修改的在前。
+// 不使用 filepath.Glob，因为 path 本身可能包含 [、? 等 glob 字符。
+// 指纹较短时较旧的轮转文件也可能匹配，所以按修改时间优先尝试刚轮转出的文件。
+func rotatedCandidates(path string) []string {
+	dir, base := filepath.Split(path)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil
+	}
+	type candidate struct {
+		path    string
+		modTime time.Time
+	}
+	var candidates []candidate
+	for _, e := range entries {
+		if e.Name() == base || !strings.HasPrefix(e.Name(), base) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		candidates = append(candidates, candidate{filepath.Join(dir, e.Name()), info.ModTime()})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].modTime.After(candidates[j].modTime)
+	})
+	paths := make([]string, len(candidates))
+	for i, c := range candidates {
+		paths[i] = c.path
+	}
+	return paths
+}
+
+// catchUpGzip 检查 gz 文件的开头是否与 st 的指纹一致，一致时读完偏移量之后的内容
+func (m *FileMonitor) catchUpGzip(gzPath, path string, st *fileState, b *batch) bool {
+	if st.FingerprintLen == 0 {
+		return false
+	}
+	f, err := os.Open(gzPath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return false
+	}
+	defer zr.Close()
+
+	head := make([]byte, st.FingerprintLen)
+	if _, err := io.ReadFull(zr, head); err != nil || hash(head) != st.Fingerprint {
+		return false
+	}
+	log.Print(i18n.T("logfile.catch_up_gzip", m.name, gzPath))
+	if skip := st.Offset - st.FingerprintLen; skip < 0 {
+		// 偏移量落在指纹范围内 (末尾是不完整的行)，从已读出的开头中补上
+		m.consume(io.MultiReader(bytes.NewReader(head[st.Offset:]), zr), path, b, true)
+	} else if _, err := io.CopyN(io.Discard, zr, skip); err == nil {
+		m.consume(zr, path, b, true)
+	}
+	return true
+}
+
+// consume 逐行读取 r 并交给规则引擎，返回已处理的字节数。
+// final 为 false 时，末尾不完整的行留到下次读取；为 true 时 (文件已轮转) 也会处理。
+func (m *FileMonitor) consume(r io.Reader, path string, b *batch, final bool) (int64, error) {
+	br := bufio.NewReader(r)
+	var n int64
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF && (len(line) == 0 || !final) {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+		n += int64(len(line))
+		entry := rules.Entry{
+			Message: string(bytes.TrimRight(line, "\r\n")),
+			Fields:  map[string]string{"FILE": path},
+			Time:    time.Now(),
+		}
+		m.add(b, m.engine(path).Process(entry))
+		if err == io.EOF {
+			return n, nil
+		}
+	}
+}
+
+// describe 生成命中规则时的告警消息
+func (m *FileMonitor) describe(match rules.Match) string {
+	hit, file := match.Hit, match.Entry.Fields["FILE"]
+	if !hit.Threshold() {
+		return i18n.T("logfile.matched", m.name, file, hit.Rule, match.Entry.Message)
+	}
+	rule := hit.Rule
+	if hit.Key != "" {
+		rule = fmt.Sprintf("%s[%s]", hit.Rule, hit.Key)
+	}
+	return i18n.T("logfile.threshold_matched", m.name, file, rule, hit.Count, hit.Window, hit.Previous, match.Entry.Message)
+}
+
+// labels 返回附加在结果上的标签
+func (m *FileMonitor) labels() map[string]string {
+	return map[string]string{"type": "logfile", "service": m.name}
+}
+
+// save 持久化读取进度，失败只记录日志
+func (m *FileMonitor) save() {
+	if err := state.Save(m.statePath, persisted{Files: m.files}); err != nil {
+		log.Print(i18n.T("logfile.state_save_failed", m.name, m.statePath, err))
+	}
+}
+
+// sameHead 判断文件开头是否仍与记录的指纹一致
+func sameHead(path string, st *fileState) bool {
+	if st.FingerprintLen == 0 {
+		return true
+	}
+	head, err := readHead(path, st.FingerprintLen)
+	if err != nil || int64(len(head)) < st.FingerprintLen {
+		return false
+	}
+	return hash(head) == st.Fingerprint
+}
+
+// updateFingerprint 用文件当前的开头重新计算指纹
+func updateFingerprint(path string, st *fileState) {
+	head, err := readHead(path, fingerprintSize)
+	if err != nil || len(head) == 0 {
+		return
+	}
+	st.Fingerprint = hash(head)
+	st.FingerprintLen = int64(len(head))
+}
+
+func readHead(path string, size int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, size)
+	n, err := io.ReadFull(f, buf)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = nil
+	}
+	return buf[:n], err
+}
+
+func hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,130 @@
+package logfile
+
+import (
+	"hashcowuwu/lychee/internal/monitor/rules"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newMonitor(t *testing.T, dir string, cfg rules.EngineConfig, patterns ...string) *FileMonitor {
+	t.Helper()
+	for i, p := range patterns {
+		patterns[i] = filepath.Join(dir, p)
+	}
+	m, err := New("app", patterns, cfg, filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestPerFileEngine(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	appendFile(t, a, "")
+	appendFile(t, b, "")
+	cfg := rules.EngineConfig{Rules: []rules.Config{{Pattern: "ERROR", Threshold: 3, Window: time.Minute}}}
+	m := newMonitor(t, dir, cfg, "*.log")
+
+	// 两个文件各两次，不应合并计数
+	appendFile(t, a, "ERROR 1\nERROR 2\n")
+	appendFile(t, b, "ERROR 1\nERROR 2\n")
+	if r := m.Check(); !r.Success {
+		t.Fatalf("lines from different files were counted together: %s", r.Message)
+	}
+	appendFile(t, a, "ERROR 3\n")
+	if r := m.Check(); r.Success {
+		t.Error("threshold of a.log not reached")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	rename := func(t *testing.T, path string) {
+		if err := os.Rename(path, path+".1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	copytruncate := func(t *testing.T, path string) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path+".1", data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Truncate(path, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name    string
+		pattern string
+		rotate  func(*testing.T, string)
+	}{
+		{"rename", "app.log", rename},
+		{"rename matching glob", "app.log*", rename},
+		{"copytruncate", "app.log", copytruncate},
+		{"copytruncate matching glob", "app.log*", copytruncate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "app.log")
+			appendFile(t, path, "started\n")
+			m := newMonitor(t, dir, rules.EngineConfig{Rules: []rules.Config{{Pattern: "ERROR"}}}, tt.pattern)
+
+			// 轮转前写入但还没读取的行，以及轮转后的新行都只处理一次
+			appendFile(t, path, "ERROR before\n")
+			tt.rotate(t, path)
+			appendFile(t, path, "ERROR after\n")
+			r := m.Check()
+			for _, line := range []string{"ERROR before", "ERROR after"} {
+				if n := strings.Count(r.Message, line); n != 1 {
+					t.Errorf("%q reported %d times, want 1:\n%s", line, n, r.Message)
+				}
+			}
+			if r := m.Check(); !r.Success {
+				t.Errorf("second check reported again: %s", r.Message)
+			}
+		})
+	}
+}
+
+func TestRotatedCandidates(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	touch := func(name string, age time.Duration) {
+		appendFile(t, filepath.Join(dir, name), "")
+		mtime := now.Add(-age)
+		if err := os.Chtimes(filepath.Join(dir, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	touch("app[1].log", 0)
+	touch("app[1].log-20240101.gz", 48*time.Hour)
+	touch("app[1].log.1", time.Hour)
+	// 作为 glob 时 app[1].log* 会匹配这个文件
+	touch("app1.log.1", 0)
+
+	got := rotatedCandidates(filepath.Join(dir, "app[1].log"))
+	want := []string{filepath.Join(dir, "app[1].log.1"), filepath.Join(dir, "app[1].log-20240101.gz")}
+	if !slices.Equal(got, want) {
+		t.Errorf("rotatedCandidates() = %q, want %q", got, want)
+	}
+}
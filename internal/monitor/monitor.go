@@ -1,10 +1,27 @@
 package monitor
 
+import "context"
+
+// Severity 表示失败结果的严重程度
+type Severity string
+
+const (
+	SeverityWarning  Severity = "warning"  // 警告
+	SeverityCritical Severity = "critical" // 严重
+	SeverityUnknown  Severity = "unknown"  // 无法判断状态，例如检查脚本超时
+)
+
 // Result 包含了监控检查的结果
 type Result struct {
-	Success bool   // 是否成功
-	Message string // 附带信息
-	Err     error  // 错误信息
+	Success   bool              // 是否成功
+	Message   string            // 附带信息
+	Err       error             // 错误信息
+	Severity  Severity          // 失败时的严重程度，为空时视为 critical
+	Labels    map[string]string // 附加标签，用于模板和告警分组
+	LogLines  []string          // 与本次结果相关的最近日志行
+	Fields    map[string]string // 最近一条相关日志的结构化字段，例如 journal 的 PRIORITY、_PID
+	Event     bool              // 结果描述的是一次性事件（如日志命中）而非持续状态
+	Offenders []string          // 应当封禁的来源地址，例如暴力破解的 IP，启用自动封禁时生效
 }
 
 // Monitor 定义了所有监控器的通用接口
@@ -14,3 +31,11 @@ type Monitor interface {
 	// Name 返回监控器的名称
 	Name() string
 }
+
+// Streamer 由持续产出结果的监控器实现，例如实时跟随日志的监控器。
+// Stream 在 ctx 结束前阻塞，每产生一个需要告警的结果就调用一次 emit；
+// 同时 Check 仍会被周期性调用，用于报告监控器自身的健康状态。
+type Streamer interface {
+	Monitor
+	Stream(ctx context.Context, emit func(Result))
+}
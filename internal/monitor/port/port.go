@@ -0,0 +1,146 @@
+package port
+
+import (
+	"errors"
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"net"
+	"regexp"
+	"syscall"
+	"time"
+)
+
+// DefaultTimeout 是连接、发送和等待响应的总超时
+const DefaultTimeout = 5 * time.Second
+
+// maxResponse 是读取响应的字节数上限
+const maxResponse = 4096
+
+// Config 描述一个端口检查
+type Config struct {
+	Name     string        `yaml:"name"`     // 默认使用 address
+	Protocol string        `yaml:"protocol"` // tcp (默认) / udp
+	Address  string        `yaml:"address"`  // host:port
+	Send     string        `yaml:"send"`     // 连接后发送的内容，例如 "PING\r\n"
+	Expect   string        `yaml:"expect"`   // 响应需要匹配的正则表达式，例如 "^\\+PONG"
+	Timeout  time.Duration `yaml:"timeout"`  // 默认 5s
+}
+
+// Validate 检查配置，不访问网络
+func (c Config) Validate() error {
+	if c.Protocol != "" && c.Protocol != "tcp" && c.Protocol != "udp" {
+		return i18n.Errorf("port.protocol_unknown", c.Protocol)
+	}
+	if _, _, err := net.SplitHostPort(c.Address); err != nil {
+		return i18n.Errorf("port.address_invalid", c.Address, err)
+	}
+	if c.Expect != "" {
+		if _, err := regexp.Compile(c.Expect); err != nil {
+			return i18n.Errorf("port.regex_invalid", c.Expect, err)
+		}
+	}
+	if c.Timeout < 0 {
+		return i18n.Errorf("port.timeout_negative")
+	}
+	return nil
+}
+
+// PortMonitor 检查一个 TCP 或 UDP 端口是否可用
+type PortMonitor struct {
+	cfg    Config
+	name   string
+	expect *regexp.Regexp
+}
+
+// New 根据配置创建端口监控器
+func New(cfg Config) (*PortMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	m := &PortMonitor{cfg: cfg, name: cfg.Name}
+	if m.name == "" {
+		m.name = cfg.Address
+	}
+	if m.cfg.Protocol == "" {
+		m.cfg.Protocol = "tcp"
+	}
+	if m.cfg.Timeout == 0 {
+		m.cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Expect != "" {
+		m.expect = regexp.MustCompile(cfg.Expect)
+	}
+	return m, nil
+}
+
+// Name 返回监控器名称
+func (m *PortMonitor) Name() string {
+	return fmt.Sprintf("%s(%s)", m.cfg.Protocol, m.name)
+}
+
+// Check 建立连接，按配置发送数据并检查响应
+func (m *PortMonitor) Check() monitor.Result {
+	start := time.Now()
+	conn, err := net.DialTimeout(m.cfg.Protocol, m.cfg.Address, m.cfg.Timeout)
+	if err != nil {
+		return m.fail(i18n.T("port.connect_failed", m.name, m.cfg.Protocol, m.cfg.Address, err), err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(start.Add(m.cfg.Timeout))
+
+	// UDP 没有连接，至少发送一个数据报才能得知端口是否可达
+	if m.cfg.Send != "" || m.cfg.Protocol == "udp" {
+		if _, err := conn.Write([]byte(m.cfg.Send)); err != nil {
+			return m.fail(i18n.T("port.send_failed", m.name, err), err)
+		}
+	}
+
+	switch {
+	case m.expect != nil:
+		resp, err := m.read(conn)
+		if !m.expect.Match(resp) {
+			if err != nil && len(resp) == 0 {
+				return m.fail(i18n.T("port.read_failed", m.name, err), err)
+			}
+			return m.fail(i18n.T("port.expect_mismatch", m.name, string(resp), m.cfg.Expect), nil)
+		}
+	case m.cfg.Protocol == "udp":
+		// 没有期望的响应时，只有收到 ICMP 端口不可达才视为失败，超时视为正常
+		conn.SetReadDeadline(time.Now().Add(min(m.cfg.Timeout, time.Second)))
+		if _, err := conn.Read(make([]byte, maxResponse)); errors.Is(err, syscall.ECONNREFUSED) {
+			return m.fail(i18n.T("port.connect_failed", m.name, m.cfg.Protocol, m.cfg.Address, err), err)
+		}
+	}
+
+	return monitor.Result{
+		Success: true,
+		Message: i18n.T("port.ok", m.name, m.cfg.Protocol, m.cfg.Address, time.Since(start).Round(time.Millisecond)),
+		Labels:  m.labels(),
+	}
+}
+
+// read 读取响应直到匹配 expect、对端关闭、超时或达到 maxResponse
+func (m *PortMonitor) read(conn net.Conn) ([]byte, error) {
+	buf := make([]byte, 0, maxResponse)
+	for len(buf) < maxResponse {
+		n, err := conn.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if m.expect.Match(buf) || m.cfg.Protocol == "udp" {
+			return buf, err
+		}
+		if err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
+
+func (m *PortMonitor) fail(msg string, err error) monitor.Result {
+	return monitor.Result{Success: false, Message: msg, Err: err, Labels: m.labels()}
+}
+
+// labels 返回附加在结果上的标签
+func (m *PortMonitor) labels() map[string]string {
+	return map[string]string{"type": m.cfg.Protocol, "service": m.name}
+}
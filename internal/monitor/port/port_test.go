@@ -0,0 +1,120 @@
+package port
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tcpServer 启动一个 TCP 服务，每个连接读取一行并回复 "+" 加上这一行的大写形式
+func tcpServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				conn.Write([]byte("+" + strings.ToUpper(line)))
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// udpServer 启动一个 UDP 服务，原样回复收到的数据报
+func udpServer(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, maxResponse)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(buf[:n], addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// closedAddr 返回一个刚刚释放、没有程序监听的地址
+func closedAddr(t *testing.T, network string) string {
+	t.Helper()
+	if network == "udp" {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.LocalAddr().String()
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestCheck(t *testing.T) {
+	tcp, udp := tcpServer(t), udpServer(t)
+	tests := []struct {
+		name    string
+		cfg     Config
+		success bool
+	}{
+		{"tcp open", Config{Address: tcp}, true},
+		{"tcp closed", Config{Address: closedAddr(t, "tcp")}, false},
+		{"tcp expect", Config{Address: tcp, Send: "ping\r\n", Expect: `^\+PING`}, true},
+		{"tcp expect mismatch", Config{Address: tcp, Send: "ping\r\n", Expect: `^\+PONG`}, false},
+		{"udp expect", Config{Protocol: "udp", Address: udp, Send: "ping", Expect: "^ping$"}, true},
+		{"udp open without reply", Config{Protocol: "udp", Address: udp, Timeout: 200 * time.Millisecond}, true},
+		{"udp closed", Config{Protocol: "udp", Address: closedAddr(t, "udp"), Timeout: 200 * time.Millisecond}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r := m.Check(); r.Success != tt.success {
+				t.Errorf("Success = %v, want %v (%s)", r.Success, tt.success, r.Message)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		valid bool
+	}{
+		{"tcp", Config{Address: "localhost:6379"}, true},
+		{"udp", Config{Protocol: "udp", Address: "localhost:53"}, true},
+		{"unknown protocol", Config{Protocol: "sctp", Address: "localhost:53"}, false},
+		{"missing port", Config{Address: "localhost"}, false},
+		{"invalid regex", Config{Address: "localhost:6379", Expect: "("}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"hashcowuwu/lychee/internal/units"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// procRoot 是 proc 文件系统的挂载位置，测试时指向 testdata
+var procRoot = "/proc"
+
+// Config 描述要监控的一组进程。process、cmdline、user、pidFile 至少设置一个，同时设置时需全部满足。
+type Config struct {
+	Name    string `yaml:"name"`    // 默认使用 process、cmdline 或 pidFile
+	Process string `yaml:"process"` // 进程名，与 /proc/<pid>/comm 或 argv[0] 的文件名比较
+	Cmdline string `yaml:"cmdline"` // 完整命令行 (参数以空格连接) 需要匹配的正则表达式
+	User    string `yaml:"user"`    // 用户名或 uid
+	PIDFile string `yaml:"pidFile"` // 只检查 pid 文件中记录的进程
+
+	// Min 是最少实例数，不设置时为 1 (max 为 0 时为 0)，0 表示不检查下限
+	Min *int `yaml:"min"`
+	// Max 是最多实例数，不设置时不限制，0 表示进程不应运行
+	Max *int `yaml:"max"`
+
+	MaxRSS     string `yaml:"maxRSS"`     // 单个进程的常驻内存上限，例如 512MiB、2G
+	MaxFDs     int    `yaml:"maxFDs"`     // 单个进程打开的文件描述符上限
+	MaxThreads int    `yaml:"maxThreads"` // 单个进程的线程数上限
+	Zombie     bool   `yaml:"zombie"`     // 有进程处于僵尸状态时告警
+}
+
+// Validate 检查配置，不访问 /proc
+func (c Config) Validate() error {
+	if c.Process == "" && c.Cmdline == "" && c.User == "" && c.PIDFile == "" {
+		return i18n.Errorf("process.match_missing")
+	}
+	if c.Cmdline != "" {
+		if _, err := regexp.Compile(c.Cmdline); err != nil {
+			return i18n.Errorf("process.regex_invalid", c.Cmdline, err)
+		}
+	}
+	minimum, maximum := c.limits()
+	if minimum < 0 || (c.Max != nil && maximum < minimum) || c.MaxFDs < 0 || c.MaxThreads < 0 {
+		return i18n.Errorf("process.limits_invalid")
+	}
+	if c.MaxRSS != "" {
+		if _, err := units.ParseBytes(c.MaxRSS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limits 返回实例数的上下限，未设置上限时 maximum 为 -1
+func (c Config) limits() (minimum, maximum int) {
+	minimum, maximum = 1, -1
+	if c.Max != nil {
+		maximum = *c.Max
+		minimum = min(minimum, maximum)
+	}
+	if c.Min != nil {
+		minimum = *c.Min
+	}
+	return minimum, maximum
+}
+
+// ProcessMonitor 扫描 /proc 统计匹配的进程并检查它们的资源使用
+type ProcessMonitor struct {
+	cfg     Config
+	name    string
+	cmdline *regexp.Regexp
+	uid     string // 为空时不按用户过滤
+	maxRSS  uint64
+	min     int
+	max     int // -1 表示不限制
+}
+
+// New 根据配置创建进程监控器，user 为用户名时在此解析为 uid
+func New(cfg Config) (*ProcessMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	m := &ProcessMonitor{cfg: cfg, name: cfg.Name}
+	if m.name == "" {
+		for _, s := range []string{cfg.Process, cfg.Cmdline, cfg.PIDFile, cfg.User} {
+			if s != "" {
+				m.name = s
+				break
+			}
+		}
+	}
+	m.min, m.max = cfg.limits()
+	if cfg.Cmdline != "" {
+		m.cmdline = regexp.MustCompile(cfg.Cmdline)
+	}
+	if cfg.User != "" {
+		if _, err := strconv.Atoi(cfg.User); err == nil {
+			m.uid = cfg.User
+		} else {
+			u, err := user.Lookup(cfg.User)
+			if err != nil {
+				return nil, i18n.Errorf("process.user_unknown", cfg.User, err)
+			}
+			m.uid = u.Uid
+		}
+	}
+	m.maxRSS, _ = units.ParseBytes(cfg.MaxRSS)
+	return m, nil
+}
+
+// Name 返回监控器名称
+func (m *ProcessMonitor) Name() string {
+	return fmt.Sprintf("process(%s)", m.name)
+}
+
+// Check 统计匹配的进程数量，并检查每个进程的内存、文件描述符、线程数和状态
+func (m *ProcessMonitor) Check() monitor.Result {
+	pids, err := m.candidates()
+	if err != nil {
+		return monitor.Result{Success: false, Message: i18n.T("process.scan_failed", m.name, err), Err: err, Labels: m.labels()}
+	}
+
+	var procs []proc
+	self := os.Getpid()
+	for _, pid := range pids {
+		if pid == self {
+			continue
+		}
+		p, err := readProc(pid)
+		if err != nil {
+			// 进程在扫描期间退出
+			continue
+		}
+		if m.matches(p) {
+			procs = append(procs, p)
+		}
+	}
+
+	var messages []string
+	severity := monitor.SeverityWarning
+	if len(procs) < m.min {
+		messages = append(messages, i18n.T("process.too_few", m.name, len(procs), m.min))
+		severity = monitor.SeverityCritical
+	}
+	if m.max >= 0 && len(procs) > m.max {
+		messages = append(messages, i18n.T("process.too_many", m.name, len(procs), m.max))
+	}
+	for _, p := range procs {
+		messages = append(messages, m.checkResources(p)...)
+	}
+
+	if len(messages) > 0 {
+		return monitor.Result{
+			Success:  false,
+			Message:  strings.Join(messages, "\n"),
+			Severity: severity,
+			Labels:   m.labels(),
+		}
+	}
+	return monitor.Result{Success: true, Message: i18n.T("process.ok", m.name, len(procs)), Labels: m.labels()}
+}
+
+// candidates 返回需要检查的 pid: 配置了 pidFile 时只有文件中的 pid，否则是 /proc 下的全部进程
+func (m *ProcessMonitor) candidates() ([]int, error) {
+	if m.cfg.PIDFile != "" {
+		data, err := os.ReadFile(m.cfg.PIDFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, i18n.Errorf("process.pidfile_invalid", m.cfg.PIDFile, err)
+		}
+		return []int{pid}, nil
+	}
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, e := range entries {
+		if pid, err := strconv.Atoi(e.Name()); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// matches 判断进程是否满足所有配置的条件
+func (m *ProcessMonitor) matches(p proc) bool {
+	if m.cfg.Process != "" && p.comm != m.cfg.Process && filepath.Base(p.argv0()) != m.cfg.Process {
+		return false
+	}
+	if m.cmdline != nil && !m.cmdline.MatchString(p.commandLine()) {
+		return false
+	}
+	if m.uid != "" && p.uid != m.uid {
+		return false
+	}
+	return true
+}
+
+// checkResources 检查单个进程的资源上限和状态
+func (m *ProcessMonitor) checkResources(p proc) []string {
+	var messages []string
+	if m.cfg.Zombie && p.state == "Z" {
+		messages = append(messages, i18n.T("process.zombie", m.name, p.pid))
+	}
+	if m.maxRSS > 0 && p.rss > m.maxRSS {
+		messages = append(messages, i18n.T("process.rss_high", m.name, p.pid, units.FormatBytes(p.rss), m.cfg.MaxRSS))
+	}
+	if m.cfg.MaxThreads > 0 && p.threads > m.cfg.MaxThreads {
+		messages = append(messages, i18n.T("process.threads_high", m.name, p.pid, p.threads, m.cfg.MaxThreads))
+	}
+	if m.cfg.MaxFDs > 0 {
+		fds, err := countFDs(p.pid)
+		if err != nil {
+			messages = append(messages, i18n.T("process.fds_failed", m.name, p.pid, err))
+		} else if fds > m.cfg.MaxFDs {
+			messages = append(messages, i18n.T("process.fds_high", m.name, p.pid, fds, m.cfg.MaxFDs))
+		}
+	}
+	return messages
+}
+
+// labels 返回附加在结果上的标签
+func (m *ProcessMonitor) labels() map[string]string {
+	return map[string]string{"type": "process", "service": m.name}
+}
+
+// proc 是从 /proc/<pid> 读取的进程信息
+type proc struct {
+	pid     int
+	comm    string
+	args    []string
+	uid     string // 真实 uid
+	state   string // 单个字母，例如 R、S、Z
+	rss     uint64 // 字节
+	threads int
+}
+
+func (p proc) argv0() string {
+	if len(p.args) == 0 {
+		return ""
+	}
+	return p.args[0]
+}
+
+func (p proc) commandLine() string {
+	return strings.Join(p.args, " ")
+}
+
+// readProc 读取进程的 comm、cmdline 和 status
+func readProc(pid int) (proc, error) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	p := proc{pid: pid}
+
+	comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+	if err != nil {
+		return p, err
+	}
+	p.comm = strings.TrimSpace(string(comm))
+
+	// 内核线程的 cmdline 为空
+	cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return p, err
+	}
+	for _, arg := range bytes.Split(bytes.TrimRight(cmdline, "\x00"), []byte{0}) {
+		if len(arg) > 0 {
+			p.args = append(p.args, string(arg))
+		}
+	}
+
+	status, err := os.ReadFile(filepath.Join(dir, "status"))
+	if err != nil {
+		return p, err
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		switch key {
+		case "State":
+			p.state = fields[0]
+		case "Uid":
+			p.uid = fields[0]
+		case "VmRSS":
+			kb, _ := strconv.ParseUint(fields[0], 10, 64)
+			p.rss = kb * 1024
+		case "Threads":
+			p.threads, _ = strconv.Atoi(fields[0])
+		}
+	}
+	return p, nil
+}
+
+// countFDs 统计进程打开的文件描述符数量，读取其他用户的进程需要相应权限
+func countFDs(pid int) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(procRoot, strconv.Itoa(pid), "fd"))
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,108 @@
+package process
+
+import (
+	"hashcowuwu/lychee/internal/monitor"
+	"slices"
+	"testing"
+)
+
+// testdata/proc 中的进程:
+//
+//	100 nginx master  uid 0     10 MiB    3 fd
+//	101 nginx worker  uid 33    586 MiB  40 fd
+//	102 nginx worker  uid 33    20 MiB   40 fd
+//	200 python3 worker.py  uid 1000  僵尸进程，4 个线程
+//	300 kthreadd  内核线程，cmdline 为空
+func ptr(n int) *int { return &n }
+
+func useTestdata(t *testing.T) {
+	t.Helper()
+	old := procRoot
+	procRoot = "testdata/proc"
+	t.Cleanup(func() { procRoot = old })
+}
+
+func TestCheck(t *testing.T) {
+	useTestdata(t)
+	tests := []struct {
+		name     string
+		cfg      Config
+		success  bool
+		severity monitor.Severity
+	}{
+		{"by name", Config{Process: "nginx"}, true, ""},
+		{"by argv0", Config{Process: "python3"}, true, ""},
+		{"by cmdline", Config{Cmdline: `worker\.py --queue high`}, true, ""},
+		{"by user", Config{Process: "nginx", User: "33", Min: ptr(2)}, true, ""},
+		{"not running", Config{Process: "redis-server"}, false, monitor.SeverityCritical},
+		{"too few", Config{Process: "nginx", Min: ptr(4)}, false, monitor.SeverityCritical},
+		{"too many", Config{Process: "nginx", Max: ptr(2)}, false, monitor.SeverityWarning},
+		{"within max", Config{Process: "nginx", Max: ptr(3)}, true, ""},
+		{"must not run", Config{Process: "nginx", Max: ptr(0)}, false, monitor.SeverityWarning},
+		{"must not run and is not", Config{Process: "redis-server", Max: ptr(0)}, true, ""},
+		{"only limit the count", Config{Process: "redis-server", Min: ptr(0), Max: ptr(3)}, true, ""},
+		{"rss", Config{Process: "nginx", MaxRSS: "512MiB"}, false, monitor.SeverityWarning},
+		{"fds", Config{Process: "nginx", MaxFDs: 32}, false, monitor.SeverityWarning},
+		{"threads", Config{Process: "python3", MaxThreads: 2}, false, monitor.SeverityWarning},
+		{"zombie", Config{Process: "python3", Zombie: true}, false, monitor.SeverityWarning},
+		{"pid file", Config{PIDFile: "testdata/nginx.pid", MaxFDs: 32}, true, ""},
+		{"stale pid file", Config{PIDFile: "testdata/stale.pid"}, false, monitor.SeverityCritical},
+		{"missing pid file", Config{PIDFile: "testdata/missing.pid"}, false, monitor.SeverityCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := m.Check()
+			if r.Success != tt.success || r.Severity != tt.severity {
+				t.Errorf("got Success = %v, Severity = %q, want %v, %q (%s)", r.Success, r.Severity, tt.success, tt.severity, r.Message)
+			}
+		})
+	}
+}
+
+func TestReadProc(t *testing.T) {
+	useTestdata(t)
+	tests := []struct {
+		pid  int
+		want proc
+	}{
+		{100, proc{pid: 100, comm: "nginx", args: []string{"/usr/sbin/nginx", "-g", "daemon off;"}, uid: "0", state: "S", rss: 10240 * 1024, threads: 1}},
+		{200, proc{pid: 200, comm: "python3", args: []string{"/usr/bin/python3", "/opt/app/worker.py", "--queue", "high"}, uid: "1000", state: "Z", threads: 4}},
+		{300, proc{pid: 300, comm: "kthreadd", uid: "0", state: "S", threads: 1}},
+	}
+	for _, tt := range tests {
+		got, err := readProc(tt.pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.pid != tt.want.pid || got.comm != tt.want.comm || !slices.Equal(got.args, tt.want.args) ||
+			got.uid != tt.want.uid || got.state != tt.want.state || got.rss != tt.want.rss || got.threads != tt.want.threads {
+			t.Errorf("readProc(%d) = %+v, want %+v", tt.pid, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		valid bool
+	}{
+		{"name", Config{Process: "nginx"}, true},
+		{"nothing to match", Config{Min: ptr(1)}, false},
+		{"invalid regex", Config{Cmdline: "("}, false},
+		{"max below min", Config{Process: "nginx", Min: ptr(3), Max: ptr(2)}, false},
+		{"max zero", Config{Process: "nginx", Max: ptr(0)}, true},
+		{"max zero with min", Config{Process: "nginx", Min: ptr(1), Max: ptr(0)}, false},
+		{"negative min", Config{Process: "nginx", Min: ptr(-1)}, false},
+		{"invalid rss", Config{Process: "nginx", MaxRSS: "lots"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package rules
+
+import (
+	"hashcowuwu/lychee/internal/i18n"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 上下文的默认值
+const (
+	DefaultContextTimeout  = 5 * time.Second
+	DefaultContextMaxBytes = 2000
+)
+
+// ContextConfig 描述告警中附带的命中日志前后的上下文
+type ContextConfig struct {
+	Before   int           `yaml:"before"`   // 命中之前的日志条数
+	After    int           `yaml:"after"`    // 命中之后的日志条数，收集够或超时后才告警
+	Timeout  time.Duration `yaml:"timeout"`  // 等待后续日志的最长时间，默认 5s
+	MaxBytes int           `yaml:"maxBytes"` // 上下文 (含命中行) 的最大字节数，默认 2000，超出时优先丢弃离命中最远的行
+}
+
+// Enabled 判断是否配置了上下文
+func (c ContextConfig) Enabled() bool {
+	return c.Before > 0 || c.After > 0
+}
+
+func (c ContextConfig) validate() error {
+	if c.Before < 0 || c.After < 0 || c.MaxBytes < 0 {
+		return i18n.Errorf("rules.context_negative")
+	}
+	return nil
+}
+
+// contextBuffer 保存最近的日志，并让命中等待后续的日志，不是并发安全的
+type contextBuffer struct {
+	before   int
+	after    int
+	timeout  time.Duration
+	maxBytes int
+
+	recent  []string // 最近 before 条日志，按时间顺序
+	pending []pendingMatch
+}
+
+type pendingMatch struct {
+	match    Match
+	deadline time.Time
+}
+
+func newContextBuffer(cfg ContextConfig) *contextBuffer {
+	c := &contextBuffer{before: cfg.Before, after: cfg.After, timeout: cfg.Timeout, maxBytes: cfg.MaxBytes}
+	if c.timeout <= 0 {
+		c.timeout = DefaultContextTimeout
+	}
+	if c.maxBytes <= 0 {
+		c.maxBytes = DefaultContextMaxBytes
+	}
+	return c
+}
+
+// add 记录一条日志及其产生的命中，返回上下文已收集完成的命中
+func (c *contextBuffer) add(record Entry, matches []Match) []Match {
+	var ready []Match
+	// 先把这条日志作为之前命中的后续上下文
+	kept := c.pending[:0]
+	for _, p := range c.pending {
+		p.match.After = append(p.match.After, record.Message)
+		if len(p.match.After) >= c.after {
+			ready = append(ready, c.finish(p.match))
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	c.pending = kept
+
+	for _, m := range matches {
+		m.Before = append([]string(nil), c.recent...)
+		if c.after == 0 {
+			ready = append(ready, c.finish(m))
+			continue
+		}
+		c.pending = append(c.pending, pendingMatch{match: m, deadline: record.Time.Add(c.timeout)})
+	}
+
+	if c.before > 0 {
+		c.recent = append(c.recent, record.Message)
+		if len(c.recent) > c.before {
+			c.recent = c.recent[len(c.recent)-c.before:]
+		}
+	}
+	return ready
+}
+
+// flush 返回等待后续日志超时 (或 force 为 true 时全部) 的命中
+func (c *contextBuffer) flush(now time.Time, force bool) []Match {
+	var ready []Match
+	kept := c.pending[:0]
+	for _, p := range c.pending {
+		if force || !now.Before(p.deadline) {
+			ready = append(ready, c.finish(p.match))
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	c.pending = kept
+	return ready
+}
+
+// finish 把上下文截断到 maxBytes 以内
+func (c *contextBuffer) finish(m Match) Match {
+	size := func() int {
+		n := len(m.Entry.Message)
+		for _, l := range m.Before {
+			n += len(l) + 1
+		}
+		for _, l := range m.After {
+			n += len(l) + 1
+		}
+		return n
+	}
+	// 交替丢弃最早的前文和最晚的后文，使保留下来的行尽量靠近命中
+	for size() > c.maxBytes && len(m.Before)+len(m.After) > 0 {
+		if len(m.Before) >= len(m.After) {
+			m.Before = m.Before[1:]
+			m.Omitted++
+		} else {
+			m.After = m.After[:len(m.After)-1]
+			m.Omitted++
+		}
+	}
+	if len(m.Entry.Message) > c.maxBytes {
+		m.Entry.Message = truncate(m.Entry.Message, c.maxBytes)
+	}
+	return m
+}
+
+// truncate 在不切断 UTF-8 字符的前提下把 s 截断到 n 字节以内
+func truncate(s string, n int) string {
+	const ellipsis = "…"
+	if len(s) <= n {
+		return s
+	}
+	suffix := ellipsis
+	if n < len(ellipsis) {
+		// 上限连省略号都放不下
+		suffix = ""
+	}
+	cut := max(n-len(suffix), 0)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + suffix
+}
+
+// Lines 返回带上下文的日志行: 前文、命中行、后文
+func (m Match) Lines() []string {
+	lines := make([]string, 0, len(m.Before)+1+len(m.After))
+	lines = append(lines, m.Before...)
+	lines = append(lines, m.Entry.Message)
+	return append(lines, m.After...)
+}
+
+// FormatContext 把上下文排版成多行文本，命中行以 "> " 开头，其他行以 "| " 开头。
+// 没有上下文时返回空字符串。
+func (m Match) FormatContext() string {
+	if len(m.Before) == 0 && len(m.After) == 0 && m.Omitted == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, l := range m.Before {
+		sb.WriteString("| " + l + "\n")
+	}
+	sb.WriteString("> " + m.Entry.Message + "\n")
+	for _, l := range m.After {
+		sb.WriteString("| " + l + "\n")
+	}
+	if m.Omitted > 0 {
+		sb.WriteString(i18n.T("rules.context_omitted", m.Omitted) + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,122 @@
+package rules
+
+import "time"
+
+// EngineConfig 描述日志从读取到匹配规则的完整处理流程
+type EngineConfig struct {
+	Rules     []Config
+	Multiline MultilineConfig // 多行合并，未配置时每条日志单独处理
+	Parse     string          // 解析日志正文的格式: json / logfmt，解析出的字段可用于规则的 fields 过滤
+	Context   ContextConfig   // 命中时附带的前后日志，未配置时不附带
+}
+
+// Match 是一次需要告警的命中及其对应的日志记录
+type Match struct {
+	Hit   Hit
+	Entry Entry
+
+	Before  []string // 命中之前的日志，按时间顺序
+	After   []string // 命中之后的日志
+	Omitted int      // 因超出大小限制而省略的上下文行数
+}
+
+// Engine 依次执行多行合并、正文解析和规则匹配。
+// Engine 带有状态且不是并发安全的，每个监控器应持有自己的 Engine。
+type Engine struct {
+	rules     []*Rule
+	assembler *Assembler // 为 nil 时不做多行合并
+	parse     string
+	context   *contextBuffer // 为 nil 时不附带上下文
+}
+
+// NewEngine 编译规则和预处理配置
+func NewEngine(cfg EngineConfig) (*Engine, error) {
+	if err := validFormat(cfg.Parse); err != nil {
+		return nil, err
+	}
+	compiled, err := CompileAll(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Context.validate(); err != nil {
+		return nil, err
+	}
+	e := &Engine{rules: compiled, parse: cfg.Parse}
+	if cfg.Multiline.Enabled() {
+		if e.assembler, err = NewAssembler(cfg.Multiline); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Context.Enabled() {
+		e.context = newContextBuffer(cfg.Context)
+	}
+	return e, nil
+}
+
+// FlushInterval 返回调用方应当调用 Flush 的最长间隔，不需要定期 Flush 时返回 0
+func (e *Engine) FlushInterval() time.Duration {
+	var interval time.Duration
+	if e.assembler != nil {
+		interval = e.assembler.Timeout()
+	}
+	if e.context != nil && e.context.after > 0 && (interval == 0 || e.context.timeout < interval) {
+		interval = e.context.timeout
+	}
+	return interval
+}
+
+// Process 处理一条日志，返回因此产生的命中
+func (e *Engine) Process(entry Entry) []Match {
+	if e.assembler == nil {
+		return e.record(entry)
+	}
+	var matches []Match
+	for _, record := range e.assembler.Add(entry) {
+		matches = append(matches, e.record(record)...)
+	}
+	return matches
+}
+
+// Flush 处理等待续行超时的多行记录和等待后续上下文超时的命中，force 为 true 时全部处理
+func (e *Engine) Flush(now time.Time, force bool) []Match {
+	var matches []Match
+	if e.assembler != nil {
+		for _, record := range e.assembler.Flush(now, force) {
+			matches = append(matches, e.record(record)...)
+		}
+	}
+	if e.context != nil {
+		matches = append(matches, e.context.flush(now, force)...)
+	}
+	return matches
+}
+
+// record 匹配一条完整的记录，并在配置了上下文时收集前后的日志
+func (e *Engine) record(entry Entry) []Match {
+	matches := e.evaluate(entry)
+	if e.context == nil {
+		return matches
+	}
+	return e.context.add(entry, matches)
+}
+
+// evaluate 解析记录正文并按顺序匹配规则，命中一条规则即停止
+func (e *Engine) evaluate(entry Entry) []Match {
+	if parsed := ParseMessage(e.parse, entry.Message); parsed != nil {
+		fields := make(map[string]string, len(entry.Fields)+len(parsed))
+		for k, v := range parsed {
+			fields[k] = v
+		}
+		// 原有字段优先，解析结果不会覆盖 journal 字段
+		for k, v := range entry.Fields {
+			fields[k] = v
+		}
+		entry.Fields = fields
+	}
+	for _, rule := range e.rules {
+		if hit, ok := rule.Observe(entry); ok {
+			return []Match{{Hit: hit, Entry: entry}}
+		}
+	}
+	return nil
+}
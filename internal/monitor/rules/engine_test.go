@@ -0,0 +1,179 @@
+package rules
+
+import (
+	"hashcowuwu/lychee/internal/i18n"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEngineMultiline(t *testing.T) {
+	e, err := NewEngine(EngineConfig{
+		Rules:     []Config{{Pattern: "panic:"}},
+		Multiline: MultilineConfig{Start: `^\S`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var matches []Match
+	for i, line := range []string{"panic: runtime error", "\tgoroutine 1 [running]:", "\tmain.main()", "next record"} {
+		matches = append(matches, e.Process(Entry{Message: line, Time: start.Add(time.Duration(i) * time.Millisecond)})...)
+	}
+	if len(matches) != 1 || matches[0].Entry.Message != "panic: runtime error\n\tgoroutine 1 [running]:\n\tmain.main()" {
+		t.Fatalf("matches = %+v", matches)
+	}
+	// 最后一条记录在超时后由 Flush 处理
+	if m := e.Flush(start.Add(time.Second), false); len(m) != 0 {
+		t.Errorf("flushed before the timeout: %+v", m)
+	}
+	if m := e.Flush(start.Add(DefaultMultilineTimeout+time.Second), false); len(m) != 0 {
+		t.Errorf("unexpected matches: %+v", m)
+	}
+}
+
+func TestEngineParse(t *testing.T) {
+	tests := []struct {
+		format, msg string
+		want        bool
+	}{
+		{FormatJSON, `{"level":"error","msg":"db down"}`, true},
+		{FormatJSON, `{"level":"info","msg":"db up"}`, false},
+		{FormatLogfmt, `level=error msg="db down"`, true},
+		{FormatLogfmt, `level=warn msg="slow query"`, false},
+		{FormatJSON, `not json`, false},
+	}
+	for _, tt := range tests {
+		e, err := NewEngine(EngineConfig{Rules: []Config{{Fields: map[string]string{"level": "error"}}}, Parse: tt.format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(e.Process(Entry{Message: tt.msg})) == 1; got != tt.want {
+			t.Errorf("%s %q matched = %v, want %v", tt.format, tt.msg, got, tt.want)
+		}
+	}
+	if _, err := NewEngine(EngineConfig{Parse: "xml"}); err == nil {
+		t.Error("unknown parse format accepted")
+	}
+}
+
+func TestEngineContext(t *testing.T) {
+	e, err := NewEngine(EngineConfig{
+		Rules:   []Config{{Pattern: "error"}},
+		Context: ContextConfig{Before: 2, After: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var matches []Match
+	for _, line := range []string{"a", "b", "c", "error", "d", "e"} {
+		matches = append(matches, e.Process(Entry{Message: line, Time: start})...)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("matches = %+v", matches)
+	}
+	if got := matches[0].Lines(); !slices.Equal(got, []string{"b", "c", "error", "d"}) {
+		t.Errorf("Lines = %q", got)
+	}
+
+	// 后续日志没有到达时等待超时
+	e.Process(Entry{Message: "error again", Time: start})
+	if m := e.Flush(start.Add(time.Second), false); len(m) != 0 {
+		t.Errorf("flushed before the timeout: %+v", m)
+	}
+	m := e.Flush(start.Add(DefaultContextTimeout), false)
+	if len(m) != 1 || !slices.Equal(m[0].Before, []string{"d", "e"}) || len(m[0].After) != 0 {
+		t.Errorf("flushed = %+v", m)
+	}
+}
+
+// contextMatch 让 lines 依次经过只匹配 "error" 的引擎，返回唯一的命中
+func contextMatch(t *testing.T, cfg ContextConfig, lines ...string) Match {
+	t.Helper()
+	e, err := NewEngine(EngineConfig{Rules: []Config{{Pattern: "error"}}, Context: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var matches []Match
+	for _, line := range lines {
+		matches = append(matches, e.Process(Entry{Message: line, Time: start})...)
+	}
+	matches = append(matches, e.Flush(start, true)...)
+	if len(matches) != 1 {
+		t.Fatalf("matches = %+v", matches)
+	}
+	return matches[0]
+}
+
+func TestEngineContextMaxBytes(t *testing.T) {
+	// 命中行 5 字节，其他每行连同换行 3 字节，共 23 字节
+	m := contextMatch(t, ContextConfig{Before: 3, After: 3, MaxBytes: 14}, "b1", "b2", "b3", "error", "a1", "a2", "a3")
+	// 交替丢弃最早的前文和最晚的后文
+	if !slices.Equal(m.Lines(), []string{"b3", "error", "a1", "a2"}) || m.Omitted != 3 {
+		t.Errorf("Lines = %q, Omitted = %d, want 3 lines omitted", m.Lines(), m.Omitted)
+	}
+
+	// 不超过上限时保留全部上下文
+	m = contextMatch(t, ContextConfig{Before: 3, After: 3, MaxBytes: 23}, "b1", "b2", "b3", "error", "a1", "a2", "a3")
+	if len(m.Lines()) != 7 || m.Omitted != 0 {
+		t.Errorf("Lines = %q, Omitted = %d, want everything kept", m.Lines(), m.Omitted)
+	}
+}
+
+func TestEngineContextLongMatch(t *testing.T) {
+	m := contextMatch(t, ContextConfig{Before: 1, MaxBytes: 10}, "before", "error 磁盘磁盘磁盘")
+	// 命中行本身超长时丢弃全部上下文并截断，不切开 UTF-8 字符
+	if m.Entry.Message != "error …" || len(m.Before) != 0 || m.Omitted != 1 {
+		t.Errorf("Message = %q, Before = %q, Omitted = %d", m.Entry.Message, m.Before, m.Omitted)
+	}
+	if len(m.Entry.Message) > 10 || !utf8.ValidString(m.Entry.Message) {
+		t.Errorf("Message = %q is not a rune-safe cut within 10 bytes", m.Entry.Message)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"hello world", 8, "hello…"},
+		{"磁盘空间", 8, "磁…"},
+		{"磁盘空间", 6, "磁…"},
+		{"磁盘空间", 5, "…"},
+		{"hello", 2, "he"},
+		{"磁盘", 2, ""},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFormatContext(t *testing.T) {
+	omitted := i18n.T("rules.context_omitted", 2)
+	tests := []struct {
+		name  string
+		match Match
+		want  string
+	}{
+		{"no context", Match{Entry: Entry{Message: "error"}}, ""},
+		{"before and after", Match{Entry: Entry{Message: "error"}, Before: []string{"a", "b"}, After: []string{"c"}}, "| a\n| b\n> error\n| c"},
+		{"omitted", Match{Entry: Entry{Message: "error"}, After: []string{"c"}, Omitted: 2}, "> error\n| c\n" + omitted},
+		{"everything omitted", Match{Entry: Entry{Message: "error"}, Omitted: 2}, "> error\n" + omitted},
+	}
+	for _, tt := range tests {
+		if got := tt.match.FormatContext(); got != tt.want {
+			t.Errorf("%s: FormatContext() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if !strings.Contains(omitted, "2") {
+		t.Errorf("rules.context_omitted = %q does not mention the count", omitted)
+	}
+}
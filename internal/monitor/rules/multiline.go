@@ -0,0 +1,112 @@
+package rules
+
+import (
+	"hashcowuwu/lychee/internal/i18n"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 多行合并的默认值
+const (
+	DefaultMultilineTimeout  = 2 * time.Second
+	DefaultMultilineMaxLines = 500
+)
+
+// MultilineConfig 描述如何把多条日志合并成一条记录，例如 Java 堆栈或 Go panic。
+// start 和 continuation 至少设置一个：只设置 start 时，不匹配 start 的行都视为续行。
+type MultilineConfig struct {
+	Start        string        `yaml:"start"`        // 记录首行的正则表达式
+	Continuation string        `yaml:"continuation"` // 续行的正则表达式
+	Timeout      time.Duration `yaml:"timeout"`      // 最后一行之后等待续行的时间，默认 2s
+	MaxLines     int           `yaml:"maxLines"`     // 单条记录的最大行数，默认 500
+}
+
+// Enabled 判断是否配置了多行合并
+func (c MultilineConfig) Enabled() bool {
+	return c.Start != "" || c.Continuation != ""
+}
+
+// Assembler 按 MultilineConfig 合并日志，不是并发安全的
+type Assembler struct {
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+	timeout      time.Duration
+	maxLines     int
+
+	pending *Entry
+	lines   int
+	last    time.Time // 最后一行日志的时间
+}
+
+// NewAssembler 编译多行合并配置
+func NewAssembler(cfg MultilineConfig) (*Assembler, error) {
+	a := &Assembler{timeout: cfg.Timeout, maxLines: cfg.MaxLines}
+	if a.timeout <= 0 {
+		a.timeout = DefaultMultilineTimeout
+	}
+	if a.maxLines <= 0 {
+		a.maxLines = DefaultMultilineMaxLines
+	}
+	var err error
+	if cfg.Start != "" {
+		if a.start, err = regexp.Compile(cfg.Start); err != nil {
+			return nil, i18n.Errorf("rules.multiline_invalid", cfg.Start, err)
+		}
+	}
+	if cfg.Continuation != "" {
+		if a.continuation, err = regexp.Compile(cfg.Continuation); err != nil {
+			return nil, i18n.Errorf("rules.multiline_invalid", cfg.Continuation, err)
+		}
+	}
+	return a, nil
+}
+
+// Timeout 返回等待续行的时间，调用方应至少以该频率调用 Flush
+func (a *Assembler) Timeout() time.Duration {
+	return a.timeout
+}
+
+// Add 加入一条日志，返回因此完成的记录。超时按日志自身的时间计算。
+func (a *Assembler) Add(e Entry) []Entry {
+	if a.pending != nil && a.isContinuation(e.Message) {
+		a.pending.Message += "\n" + e.Message
+		a.lines++
+		a.last = e.Time
+		if a.lines >= a.maxLines {
+			return a.take()
+		}
+		return nil
+	}
+	done := a.take()
+	a.pending = &Entry{Message: e.Message, Fields: e.Fields, Time: e.Time}
+	a.lines = 1
+	a.last = e.Time
+	return done
+}
+
+// Flush 在等待续行超时 (或 force 为 true) 时返回尚未完成的记录
+func (a *Assembler) Flush(now time.Time, force bool) []Entry {
+	if a.pending == nil || (!force && now.Sub(a.last) < a.timeout) {
+		return nil
+	}
+	return a.take()
+}
+
+func (a *Assembler) isContinuation(msg string) bool {
+	if a.continuation != nil {
+		return a.continuation.MatchString(msg)
+	}
+	return !a.start.MatchString(msg)
+}
+
+func (a *Assembler) take() []Entry {
+	if a.pending == nil {
+		return nil
+	}
+	e := *a.pending
+	e.Message = strings.TrimRight(e.Message, "\n")
+	a.pending = nil
+	a.lines = 0
+	return []Entry{e}
+}
@@ -0,0 +1,91 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAssembler(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   MultilineConfig
+		lines []string
+		want  []string // 全部加入并强制 Flush 后得到的记录
+	}{
+		{
+			name:  "start pattern",
+			cfg:   MultilineConfig{Start: `^\d{4}-`},
+			lines: []string{"2026-01-01 ERROR boom", "java.lang.NullPointerException", "\tat Foo.bar(Foo.java:1)", "2026-01-01 INFO ok"},
+			want:  []string{"2026-01-01 ERROR boom\njava.lang.NullPointerException\n\tat Foo.bar(Foo.java:1)", "2026-01-01 INFO ok"},
+		},
+		{
+			name:  "continuation pattern",
+			cfg:   MultilineConfig{Continuation: `^\s`},
+			lines: []string{"panic: boom", "\tgoroutine 1", "\tmain.go:1", "exit status 2"},
+			want:  []string{"panic: boom\n\tgoroutine 1\n\tmain.go:1", "exit status 2"},
+		},
+		{
+			name:  "leading continuation starts a record",
+			cfg:   MultilineConfig{Continuation: `^\s`},
+			lines: []string{"\torphan", "\tline", "next"},
+			want:  []string{"\torphan\n\tline", "next"},
+		},
+		{
+			name:  "max lines",
+			cfg:   MultilineConfig{Start: `^\S`, MaxLines: 2},
+			lines: []string{"head", " a", " b", " c"},
+			want:  []string{"head\n a", " b\n c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAssembler(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, line := range tt.lines {
+				for _, e := range a.Add(Entry{Message: line}) {
+					got = append(got, e.Message)
+				}
+			}
+			for _, e := range a.Flush(time.Time{}, true) {
+				got = append(got, e.Message)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("records = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssemblerTimeout(t *testing.T) {
+	a, err := NewAssembler(MultilineConfig{Start: `^\S`, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fields := map[string]string{"PRIORITY": "3"}
+	a.Add(Entry{Message: "panic: boom", Fields: fields, Time: start})
+	a.Add(Entry{Message: "\tgoroutine 1", Time: start.Add(800 * time.Millisecond)})
+	// 超时从最后一行开始计算
+	if got := a.Flush(start.Add(time.Second), false); len(got) != 0 {
+		t.Fatalf("flushed before the timeout: %+v", got)
+	}
+	got := a.Flush(start.Add(1800*time.Millisecond), false)
+	if len(got) != 1 || got[0].Message != "panic: boom\n\tgoroutine 1" || !got[0].Time.Equal(start) || got[0].Fields["PRIORITY"] != "3" {
+		t.Errorf("flushed = %+v, want the record with the first line's time and fields", got)
+	}
+	if got := a.Flush(start.Add(time.Hour), true); len(got) != 0 {
+		t.Errorf("flushed twice: %+v", got)
+	}
+}
+
+func TestAssemblerInvalid(t *testing.T) {
+	for _, cfg := range []MultilineConfig{{Start: "("}, {Continuation: "["}} {
+		if _, err := NewAssembler(cfg); err == nil {
+			t.Errorf("NewAssembler(%+v) succeeded", cfg)
+		}
+	}
+}
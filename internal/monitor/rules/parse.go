@@ -0,0 +1,108 @@
+package rules
+
+import (
+	"encoding/json"
+	"hashcowuwu/lychee/internal/i18n"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 支持的消息解析格式
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// validFormat 检查解析格式是否受支持，空字符串表示不解析
+func validFormat(format string) error {
+	switch format {
+	case "", FormatJSON, FormatLogfmt:
+		return nil
+	}
+	return i18n.Errorf("rules.parse_unknown", format)
+}
+
+// ParseMessage 按 format 解析日志正文，返回其中的字段。无法解析时返回 nil。
+func ParseMessage(format, msg string) map[string]string {
+	switch format {
+	case FormatJSON:
+		return parseJSON(msg)
+	case FormatLogfmt:
+		return parseLogfmt(msg)
+	}
+	return nil
+}
+
+// parseJSON 解析 JSON 对象的顶层字段，非字符串的值保留其 JSON 文本
+func parseJSON(msg string) map[string]string {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(strings.TrimSpace(msg)), &raw); err != nil {
+		return nil
+	}
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			fields[k] = s
+			continue
+		}
+		fields[k] = string(v)
+	}
+	return fields
+}
+
+// parseLogfmt 解析 key=value 形式的日志，值可以用双引号包裹并包含转义字符。
+// 没有 = 的单词不作为字段，否则任何自由文本都会被当作 logfmt；一个 key=value 都没有时返回 nil
+func parseLogfmt(msg string) map[string]string {
+	fields := make(map[string]string)
+	s := strings.TrimLeftFunc(msg, unicode.IsSpace)
+	for len(s) > 0 {
+		// 读取 key，跳过没有值的单词
+		i := strings.IndexFunc(s, func(r rune) bool { return r == '=' || unicode.IsSpace(r) })
+		if i < 0 {
+			break
+		}
+		key := s[:i]
+		if s[i] != '=' {
+			s = strings.TrimLeftFunc(s[i:], unicode.IsSpace)
+			continue
+		}
+		s = s[i+1:]
+
+		// 读取 value
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil
+			}
+			var err error
+			if value, err = strconv.Unquote(s[:end+1]); err != nil {
+				value = s[1:end]
+			}
+			s = s[end+1:]
+		} else {
+			j := strings.IndexFunc(s, unicode.IsSpace)
+			if j < 0 {
+				j = len(s)
+			}
+			value = s[:j]
+			s = s[j:]
+		}
+		if key != "" {
+			fields[key] = value
+		}
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
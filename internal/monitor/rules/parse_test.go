@@ -0,0 +1,39 @@
+package rules
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		msg    string
+		want   map[string]string // nil 表示无法解析
+	}{
+		{"json", FormatJSON, `{"level":"error","msg":"db down"}`, map[string]string{"level": "error", "msg": "db down"}},
+		{"json non-string values", FormatJSON, ` {"code":503,"ok":false,"tags":["a"],"err":null} `, map[string]string{"code": "503", "ok": "false", "tags": `["a"]`, "err": ""}},
+		{"json array", FormatJSON, `[1, 2]`, nil},
+		{"json text", FormatJSON, `db down`, nil},
+		{"logfmt", FormatLogfmt, `level=error msg="db down" retries=3`, map[string]string{"level": "error", "msg": "db down", "retries": "3"}},
+		{"logfmt escapes", FormatLogfmt, `msg="say \"hi\"\tnow" path=/tmp`, map[string]string{"msg": "say \"hi\"\tnow", "path": "/tmp"}},
+		{"logfmt empty value", FormatLogfmt, `level= msg=x`, map[string]string{"level": "", "msg": "x"}},
+		{"logfmt unterminated quote", FormatLogfmt, `level=error msg="db down`, nil},
+		{"logfmt empty", FormatLogfmt, `   `, nil},
+		// 没有值的单词不作为字段，自由文本不会被当作 logfmt
+		{"logfmt bare words ignored", FormatLogfmt, `request failed status=500 retrying`, map[string]string{"status": "500"}},
+		{"free text", FormatLogfmt, `Connection closed by authenticating user root`, nil},
+		{"free text trailing word", FormatLogfmt, `disk full`, nil},
+		{"empty key", FormatLogfmt, `=x and more`, nil},
+		{"no format", "", `level=error`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseMessage(tt.format, tt.msg)
+			if (got == nil) != (tt.want == nil) || !maps.Equal(got, tt.want) {
+				t.Errorf("ParseMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+// Package rules 实现日志监控共用的关键字规则引擎。
+//
+// 规则在创建监控器时一次性编译，运行时只做匹配；无效的正则表达式会在
+// 加载配置时作为错误返回，而不是在每一行日志上重复报警告。
+package rules
+
+import (
+	"hashcowuwu/lychee/internal/i18n"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config 描述一条日志匹配规则
+type Config struct {
+	Name          string   `yaml:"name"`          // 规则名称，出现在告警中，默认使用 pattern
+	Pattern       string   `yaml:"pattern"`       // 要匹配的关键字或正则表达式，只按字段过滤时可以留空
+	Regex         bool     `yaml:"regex"`         // 为 true 时 pattern 按正则表达式匹配，否则按字面子串匹配
+	CaseSensitive bool     `yaml:"caseSensitive"` // 是否区分大小写，默认不区分
+	Exclude       []string `yaml:"exclude"`       // 排除模式，命中任一项的行不算匹配；与 pattern 使用相同的匹配方式
+
+	// 字段过滤：只有满足全部条件的日志才参与匹配
+	Priority string            `yaml:"priority"` // 只匹配该优先级及更严重的日志，例如 err 或 3
+	Fields   map[string]string `yaml:"fields"`   // 字段需要等于给定值，例如 SYSLOG_IDENTIFIER: sshd 或解析出的 level: error；字段名不区分大小写
+
+	// 阈值规则：在滑动窗口内命中次数达到 Threshold 时才告警。越过阈值时告警一次，
+	// 之后计数一直不低于阈值时每过一个 Window 再告警一次
+	Threshold    int           `yaml:"threshold"`    // 0 表示每次命中都告警
+	Window       time.Duration `yaml:"window"`       // 滑动窗口长度，设置 threshold 时必填
+	GroupBy      string        `yaml:"groupBy"`      // 按正则捕获组 (名称或序号) 分别计数，例如来源 IP
+	RateIncrease float64       `yaml:"rateIncrease"` // 当前窗口次数还需达到上一窗口的多少倍，用于检测突增
+	Ban          bool          `yaml:"ban"`          // 越过阈值时封禁 groupBy 捕获的地址，需要启用全局的 ban 配置
+}
+
+// matcher 判断一行文本是否命中某个模式
+type matcher interface {
+	MatchString(s string) bool
+}
+
+// always 匹配任意文本，用于只按字段过滤的规则
+type always struct{}
+
+func (always) MatchString(string) bool { return true }
+
+// literal 是字面子串匹配
+type literal struct {
+	needle string
+	fold   bool
+}
+
+func (l literal) MatchString(s string) bool {
+	if l.fold {
+		s = strings.ToLower(s)
+	}
+	return strings.Contains(s, l.needle)
+}
+
+// Entry 是参与规则匹配的一条日志
+type Entry struct {
+	Message string            // 日志正文
+	Fields  map[string]string // 结构化字段，例如 journal 的 PRIORITY、_COMM 或从正文解析出的 level
+	Time    time.Time         // 日志产生的时间，用于阈值规则的滑动窗口
+}
+
+// priorities 是 syslog 优先级名称到数值的映射，数值越小越严重
+var priorities = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3, "error": 3,
+	"warning": 4, "warn": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// ParsePriority 解析 syslog 优先级名称或 0-7 的数值
+func ParsePriority(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if p, ok := priorities[s]; ok {
+		return p, nil
+	}
+	if p, err := strconv.Atoi(s); err == nil && p >= 0 && p <= 7 {
+		return p, nil
+	}
+	return 0, i18n.Errorf("rules.priority_invalid", s)
+}
+
+// Rule 是一条编译后的规则。阈值规则带有计数状态，因此一个 Rule 只应属于一个监控器。
+type Rule struct {
+	name    string
+	include matcher
+	exclude []matcher
+
+	priority int // 最低优先级 (数值上限)，-1 表示不过滤
+	fields   map[string]string
+
+	threshold    int
+	window       time.Duration
+	rateIncrease float64
+	group        int  // 分组捕获组的序号，-1 表示不分组
+	ban          bool // 命中时封禁分组的值
+
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+// counter 记录某个分组在最近两个窗口内的命中时间
+type counter struct {
+	hits    []time.Time // 按时间排序
+	firedAt time.Time   // 上次告警的时间，计数回落到阈值以下时清零
+}
+
+// Hit 描述一次应当告警的命中
+type Hit struct {
+	Rule     string // 规则名称
+	Key      string // 分组捕获组的值，不分组时为空
+	Count    int    // 当前窗口内的命中次数，非阈值规则为 1
+	Previous int    // 上一窗口内的命中次数，仅在设置 rateIncrease 时有意义
+	Window   time.Duration
+	Ban      bool // 是否应封禁 Key 对应的地址
+}
+
+// Threshold 判断命中是否来自阈值规则
+func (h Hit) Threshold() bool {
+	return h.Window > 0
+}
+
+// Compile 编译一条规则
+func Compile(cfg Config) (*Rule, error) {
+	if cfg.Pattern == "" && cfg.Priority == "" && len(cfg.Fields) == 0 {
+		return nil, i18n.Errorf("rules.pattern_empty", cfg.Name)
+	}
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Pattern
+	}
+	if name == "" {
+		name = describeFilter(cfg)
+	}
+
+	if cfg.Threshold < 0 || cfg.RateIncrease < 0 {
+		return nil, i18n.Errorf("rules.threshold_negative", name)
+	}
+	if cfg.Threshold > 0 && cfg.Window <= 0 {
+		return nil, i18n.Errorf("rules.window_missing", name)
+	}
+	if (cfg.GroupBy != "" || cfg.RateIncrease > 0) && cfg.Threshold == 0 {
+		return nil, i18n.Errorf("rules.threshold_missing", name)
+	}
+	if cfg.Ban && cfg.GroupBy == "" {
+		return nil, i18n.Errorf("rules.ban_needs_group", name)
+	}
+
+	r := &Rule{
+		name:         name,
+		threshold:    cfg.Threshold,
+		window:       cfg.Window,
+		rateIncrease: cfg.RateIncrease,
+		group:        -1,
+		ban:          cfg.Ban,
+		priority:     -1,
+		counters:     make(map[string]*counter),
+	}
+	if cfg.Priority != "" {
+		p, err := ParsePriority(cfg.Priority)
+		if err != nil {
+			return nil, i18n.Errorf("rules.invalid", name, err)
+		}
+		r.priority = p
+	}
+	r.fields = cfg.Fields
+	var err error
+	if cfg.Pattern == "" {
+		r.include = always{}
+	} else if r.include, err = compilePattern(cfg, cfg.Pattern); err != nil {
+		return nil, i18n.Errorf("rules.regex_invalid", name, cfg.Pattern, err)
+	}
+	for _, p := range cfg.Exclude {
+		m, err := compilePattern(cfg, p)
+		if err != nil {
+			return nil, i18n.Errorf("rules.regex_invalid", name, p, err)
+		}
+		r.exclude = append(r.exclude, m)
+	}
+	if cfg.GroupBy != "" {
+		re, ok := r.include.(*regexp.Regexp)
+		if !ok {
+			return nil, i18n.Errorf("rules.group_needs_regex", name)
+		}
+		if r.group = captureIndex(re, cfg.GroupBy); r.group < 0 {
+			return nil, i18n.Errorf("rules.group_unknown", name, cfg.GroupBy)
+		}
+	}
+	return r, nil
+}
+
+// describeFilter 为只有字段过滤的无名规则生成名称，例如 "priority=err,level=error"
+func describeFilter(cfg Config) string {
+	var parts []string
+	if cfg.Priority != "" {
+		parts = append(parts, "priority="+cfg.Priority)
+	}
+	keys := make([]string, 0, len(cfg.Fields))
+	for k := range cfg.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, k+"="+cfg.Fields[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+// captureIndex 按名称或序号查找捕获组，找不到时返回 -1
+func captureIndex(re *regexp.Regexp, group string) int {
+	if i := re.SubexpIndex(group); i > 0 {
+		return i
+	}
+	if i, err := strconv.Atoi(group); err == nil && i > 0 && i <= re.NumSubexp() {
+		return i
+	}
+	return -1
+}
+
+// CompileAll 按顺序编译一组规则，遇到第一个错误即返回
+func CompileAll(cfgs []Config) ([]*Rule, error) {
+	compiled := make([]*Rule, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		r, err := Compile(cfg)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+func compilePattern(cfg Config, pattern string) (matcher, error) {
+	if !cfg.Regex {
+		if cfg.CaseSensitive {
+			return literal{needle: pattern}, nil
+		}
+		return literal{needle: strings.ToLower(pattern), fold: true}, nil
+	}
+	if !cfg.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// Name 返回规则名称
+func (r *Rule) Name() string {
+	return r.name
+}
+
+// Match 判断一行日志是否命中规则且未被排除
+func (r *Rule) Match(line string) bool {
+	if !r.include.MatchString(line) {
+		return false
+	}
+	for _, ex := range r.exclude {
+		if ex.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// accept 判断日志是否满足规则的字段过滤条件
+func (r *Rule) accept(e Entry) bool {
+	if r.priority >= 0 {
+		p, err := strconv.Atoi(e.Fields["PRIORITY"])
+		if err != nil || p > r.priority {
+			return false
+		}
+	}
+	for k, v := range r.fields {
+		if got, ok := lookupField(e.Fields, k); !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupField 不区分大小写地查找字段。
+// viper 会把配置中 map 的 key 转为小写，而 journal 字段名总是大写，解析出的字段则保持原样。
+func lookupField(fields map[string]string, key string) (string, bool) {
+	if v, ok := fields[key]; ok {
+		return v, true
+	}
+	if v, ok := fields[strings.ToUpper(key)]; ok {
+		return v, true
+	}
+	for k, v := range fields {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Observe 记录一条日志，返回需要告警的命中。
+// 非阈值规则每次匹配都返回命中；阈值规则在计数越过阈值时返回命中，
+// 持续超过阈值时每个窗口再返回一次。
+func (r *Rule) Observe(e Entry) (Hit, bool) {
+	if !r.accept(e) || !r.Match(e.Message) {
+		return Hit{}, false
+	}
+	line, at := e.Message, e.Time
+	if r.threshold == 0 {
+		return Hit{Rule: r.name, Count: 1}, true
+	}
+
+	key := ""
+	if r.group > 0 {
+		if m := r.include.(*regexp.Regexp).FindStringSubmatch(line); m != nil {
+			key = m[r.group]
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(at)
+	c, ok := r.counters[key]
+	if !ok {
+		c = &counter{}
+		r.counters[key] = c
+	}
+	// 多个来源补读时日志不一定按时间到达，计数以最新一次命中为准
+	c.insert(at)
+	now := c.hits[len(c.hits)-1]
+	c.prune(now.Add(-2 * r.window))
+
+	count, previous := c.split(now.Add(-r.window))
+	reached := count >= r.threshold
+	if reached && r.rateIncrease > 0 {
+		reached = float64(count) >= r.rateIncrease*float64(previous)
+	}
+	if !reached {
+		c.firedAt = time.Time{}
+		return Hit{}, false
+	}
+	if !c.firedAt.IsZero() && now.Sub(c.firedAt) < r.window {
+		return Hit{}, false
+	}
+	c.firedAt = now
+	return Hit{Rule: r.name, Key: key, Count: count, Previous: previous, Window: r.window, Ban: r.ban && key != ""}, true
+}
+
+// sweep 定期清理长时间没有命中的分组，避免按来源 IP 等分组时状态无限增长
+func (r *Rule) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < r.window {
+		return
+	}
+	r.lastSweep = now
+	cutoff := now.Add(-2 * r.window)
+	for key, c := range r.counters {
+		c.prune(cutoff)
+		if len(c.hits) == 0 {
+			delete(r.counters, key)
+		}
+	}
+}
+
+// insert 按时间顺序插入一次命中
+func (c *counter) insert(at time.Time) {
+	i := sort.Search(len(c.hits), func(i int) bool { return c.hits[i].After(at) })
+	c.hits = slices.Insert(c.hits, i, at)
+}
+
+// prune 丢弃 cutoff 之前的命中
+func (c *counter) prune(cutoff time.Time) {
+	i := 0
+	for i < len(c.hits) && !c.hits[i].After(cutoff) {
+		i++
+	}
+	c.hits = c.hits[i:]
+}
+
+// split 返回 boundary 之后 (当前窗口) 和之前 (上一窗口) 的命中次数
+func (c *counter) split(boundary time.Time) (current, previous int) {
+	for _, t := range c.hits {
+		if t.After(boundary) {
+			current++
+		} else {
+			previous++
+		}
+	}
+	return current, previous
+}
+
+// FromKeywords 将旧的 keywords 列表转换为规则。
+// 为了兼容已有配置，关键字仍按不区分大小写的正则表达式处理。
+func FromKeywords(keywords []string) []Config {
+	cfgs := make([]Config, 0, len(keywords))
+	for _, kw := range keywords {
+		cfgs = append(cfgs, Config{Name: kw, Pattern: kw, Regex: true})
+	}
+	return cfgs
+}
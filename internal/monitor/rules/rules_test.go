@@ -0,0 +1,364 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		line string
+		want bool
+	}{
+		{"literal ignores case", Config{Pattern: "Error"}, "disk ERROR on sda", true},
+		{"literal is not a regex", Config{Pattern: "a.c"}, "abc", false},
+		{"literal with brackets", Config{Pattern: "[crit]"}, "2026/01/01 [crit] 12#0: worker exited", true},
+		{"literal needs the brackets", Config{Pattern: "[crit]"}, "critical section", false},
+		{"case sensitive", Config{Pattern: "Error", CaseSensitive: true}, "disk ERROR on sda", false},
+		{"regex", Config{Pattern: `oom-kill(er)?`, Regex: true}, "kernel: OOM-killer invoked", true},
+		{"regex case sensitive", Config{Pattern: `^panic:`, Regex: true, CaseSensitive: true}, "PANIC: nil map", false},
+		{"excluded", Config{Pattern: "error", Exclude: []string{"0 errors"}}, "sync finished, 0 errors", false},
+		{"not excluded", Config{Pattern: "error", Exclude: []string{"0 errors"}}, "sync failed: error 5", true},
+		{"regex exclude", Config{Pattern: "fail", Regex: true, Exclude: []string{`^debug:`}}, "DEBUG: fail fast", false},
+		{"literal exclude is not a regex", Config{Pattern: "error", Exclude: []string{"e.r"}}, "error on ear", true},
+		{"case sensitive exclude", Config{Pattern: "error", CaseSensitive: true, Exclude: []string{"Test"}}, "test error", true},
+		{"any exclude", Config{Pattern: "error", Exclude: []string{"retrying", "0 errors"}}, "error, retrying", false},
+		{"regex no match", Config{Pattern: `^\d+ errors$`, Regex: true}, "3 errors found", false},
+		{"fields only", Config{Priority: "err"}, "anything", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Compile(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.Match(tt.line); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleName(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{Name: "oom", Pattern: "Out of memory"}, "oom"},
+		{Config{Pattern: "Out of memory"}, "Out of memory"},
+	}
+	for _, tt := range tests {
+		r, err := Compile(tt.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Name(); got != tt.want {
+			t.Errorf("Name() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCompileAll(t *testing.T) {
+	rules, err := CompileAll([]Config{{Pattern: "a"}, {Pattern: "b", Regex: true}})
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("CompileAll = %d rules, %v", len(rules), err)
+	}
+	// 规则在加载配置时编译，无效的正则表达式作为错误返回
+	if _, err := CompileAll([]Config{{Pattern: "a"}, {Name: "broken", Pattern: "(", Regex: true}}); err == nil {
+		t.Error("invalid rule accepted")
+	}
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		fields map[string]string
+		want   bool
+	}{
+		{"priority matched", Config{Priority: "err"}, map[string]string{"PRIORITY": "2"}, true},
+		{"priority too low", Config{Priority: "err"}, map[string]string{"PRIORITY": "4"}, false},
+		{"priority missing", Config{Priority: "3"}, nil, false},
+		// viper 把配置中的 key 转为小写，journal 字段名是大写
+		{"field name ignores case", Config{Fields: map[string]string{"syslog_identifier": "sshd"}}, map[string]string{"SYSLOG_IDENTIFIER": "sshd"}, true},
+		{"field value differs", Config{Fields: map[string]string{"level": "error"}}, map[string]string{"level": "info"}, false},
+		{"pattern and field", Config{Pattern: "denied", Fields: map[string]string{"level": "error"}}, map[string]string{"Level": "error"}, true},
+		{"pattern does not match", Config{Pattern: "granted", Fields: map[string]string{"level": "error"}}, map[string]string{"level": "error"}, false},
+		{"all fields required", Config{Fields: map[string]string{"_comm": "sshd", "_uid": "0"}}, map[string]string{"_COMM": "sshd", "_UID": "1000"}, false},
+		{"all fields matched", Config{Fields: map[string]string{"_comm": "sshd", "_uid": "0"}}, map[string]string{"_COMM": "sshd", "_UID": "0"}, true},
+		{"priority and field", Config{Priority: "warning", Fields: map[string]string{"_comm": "kernel"}}, map[string]string{"PRIORITY": "4", "_COMM": "kernel"}, true},
+		{"priority not numeric", Config{Priority: "warning"}, map[string]string{"PRIORITY": "warning"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Compile(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, got := r.Observe(Entry{Message: "access denied", Fields: tt.fields}); got != tt.want {
+				t.Errorf("Observe = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"emerg", 0, true},
+		{"crit", 2, true},
+		{"err", 3, true},
+		{"error", 3, true},
+		{" Warning ", 4, true},
+		{"warn", 4, true},
+		{"debug", 7, true},
+		{"0", 0, true},
+		{"5", 5, true},
+		{"8", 0, false},
+		{"-1", 0, false},
+		{"loud", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriority(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParsePriority(%q) = %d, %v, want %d, ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFilterName(t *testing.T) {
+	r, err := Compile(Config{Priority: "err", Fields: map[string]string{"level": "error", "app": "api"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "priority=err,app=api,level=error"; r.Name() != want {
+		t.Errorf("Name() = %q, want %q", r.Name(), want)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"empty":               {},
+		"bad regex":           {Pattern: "(", Regex: true},
+		"bad exclude":         {Pattern: "x", Regex: true, Exclude: []string{"["}},
+		"bad priority":        {Priority: "loud"},
+		"threshold no window": {Pattern: "x", Threshold: 3},
+		"negative threshold":  {Pattern: "x", Threshold: -1},
+		"group no threshold":  {Pattern: `(\d+)`, Regex: true, GroupBy: "1"},
+		"group not regex":     {Pattern: "x", Threshold: 3, Window: time.Minute, GroupBy: "1"},
+		"unknown group":       {Pattern: `(?P<ip>\S+)`, Regex: true, Threshold: 3, Window: time.Minute, GroupBy: "host"},
+		"ban without group":   {Pattern: "x", Threshold: 3, Window: time.Minute, Ban: true},
+		"rate no threshold":   {Pattern: "x", RateIncrease: 2},
+		"negative rate":       {Pattern: "x", Threshold: 3, Window: time.Minute, RateIncrease: -1},
+	} {
+		if _, err := Compile(cfg); err == nil {
+			t.Errorf("%s: Compile succeeded", name)
+		}
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	r, err := Compile(Config{
+		Name:      "ssh",
+		Pattern:   `Failed password for .* from (?P<ip>\S+)`,
+		Regex:     true,
+		Threshold: 3,
+		Window:    time.Minute,
+		GroupBy:   "ip",
+		Ban:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	observe := func(ip string, offset time.Duration) (Hit, bool) {
+		return r.Observe(Entry{Message: "Failed password for root from " + ip + " port 22", Time: start.Add(offset)})
+	}
+
+	for i, offset := range []time.Duration{0, 10 * time.Second} {
+		if _, ok := observe("203.0.113.5", offset); ok {
+			t.Fatalf("hit %d reported before reaching the threshold", i+1)
+		}
+	}
+	// 其他来源单独计数
+	if _, ok := observe("198.51.100.7", 15*time.Second); ok {
+		t.Fatal("another group reached the threshold")
+	}
+	hit, ok := observe("203.0.113.5", 20*time.Second)
+	if !ok {
+		t.Fatal("threshold not reported")
+	}
+	if hit.Rule != "ssh" || hit.Key != "203.0.113.5" || hit.Count != 3 || !hit.Ban || !hit.Threshold() {
+		t.Errorf("hit = %+v", hit)
+	}
+	// 越过阈值后一个窗口内只告警一次
+	if _, ok := observe("203.0.113.5", 30*time.Second); ok {
+		t.Error("threshold reported twice")
+	}
+	// 窗口滑过后回落到阈值以下，再次越过时重新告警
+	if _, ok := observe("203.0.113.5", 2*time.Minute); ok {
+		t.Error("hit reported below the threshold")
+	}
+	observe("203.0.113.5", 2*time.Minute+time.Second)
+	if _, ok := observe("203.0.113.5", 2*time.Minute+2*time.Second); !ok {
+		t.Error("threshold not reported again")
+	}
+}
+
+func TestThresholdWindow(t *testing.T) {
+	r, err := Compile(Config{Pattern: `segfault at (\w+)`, Regex: true, Threshold: 3, Window: time.Minute, GroupBy: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		offset time.Duration
+		line   string
+		want   bool
+		count  int
+	}{
+		{0, "segfault at a", false, 0},
+		{30 * time.Second, "segfault at a", false, 0},
+		{45 * time.Second, "unrelated", false, 0},
+		// 第一次命中已滑出窗口，窗口内只有 2 次
+		{70 * time.Second, "segfault at a", false, 0},
+		{80 * time.Second, "segfault at a", true, 3},
+		// 按序号引用的捕获组分别计数
+		{81 * time.Second, "segfault at b", false, 0},
+	}
+	for _, tt := range tests {
+		hit, ok := r.Observe(Entry{Message: tt.line, Time: start.Add(tt.offset)})
+		if ok != tt.want || hit.Count != tt.count {
+			t.Errorf("%v %q: got %v, count %d, want %v, count %d", tt.offset, tt.line, ok, hit.Count, tt.want, tt.count)
+		}
+	}
+	if hit, ok := r.Observe(Entry{Message: "segfault at a", Time: start.Add(85 * time.Second)}); ok {
+		t.Errorf("hit reported while firing: %+v", hit)
+	}
+}
+
+func TestThresholdRearm(t *testing.T) {
+	r, err := Compile(Config{Pattern: "ERROR", Threshold: 2, Window: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 每 10 秒一次，计数一直不低于阈值
+	var fired []time.Duration
+	for offset := time.Duration(0); offset <= 3*time.Minute; offset += 10 * time.Second {
+		if _, ok := r.Observe(Entry{Message: "ERROR", Time: start.Add(offset)}); ok {
+			fired = append(fired, offset)
+		}
+	}
+	want := []time.Duration{10 * time.Second, 70 * time.Second, 130 * time.Second}
+	if !slices.Equal(fired, want) {
+		t.Errorf("alerts at %v, want %v", fired, want)
+	}
+}
+
+func TestThresholdOutOfOrder(t *testing.T) {
+	r, err := Compile(Config{Pattern: "ERROR", Threshold: 3, Window: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 两个来源补读时交错到达，晚到的旧日志与窗口外的新命中不能算在同一窗口内
+	for i, offset := range []time.Duration{2 * time.Minute, 130 * time.Second, 10 * time.Second} {
+		if hit, ok := r.Observe(Entry{Message: "ERROR", Time: start.Add(offset)}); ok {
+			t.Fatalf("entry %d: threshold reported early: %+v", i, hit)
+		}
+	}
+	hit, ok := r.Observe(Entry{Message: "ERROR", Time: start.Add(100 * time.Second)})
+	if !ok || hit.Count != 3 {
+		t.Errorf("got %v, count %d, want the threshold with 3 hits", ok, hit.Count)
+	}
+}
+
+func TestThresholdSweep(t *testing.T) {
+	r, err := Compile(Config{Pattern: `from (\S+)`, Regex: true, Threshold: 5, Window: time.Minute, GroupBy: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 100 {
+		r.Observe(Entry{Message: fmt.Sprintf("from 10.0.0.%d", i), Time: start})
+	}
+	if got := len(r.counters); got != 100 {
+		t.Fatalf("%d counters, want 100", got)
+	}
+	// 两个窗口之后没有新命中的分组被清理
+	r.Observe(Entry{Message: "from 10.0.1.1", Time: start.Add(3 * time.Minute)})
+	if got := len(r.counters); got != 1 {
+		t.Errorf("%d counters after the sweep, want 1", got)
+	}
+}
+
+func TestRateIncrease(t *testing.T) {
+	r, err := Compile(Config{Pattern: "timeout", Threshold: 2, Window: time.Minute, RateIncrease: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(offsets ...time.Duration) (reported int) {
+		for _, o := range offsets {
+			if _, ok := r.Observe(Entry{Message: "timeout", Time: start.Add(o)}); ok {
+				reported++
+			}
+		}
+		return reported
+	}
+	// 没有上一窗口时只看阈值
+	if n := at(0, time.Second); n != 1 {
+		t.Errorf("first burst reported %d times, want 1", n)
+	}
+	// 上一窗口 2 次，当前窗口需要至少 4 次
+	if n := at(61*time.Second, 62*time.Second, 63*time.Second); n != 0 {
+		t.Errorf("reported %d times without a rate increase", n)
+	}
+	if n := at(64 * time.Second); n != 1 {
+		t.Errorf("reported %d times, want 1", n)
+	}
+	if hit, ok := r.Observe(Entry{Message: "timeout", Time: start.Add(200 * time.Second)}); ok {
+		t.Errorf("isolated hit reported: %+v", hit)
+	}
+}
+
+func TestRateIncreaseHit(t *testing.T) {
+	r, err := Compile(Config{Name: "timeouts", Pattern: "timeout", Threshold: 2, Window: time.Minute, RateIncrease: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var hits []Hit
+	for _, o := range []time.Duration{0, 10, 70, 71, 72, 73, 74, 75} {
+		if hit, ok := r.Observe(Entry{Message: "timeout", Time: start.Add(o * time.Second)}); ok {
+			hits = append(hits, hit)
+		}
+	}
+	// 第一个窗口越过阈值；上一窗口 2 次，当前窗口第 6 次时达到 3 倍
+	want := []Hit{
+		{Rule: "timeouts", Count: 2, Previous: 0, Window: time.Minute},
+		{Rule: "timeouts", Count: 6, Previous: 2, Window: time.Minute},
+	}
+	if !slices.Equal(hits, want) {
+		t.Errorf("hits = %+v, want %+v", hits, want)
+	}
+}
+
+func TestFromKeywords(t *testing.T) {
+	cfgs := FromKeywords([]string{"error", "fail(ed)?"})
+	rules, err := CompileAll(cfgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].Name() != "error" || !rules[1].Match("Job FAILED") {
+		t.Errorf("keywords not compiled as case-insensitive regexes")
+	}
+}
@@ -0,0 +1,160 @@
+package script
+
+import (
+	"strconv"
+	"strings"
+)
+
+// output 是解析后的插件输出
+type output struct {
+	text     string      // 第一行 | 之前的部分
+	long     []string    // 其余的详细输出
+	perfdata []perfValue // 第一行和详细输出中 | 之后的性能数据
+}
+
+// perfValue 是一项性能数据: 'label'=value[UOM];[warn];[crit];[min];[max]
+type perfValue struct {
+	label string
+	value float64
+	uom   string
+	warn  *float64
+	crit  *float64
+	min   *float64
+	max   *float64
+}
+
+// parseOutput 按 Nagios 插件规范解析输出:
+//
+//	TEXT | PERFDATA
+//	LONG TEXT
+//	LONG TEXT | PERFDATA
+//	PERFDATA
+//
+// 详细输出中第一个 | 之后的所有内容都是性能数据
+func parseOutput(s string) output {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	var out output
+	first, perf, _ := strings.Cut(lines[0], "|")
+	out.text = strings.TrimSpace(first)
+	perfText := []string{perf}
+
+	inPerf := false
+	for _, line := range lines[1:] {
+		if inPerf {
+			perfText = append(perfText, line)
+			continue
+		}
+		text, perf, found := strings.Cut(line, "|")
+		if text = strings.TrimRight(text, " \t"); text != "" {
+			out.long = append(out.long, text)
+		}
+		if found {
+			inPerf = true
+			perfText = append(perfText, perf)
+		}
+	}
+	out.perfdata = parsePerfdata(strings.Join(perfText, " "))
+	return out
+}
+
+// parsePerfdata 解析以空格分隔的性能数据，无法解析的项和值为 U 的项被忽略
+func parsePerfdata(s string) []perfValue {
+	var values []perfValue
+	for _, item := range splitPerfdata(s) {
+		label, rest, ok := cutLabel(item)
+		if !ok {
+			continue
+		}
+		fields := strings.Split(rest, ";")
+		value, uom, ok := parseValue(fields[0])
+		if !ok {
+			continue
+		}
+		p := perfValue{label: label, value: value, uom: uom}
+		for i, dst := range []**float64{&p.warn, &p.crit, &p.min, &p.max} {
+			if i+1 < len(fields) {
+				*dst = parseThreshold(fields[i+1])
+			}
+		}
+		values = append(values, p)
+	}
+	return values
+}
+
+// splitPerfdata 按空白拆分性能数据，单引号中的空白不拆分
+func splitPerfdata(s string) []string {
+	var items []string
+	var sb strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			sb.WriteRune(r)
+		case (r == ' ' || r == '\t' || r == '\n') && !quoted:
+			if sb.Len() > 0 {
+				items = append(items, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() > 0 {
+		items = append(items, sb.String())
+	}
+	return items
+}
+
+// cutLabel 拆分 label=rest，带引号的 label 中连续两个单引号表示一个单引号
+func cutLabel(item string) (string, string, bool) {
+	if strings.HasPrefix(item, "'") {
+		end := strings.LastIndex(item, "'=")
+		if end <= 0 {
+			return "", "", false
+		}
+		return strings.ReplaceAll(item[1:end], "''", "'"), item[end+2:], true
+	}
+	label, rest, ok := strings.Cut(item, "=")
+	return label, rest, ok && label != ""
+}
+
+// parseValue 拆分数值和单位，例如 "12.5ms" 返回 12.5 和 "ms"
+func parseValue(s string) (float64, string, bool) {
+	end := len(s)
+	for end > 0 && !isNumberChar(s[end-1]) {
+		end--
+	}
+	v, err := strconv.ParseFloat(strings.Replace(s[:end], ",", ".", 1), 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return v, s[end:], true
+}
+
+func isNumberChar(c byte) bool {
+	return c >= '0' && c <= '9' || c == '.' || c == ','
+}
+
+// parseThreshold 解析 warn/crit/min/max 字段。阈值可能是 10:20、@10:20 这样的范围，
+// 这里只记录范围的上限 (没有上限时取下限)，无法解析时返回 nil
+func parseThreshold(s string) *float64 {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "@")
+	if s == "" {
+		return nil
+	}
+	if low, high, ok := strings.Cut(s, ":"); ok {
+		s = high
+		if s == "" {
+			s = low
+		}
+	}
+	if s == "~" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,49 @@
+package script
+
+import (
+	"os/user"
+	"strconv"
+)
+
+// credential 是运行脚本的用户
+type credential struct {
+	username string
+	home     string
+	uid, gid uint32
+	groups   []uint32
+}
+
+// lookupCredential 解析用户名或 uid 及其附加组
+func lookupCredential(name string) (*credential, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		var idErr error
+		if u, idErr = user.LookupId(name); idErr != nil {
+			return nil, err
+		}
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	c := &credential{username: u.Username, home: u.HomeDir, uid: uint32(uid), gid: uint32(gid)}
+	gids, err := u.GroupIds()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gids {
+		if id, err := strconv.ParseUint(g, 10, 32); err == nil {
+			c.groups = append(c.groups, uint32(id))
+		}
+	}
+	return c, nil
+}
+
+// env 返回以该用户运行时需要覆盖的环境变量
+func (c *credential) env() []string {
+	return []string{"USER=" + c.username, "LOGNAME=" + c.username, "HOME=" + c.home}
+}
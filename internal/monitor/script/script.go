@@ -0,0 +1,182 @@
+// Package script 运行 Nagios 风格的检查脚本: 退出码 0/1/2/3 对应 OK/WARNING/CRITICAL/UNKNOWN，
+// 输出的第一行作为消息，| 之后的性能数据写入指标。
+package script
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"hashcowuwu/lychee/internal/executor"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/metrics"
+	"hashcowuwu/lychee/internal/monitor"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout 是未配置 timeout 时脚本的最长运行时间
+const DefaultTimeout = 30 * time.Second
+
+// maxOutput 是读取的脚本输出的最大字节数
+const maxOutput = 64 * 1024
+
+// Nagios 插件的退出码
+const (
+	StatusOK       = 0
+	StatusWarning  = 1
+	StatusCritical = 2
+	StatusUnknown  = 3
+)
+
+// Config 描述一个检查脚本
+type Config struct {
+	Name    string        `yaml:"name"`    // 默认使用 command 的文件名
+	Command string        `yaml:"command"` // 可执行文件路径，不经过 shell
+	Args    []string      `yaml:"args"`
+	Env     []string      `yaml:"env"`     // 额外的环境变量，格式为 KEY=value
+	Dir     string        `yaml:"dir"`     // 工作目录
+	User    string        `yaml:"user"`    // 以该用户 (用户名或 uid) 运行，需要 lychee 以 root 运行
+	Timeout time.Duration `yaml:"timeout"` // 默认 30s，超时视为 UNKNOWN
+}
+
+// Validate 检查配置，不访问文件系统
+func (c Config) Validate() error {
+	if c.Command == "" {
+		return i18n.Errorf("exec.command_missing")
+	}
+	for _, e := range c.Env {
+		if k, _, ok := strings.Cut(e, "="); !ok || k == "" {
+			return i18n.Errorf("exec.env_invalid", e)
+		}
+	}
+	if c.Timeout < 0 {
+		return i18n.Errorf("exec.timeout_negative")
+	}
+	return nil
+}
+
+// ScriptMonitor 周期性运行检查脚本
+type ScriptMonitor struct {
+	cfg     Config
+	name    string
+	cred    *credential // 为 nil 时以当前用户运行
+	metrics *metrics.Registry
+	exec    executor.Executor
+}
+
+// New 创建检查脚本监控器，user 在此解析；reg 为 nil 时不记录性能数据
+func New(cfg Config, reg *metrics.Registry, exec executor.Executor) (*ScriptMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	m := &ScriptMonitor{cfg: cfg, name: cmp.Or(cfg.Name, filepath.Base(cfg.Command)), metrics: reg, exec: exec}
+	if cfg.User != "" {
+		cred, err := lookupCredential(cfg.User)
+		if err != nil {
+			return nil, i18n.Errorf("exec.user_unknown", cfg.User, err)
+		}
+		m.cred = cred
+	}
+	return m, nil
+}
+
+// Name 返回监控器名称
+func (m *ScriptMonitor) Name() string {
+	return fmt.Sprintf("exec(%s)", m.name)
+}
+
+// Check 运行脚本并根据退出码和输出生成结果
+func (m *ScriptMonitor) Check() monitor.Result {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.Timeout)
+	defer cancel()
+
+	cmd := executor.Command{Name: m.cfg.Command, Args: m.cfg.Args, Dir: m.cfg.Dir, Env: m.cfg.Env}
+	if m.cred != nil {
+		cmd.Env = append(slices.Clip(cmd.Env), m.cred.env()...)
+		cmd.Credential = &executor.Credential{UID: m.cred.uid, GID: m.cred.gid, Groups: m.cred.groups}
+	}
+	var stdout, stderr limitedBuffer
+	// 超时后执行器结束整个进程组，脚本启动的子进程也会被结束
+	err := m.exec.Run(ctx, cmd, &stdout, &stderr)
+	status := StatusOK
+	code, exited := executor.ExitCode(err)
+	switch {
+	case ctx.Err() != nil:
+		return m.unknown(i18n.T("exec.timeout", m.name, m.cfg.Timeout), ctx.Err())
+	case exited:
+		status = code
+	case err != nil:
+		return m.unknown(i18n.T("exec.run_failed", m.name, err), err)
+	}
+
+	output := stdout.String()
+	if strings.TrimSpace(output) == "" {
+		output = stderr.String()
+	}
+	out := parseOutput(output)
+	m.record(status, out.perfdata)
+
+	message := out.text
+	if message == "" {
+		message = i18n.T("exec.no_output", m.name, status)
+	}
+	result := monitor.Result{Success: status == StatusOK, Message: message, LogLines: out.long, Labels: m.labels()}
+	switch status {
+	case StatusOK:
+	case StatusWarning:
+		result.Severity = monitor.SeverityWarning
+	case StatusCritical:
+		result.Severity = monitor.SeverityCritical
+	default:
+		result.Severity = monitor.SeverityUnknown
+	}
+	return result
+}
+
+// unknown 返回脚本无法运行或超时时的结果
+func (m *ScriptMonitor) unknown(msg string, err error) monitor.Result {
+	m.record(StatusUnknown, nil)
+	return monitor.Result{Success: false, Message: msg, Err: err, Severity: monitor.SeverityUnknown, Labels: m.labels()}
+}
+
+// record 把退出码和性能数据写入指标，先删除该脚本上一轮的指标
+func (m *ScriptMonitor) record(status int, perfdata []perfValue) {
+	if m.metrics == nil {
+		return
+	}
+	m.metrics.DeleteMatching(map[string]string{"check": m.name})
+	m.metrics.Set("lychee_exec_status", "Exit status of the check script (0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN).",
+		map[string]string{"check": m.name}, float64(status))
+	for _, p := range perfdata {
+		labels := map[string]string{"check": m.name, "label": p.label, "uom": p.uom}
+		m.metrics.Set("lychee_exec_perfdata", "Performance data reported by the check script.", labels, p.value)
+		for suffix, v := range map[string]*float64{"warning": p.warn, "critical": p.crit, "min": p.min, "max": p.max} {
+			if v != nil {
+				m.metrics.Set("lychee_exec_perfdata_"+suffix, "The "+suffix+" value of the performance data.", labels, *v)
+			}
+		}
+	}
+}
+
+// labels 返回附加在结果上的标签
+func (m *ScriptMonitor) labels() map[string]string {
+	return map[string]string{"type": "exec", "service": m.name}
+}
+
+// limitedBuffer 只保留前 maxOutput 字节，多余的输出被丢弃，避免脚本输出过多占用内存
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,151 @@
+package script
+
+import (
+	"errors"
+	"hashcowuwu/lychee/internal/executor/executortest"
+	"hashcowuwu/lychee/internal/metrics"
+	"hashcowuwu/lychee/internal/monitor"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	const cmdline = "/usr/lib/nagios/check_disk -w 10%"
+	tests := []struct {
+		name     string
+		resp     executortest.Response
+		success  bool
+		severity monitor.Severity
+		message  string
+		status   string // lychee_exec_status 指标的值
+	}{
+		{"ok", executortest.Response{Stdout: "DISK OK | /=42%;90;95\n"}, true, "", "DISK OK", "0"},
+		{"warning", executortest.Response{Stdout: "DISK WARNING\n", ExitCode: 1}, false, monitor.SeverityWarning, "DISK WARNING", "1"},
+		{"critical", executortest.Response{Stdout: "DISK CRITICAL\n", ExitCode: 2}, false, monitor.SeverityCritical, "DISK CRITICAL", "2"},
+		{"unknown", executortest.Response{Stdout: "DISK UNKNOWN\n", ExitCode: 3}, false, monitor.SeverityUnknown, "DISK UNKNOWN", "3"},
+		{"out of range exit code", executortest.Response{Stdout: "segfault\n", ExitCode: 139}, false, monitor.SeverityUnknown, "segfault", "139"},
+		{"stderr when stdout is empty", executortest.Response{Stderr: "no such mount\n", ExitCode: 2}, false, monitor.SeverityCritical, "no such mount", "2"},
+		{"not runnable", executortest.Response{Err: errors.New("permission denied")}, false, monitor.SeverityUnknown, "", "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := metrics.NewRegistry()
+			fake := executortest.New().On(cmdline, tt.resp)
+			m, err := New(Config{Command: "/usr/lib/nagios/check_disk", Args: []string{"-w", "10%"}}, reg, fake)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := m.Check()
+			if r.Success != tt.success || r.Severity != tt.severity {
+				t.Errorf("Success, Severity = %v, %q, want %v, %q (%s)", r.Success, r.Severity, tt.success, tt.severity, r.Message)
+			}
+			if tt.message != "" && r.Message != tt.message {
+				t.Errorf("Message = %q, want %q", r.Message, tt.message)
+			}
+			var b strings.Builder
+			reg.Write(&b)
+			if want := `lychee_exec_status{check="check_disk"} ` + tt.status + "\n"; !strings.Contains(b.String(), want) {
+				t.Errorf("metrics do not contain %q:\n%s", want, b.String())
+			}
+		})
+	}
+}
+
+func TestCheckPerfdataMetrics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	fake := executortest.New().On("check_load",
+		executortest.Response{Stdout: "LOAD OK | load1=0.5;2;4;0 load5=U\n"},
+		executortest.Response{Stdout: "LOAD OK\n"},
+	)
+	m, err := New(Config{Name: "load", Command: "check_load"}, reg, fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Check()
+	var b strings.Builder
+	reg.Write(&b)
+	for _, want := range []string{
+		`lychee_exec_perfdata{check="load",label="load1",uom=""} 0.5`,
+		`lychee_exec_perfdata_warning{check="load",label="load1",uom=""} 2`,
+		`lychee_exec_perfdata_critical{check="load",label="load1",uom=""} 4`,
+		`lychee_exec_perfdata_min{check="load",label="load1",uom=""} 0`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "load5") || strings.Contains(b.String(), "perfdata_max") {
+		t.Errorf("unexpected metrics:\n%s", b.String())
+	}
+
+	// 下一轮没有性能数据时删除上一轮的指标
+	m.Check()
+	b.Reset()
+	reg.Write(&b)
+	if strings.Contains(b.String(), "lychee_exec_perfdata") {
+		t.Errorf("stale perfdata metrics:\n%s", b.String())
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		in    string
+		value float64
+		uom   string
+		ok    bool
+	}{
+		{"12.5ms", 12.5, "ms", true},
+		{"80%", 80, "%", true},
+		{"1024KB", 1024, "KB", true},
+		{"3", 3, "", true},
+		{"-1.5", -1.5, "", true},
+		{"0,25s", 0.25, "s", true},
+		{"U", 0, "", false},
+		{"", 0, "", false},
+	}
+	for _, tt := range tests {
+		value, uom, ok := parseValue(tt.in)
+		if value != tt.value || uom != tt.uom || ok != tt.ok {
+			t.Errorf("parseValue(%q) = %v, %q, %v, want %v, %q, %v", tt.in, value, uom, ok, tt.value, tt.uom, tt.ok)
+		}
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	out := parseOutput("DISK OK - free space: / 3326 MB | /=2643MB;5948;5958;0;5968\n" +
+		"/ 15272 MB (77%);\n" +
+		"/boot 68 MB (69%); | /boot=68MB;88;93;0;98\n" +
+		"'home dir'=69%;90;95 'it''s'=U\n")
+	if out.text != "DISK OK - free space: / 3326 MB" {
+		t.Errorf("text = %q", out.text)
+	}
+	if len(out.long) != 2 || out.long[0] != "/ 15272 MB (77%);" || out.long[1] != "/boot 68 MB (69%);" {
+		t.Errorf("long = %q", out.long)
+	}
+	var labels []string
+	for _, p := range out.perfdata {
+		labels = append(labels, p.label+"="+p.uom)
+	}
+	if got := strings.Join(labels, " "); got != "/=MB /boot=MB home dir=%" {
+		t.Errorf("perfdata = %s", got)
+	}
+	if p := out.perfdata[0]; p.value != 2643 || *p.warn != 5948 || *p.crit != 5958 || *p.min != 0 || *p.max != 5968 {
+		t.Errorf("perfdata[0] = %+v", p)
+	}
+	if p := out.perfdata[2]; p.max != nil || p.min != nil || *p.crit != 95 {
+		t.Errorf("perfdata[2] = %+v", p)
+	}
+}
+
+func TestParseThreshold(t *testing.T) {
+	for in, want := range map[string]float64{"10": 10, "10:20": 20, "@10:20": 20, "10:": 10, "~:5": 5} {
+		if got := parseThreshold(in); got == nil || *got != want {
+			t.Errorf("parseThreshold(%q) = %v, want %v", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "~", "x"} {
+		if got := parseThreshold(in); got != nil {
+			t.Errorf("parseThreshold(%q) = %v, want nil", in, *got)
+		}
+	}
+}
@@ -1,20 +1,22 @@
 package systemd
 
 import (
-	"bytes"
+	"context"
 	"fmt"
+	"hashcowuwu/lychee/internal/executor"
+	"hashcowuwu/lychee/internal/i18n"
 	"hashcowuwu/lychee/internal/monitor"
-	"os/exec"
 )
 
 // ServiceMonitor 监控一个具体的 systemd 服务
 type ServiceMonitor struct {
 	serviceName string
+	exec        executor.Executor
 }
 
-// New 创建一个新的 systemd 服务监控器
-func New(serviceName string) *ServiceMonitor {
-	return &ServiceMonitor{serviceName: serviceName}
+// New 创建一个新的 systemd 服务监控器，systemctl 通过 exec 执行
+func New(serviceName string, exec executor.Executor) *ServiceMonitor {
+	return &ServiceMonitor{serviceName: serviceName, exec: exec}
 }
 
 // Name 返回监控器名称
@@ -24,23 +26,25 @@ func (s *ServiceMonitor) Name() string {
 
 // Check 使用 `systemctl is-active` 命令检查服务状态
 func (s *ServiceMonitor) Check() monitor.Result {
-	cmd := exec.Command("systemctl", "is-active", "--quiet", s.serviceName)
-	
-	var out bytes.Buffer
-	cmd.Stdout = &out
-
-	err := cmd.Run()
+	_, err := s.exec.Output(context.Background(), "systemctl", "is-active", "--quiet", s.serviceName)
 	if err != nil {
 		// `is-active` 命令在服务不活跃时会返回非零退出码
 		return monitor.Result{
 			Success: false,
-			Message: fmt.Sprintf("服务 %s 状态异常或不存在。", s.serviceName),
+			Message: i18n.T("systemd.inactive", s.serviceName),
 			Err:     err,
+			Labels:  s.labels(),
 		}
 	}
 
 	return monitor.Result{
 		Success: true,
-		Message: fmt.Sprintf("服务 %s 运行正常。", s.serviceName),
+		Message: i18n.T("systemd.active", s.serviceName),
+		Labels:  s.labels(),
 	}
-}
\ No newline at end of file
+}
+
+// labels 返回附加在结果上的标签
+func (s *ServiceMonitor) labels() map[string]string {
+	return map[string]string{"type": "systemd", "service": s.serviceName}
+}
@@ -0,0 +1,225 @@
+package systemd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"hashcowuwu/lychee/internal/executor"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimerConfig 描述一个定时任务: systemd timer 及其触发的服务，或单独的 oneshot 服务
+type TimerConfig struct {
+	Name    string        `yaml:"name"`    // 默认使用 timer 或 service
+	Timer   string        `yaml:"timer"`   // 例如 backup.timer
+	Service string        `yaml:"service"` // 由 timer 触发的服务，默认读取 timer 的 Unit 属性；不配置 timer 时检查该 oneshot 服务
+	MaxAge  time.Duration `yaml:"maxAge"`  // 超过该时间没有成功运行时告警，例如 26h；为 0 时按 timer 的触发间隔推算，没有 timer 时不检查
+}
+
+// Validate 检查配置
+func (c TimerConfig) Validate() error {
+	if c.Timer == "" && c.Service == "" {
+		return i18n.Errorf("systemd.timer_unit_missing")
+	}
+	if c.MaxAge < 0 {
+		return i18n.Errorf("systemd.timer_max_age_negative")
+	}
+	return nil
+}
+
+// TimerMonitor 检查定时任务上次运行的结果，以及是否在预期时间内成功运行过
+type TimerMonitor struct {
+	cfg  TimerConfig
+	name string
+	exec executor.Executor
+	// started 是监控器的创建时间，从未成功运行过的任务从此时开始计算 maxAge
+	started     time.Time
+	lastSuccess time.Time
+	// period 是观察到的最短触发间隔 (NextElapseUSecRealtime - LastTriggerUSec)，用于推算默认的 maxAge
+	period time.Duration
+	// legacy 表示 systemctl 不支持 --timestamp=unix (systemd 251 之前)，此后不再带该参数
+	legacy bool
+}
+
+// NewTimer 创建定时任务监控器，systemctl 通过 exec 执行
+func NewTimer(cfg TimerConfig, exec executor.Executor) (*TimerMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Timer
+		if name == "" {
+			name = cfg.Service
+		}
+	}
+	return &TimerMonitor{cfg: cfg, name: name, exec: exec, started: time.Now()}, nil
+}
+
+// Name 返回监控器名称
+func (t *TimerMonitor) Name() string {
+	return fmt.Sprintf("systemd-timer(%s)", t.name)
+}
+
+// Check 读取 timer 和服务的属性并检查上次运行结果和最近一次成功运行的时间
+func (t *TimerMonitor) Check() monitor.Result {
+	var problems []string
+	service := t.cfg.Service
+	var nextRun, lastTrigger time.Time
+	if t.cfg.Timer != "" {
+		props, err := t.show(t.cfg.Timer, "ActiveState", "Unit", "LastTriggerUSec", "NextElapseUSecRealtime")
+		if err != nil {
+			return t.fail(i18n.T("systemd.show_failed", t.cfg.Timer, err), err)
+		}
+		if state := props["ActiveState"]; state != "active" {
+			problems = append(problems, i18n.T("systemd.timer_inactive", t.cfg.Timer, state))
+		}
+		if service == "" {
+			service = props["Unit"]
+		}
+		lastTrigger = parseTimestamp(props["LastTriggerUSec"])
+		nextRun = parseTimestamp(props["NextElapseUSecRealtime"])
+		// 错过运行后下一次触发时间会被推迟，所以取观察到的最短间隔
+		if p := nextRun.Sub(lastTrigger); !lastTrigger.IsZero() && !nextRun.IsZero() && p > 0 && (t.period == 0 || p < t.period) {
+			t.period = p
+		}
+	}
+
+	props, err := t.show(service, "ActiveState", "Result", "ExecMainStatus", "ExecMainExitTimestamp")
+	if err != nil {
+		return t.fail(i18n.T("systemd.show_failed", service, err), err)
+	}
+	running := props["ActiveState"] == "activating" || props["ActiveState"] == "deactivating"
+	// 有 timer 时以 LastTriggerUSec 作为运行时间，单独的 oneshot 服务使用退出时间
+	ran := parseTimestamp(props["ExecMainExitTimestamp"])
+	if t.cfg.Timer != "" {
+		ran = lastTrigger
+	}
+	result, status := props["Result"], props["ExecMainStatus"]
+	switch {
+	case running:
+		// 正在运行，结果在下一轮检查
+	case result != "" && result != "success":
+		problems = append(problems, i18n.T("systemd.timer_failed", service, result, status))
+	case !ran.IsZero() && ran.After(t.lastSuccess):
+		t.lastSuccess = ran
+	}
+
+	// 没有配置 maxAge 时，允许超过一个触发间隔 10% (至少 1 分钟) 的延迟
+	maxAge := t.cfg.MaxAge
+	if maxAge == 0 && t.period > 0 {
+		maxAge = t.period + max(t.period/10, time.Minute)
+	}
+	if maxAge > 0 && !running {
+		since := t.lastSuccess
+		if since.IsZero() {
+			since = t.started
+		}
+		if time.Since(since) > maxAge {
+			last := i18n.T("systemd.timer_never")
+			if !t.lastSuccess.IsZero() {
+				last = t.lastSuccess.Format(time.DateTime)
+			}
+			problems = append(problems, i18n.T("systemd.timer_missed", t.name, maxAge, last))
+		}
+	}
+
+	if len(problems) > 0 {
+		return monitor.Result{
+			Success:  false,
+			Message:  strings.Join(problems, "\n"),
+			Severity: monitor.SeverityCritical,
+			Labels:   t.labels(),
+		}
+	}
+	message := i18n.T("systemd.timer_ok", t.name, formatTime(t.lastSuccess), formatTime(nextRun))
+	return monitor.Result{Success: true, Message: message, Labels: t.labels()}
+}
+
+func (t *TimerMonitor) fail(msg string, err error) monitor.Result {
+	return monitor.Result{Success: false, Message: msg, Err: err, Labels: t.labels()}
+}
+
+// labels 返回附加在结果上的标签
+func (t *TimerMonitor) labels() map[string]string {
+	return map[string]string{"type": "systemd-timer", "service": t.name}
+}
+
+// show 执行 `systemctl show` 读取单元的属性，时间戳以 "@秒数" 输出，不受语言环境和时区影响。
+// 带 --timestamp=unix 失败而不带时成功，说明 systemctl 不支持该参数，之后改用默认的时间格式
+func (t *TimerMonitor) show(unit string, props ...string) (map[string]string, error) {
+	args := []string{"show", unit, "--property=" + strings.Join(props, ",")}
+	if t.legacy {
+		return show(t.exec, args...)
+	}
+	values, err := show(t.exec, append(args, "--timestamp=unix")...)
+	if err == nil {
+		return values, nil
+	}
+	if values, legacyErr := show(t.exec, args...); legacyErr == nil {
+		t.legacy = true
+		return values, nil
+	}
+	return nil, err
+}
+
+func show(exec executor.Executor, args ...string) (map[string]string, error) {
+	out, err := exec.Output(context.Background(), "systemctl", args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseShow(out), nil
+}
+
+// parseShow 解析 `systemctl show` 输出的 Key=Value 行
+func parseShow(out []byte) map[string]string {
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			props[key] = value
+		}
+	}
+	return props
+}
+
+// parseTimestamp 解析 --timestamp=unix 输出的 "@1704078000"，也接受微秒数 "1704078000000000"
+// 和旧版 systemctl 的默认格式 "Mon 2024-01-01 03:00:00 CST" (按本地时区解析)。
+// 空值、"n/a"、0 和无法解析的值返回零值
+func parseTimestamp(s string) time.Time {
+	if unix, ok := strings.CutPrefix(s, "@"); ok {
+		if sec, err := strconv.ParseInt(unix, 10, 64); err == nil && sec > 0 {
+			return time.Unix(sec, 0)
+		}
+		return time.Time{}
+	}
+	if usec, err := strconv.ParseInt(s, 10, 64); err == nil && usec > 0 {
+		return time.UnixMicro(usec)
+	}
+	// 星期的缩写可能随语言环境变化，只解析日期、时间和时区
+	fields := strings.Fields(s)
+	if len(fields) < 3 {
+		return time.Time{}
+	}
+	layout := "2006-01-02 15:04:05"
+	if len(fields) > 3 {
+		layout += " MST"
+	}
+	ts, err := time.ParseInLocation(layout, strings.Join(fields[1:min(len(fields), 4)], " "), time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return ts
+}
+
+func formatTime(ts time.Time) string {
+	if ts.IsZero() {
+		return "-"
+	}
+	return ts.Format(time.DateTime)
+}
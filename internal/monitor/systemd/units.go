@@ -0,0 +1,201 @@
+package systemd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"hashcowuwu/lychee/internal/executor"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"path"
+	"slices"
+	"strings"
+)
+
+// IsPattern 判断服务名是否包含 glob 通配符，例如 app-*.service
+func IsPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// ValidatePattern 检查 glob 模式的语法
+func ValidatePattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return i18n.Errorf("systemd.pattern_invalid", pattern, err)
+	}
+	return nil
+}
+
+// FailedConfig 描述对全部处于 failed 状态的单元的检查
+type FailedConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Exclude []string `yaml:"exclude"` // 忽略匹配的单元 (glob)
+}
+
+// Validate 检查配置
+func (c FailedConfig) Validate() error {
+	for _, p := range c.Exclude {
+		if err := ValidatePattern(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unit 是 `systemctl list-units` 输出的一行
+type unit struct {
+	name, load, active, sub string
+}
+
+// listUnits 执行 `systemctl list-units` 并解析输出，args 为附加的过滤参数
+func listUnits(exec executor.Executor, args ...string) ([]unit, error) {
+	args = append([]string{"list-units", "--plain", "--no-legend", "--no-pager", "--full"}, args...)
+	out, err := exec.Output(context.Background(), "systemctl", args...)
+	if err != nil {
+		return nil, err
+	}
+	var units []unit
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// 未加载的单元前面可能带有 ● 标记
+		if len(fields) > 0 && fields[0] == "●" {
+			fields = fields[1:]
+		}
+		if len(fields) < 4 {
+			continue
+		}
+		units = append(units, unit{name: fields[0], load: fields[1], active: fields[2], sub: fields[3]})
+	}
+	return units, scanner.Err()
+}
+
+// PatternMonitor 检查所有名称匹配 glob 模式的已加载单元，每次检查时重新展开，新增的单元会自动纳入监控
+type PatternMonitor struct {
+	pattern string
+	exec    executor.Executor
+}
+
+// NewPattern 创建按 glob 模式匹配服务的监控器，systemctl 通过 exec 执行
+func NewPattern(pattern string, exec executor.Executor) (*PatternMonitor, error) {
+	if err := ValidatePattern(pattern); err != nil {
+		return nil, err
+	}
+	return &PatternMonitor{pattern: pattern, exec: exec}, nil
+}
+
+// Name 返回监控器名称
+func (p *PatternMonitor) Name() string {
+	return fmt.Sprintf("systemd-service(%s)", p.pattern)
+}
+
+// Check 列出匹配的单元，任何一个不处于 active 状态时告警
+func (p *PatternMonitor) Check() monitor.Result {
+	labels := map[string]string{"type": "systemd", "service": p.pattern}
+	// --all 才能列出已停止的单元，--state=loaded 排除 not-found 和 masked 等只是被引用过的单元
+	units, err := listUnits(p.exec, "--all", "--state=loaded", p.pattern)
+	if err != nil {
+		return monitor.Result{Success: false, Message: i18n.T("systemd.list_failed", err), Err: err, Labels: labels}
+	}
+	units = slices.DeleteFunc(units, func(u unit) bool { return u.load != "loaded" })
+	if len(units) == 0 {
+		return monitor.Result{Success: false, Message: i18n.T("systemd.pattern_empty", p.pattern), Labels: labels}
+	}
+	var messages []string
+	for _, u := range units {
+		if u.active != "active" {
+			messages = append(messages, i18n.T("systemd.unit_state", u.name, u.active, u.sub))
+		}
+	}
+	if len(messages) > 0 {
+		return monitor.Result{Success: false, Message: strings.Join(messages, "\n"), Labels: labels}
+	}
+	return monitor.Result{Success: true, Message: i18n.T("systemd.pattern_ok", p.pattern, len(units)), Labels: labels}
+}
+
+// FailedMonitor 检查系统中所有处于 failed 状态的单元
+type FailedMonitor struct {
+	exclude []string
+	exec    executor.Executor
+}
+
+// NewFailed 创建 failed 单元监控器，systemctl 通过 exec 执行
+func NewFailed(cfg FailedConfig, exec executor.Executor) (*FailedMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &FailedMonitor{exclude: cfg.Exclude, exec: exec}, nil
+}
+
+// Name 返回监控器名称
+func (f *FailedMonitor) Name() string {
+	return "systemd-failed"
+}
+
+// Check 执行 `systemctl list-units --state=failed`，排除 exclude 中的单元后仍有剩余时告警
+func (f *FailedMonitor) Check() monitor.Result {
+	labels := map[string]string{"type": "systemd", "service": "failed-units"}
+	units, err := listUnits(f.exec, "--state=failed")
+	if err != nil {
+		return monitor.Result{Success: false, Message: i18n.T("systemd.list_failed", err), Err: err, Labels: labels}
+	}
+	var names []string
+	for _, u := range units {
+		if !f.excluded(u.name) {
+			names = append(names, u.name)
+		}
+	}
+	if len(names) > 0 {
+		return monitor.Result{
+			Success: false,
+			Message: i18n.T("systemd.units_failed", len(names), strings.Join(names, "\n")),
+			Labels:  labels,
+		}
+	}
+	return monitor.Result{Success: true, Message: i18n.T("systemd.units_ok"), Labels: labels}
+}
+
+func (f *FailedMonitor) excluded(name string) bool {
+	for _, p := range f.exclude {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SystemStateMonitor 检查 `systemctl is-system-running` 报告的整体状态
+type SystemStateMonitor struct {
+	exec executor.Executor
+}
+
+// NewSystemState 创建系统状态监控器，systemctl 通过 exec 执行
+func NewSystemState(exec executor.Executor) *SystemStateMonitor {
+	return &SystemStateMonitor{exec: exec}
+}
+
+// Name 返回监控器名称
+func (s *SystemStateMonitor) Name() string {
+	return "systemd-system"
+}
+
+// Check 读取系统状态: running 和启动过程中的状态视为正常，degraded 为警告，其他状态为严重
+func (s *SystemStateMonitor) Check() monitor.Result {
+	labels := map[string]string{"type": "systemd", "service": "system"}
+	// 状态不是 running 时命令返回非零退出码，只要有输出就以输出为准
+	out, err := s.exec.Output(context.Background(), "systemctl", "is-system-running")
+	state := strings.TrimSpace(string(out))
+	if state == "" {
+		if err == nil {
+			err = i18n.Errorf("systemd.system_state_empty")
+		}
+		return monitor.Result{Success: false, Message: i18n.T("systemd.system_state_failed", err), Err: err, Labels: labels}
+	}
+	switch state {
+	case "running", "initializing", "starting":
+		return monitor.Result{Success: true, Message: i18n.T("systemd.system_ok", state), Labels: labels}
+	case "degraded":
+		return monitor.Result{Success: false, Message: i18n.T("systemd.system_state", state), Severity: monitor.SeverityWarning, Labels: labels}
+	}
+	return monitor.Result{Success: false, Message: i18n.T("systemd.system_state", state), Severity: monitor.SeverityCritical, Labels: labels}
+}
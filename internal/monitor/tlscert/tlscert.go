@@ -0,0 +1,324 @@
+package tlscert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"hashcowuwu/lychee/internal/monitor"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 默认的告警阈值和连接超时
+const (
+	DefaultWarningDays  = 30
+	DefaultCriticalDays = 7
+	DefaultTimeout      = 10 * time.Second
+)
+
+// Config 描述一个证书检查: 连接 address 读取服务端证书，或读取磁盘上的 PEM 文件，二选一
+type Config struct {
+	Name         string        `yaml:"name"`         // 默认使用 address 或第一个文件模式
+	Address      string        `yaml:"address"`      // host:port
+	ServerName   string        `yaml:"serverName"`   // SNI 和校验用的主机名，默认取 address 中的主机；对文件设置时同样校验主机名
+	Files        []string      `yaml:"files"`        // PEM 文件的 glob 模式，例如 /etc/letsencrypt/live/*/fullchain.pem
+	WarningDays  int           `yaml:"warningDays"`  // 剩余天数不超过该值时产生 warning 告警，默认 30
+	CriticalDays int           `yaml:"criticalDays"` // 剩余天数不超过该值时产生 critical 告警，默认 7
+	CAFile       string        `yaml:"caFile"`       // 校验证书链时额外信任的 CA
+	Timeout      time.Duration `yaml:"timeout"`      // 连接超时，默认 10s
+}
+
+// Validate 检查配置，不访问文件和网络
+func (c Config) Validate() error {
+	if (c.Address == "") == (len(c.Files) == 0) {
+		return i18n.Errorf("tls.source_required")
+	}
+	if c.Address != "" {
+		if _, _, err := net.SplitHostPort(c.Address); err != nil {
+			return i18n.Errorf("tls.address_invalid", c.Address, err)
+		}
+	}
+	for _, p := range c.Files {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return i18n.Errorf("tls.pattern_invalid", p, err)
+		}
+	}
+	warning, critical := c.days()
+	if warning < 0 || critical < 0 || warning < critical || c.Timeout < 0 {
+		return i18n.Errorf("tls.days_invalid")
+	}
+	return nil
+}
+
+// days 返回应用默认值后的告警阈值
+func (c Config) days() (warning, critical int) {
+	warning, critical = c.WarningDays, c.CriticalDays
+	if warning == 0 {
+		warning = DefaultWarningDays
+	}
+	if critical == 0 {
+		critical = DefaultCriticalDays
+	}
+	return warning, critical
+}
+
+// CertMonitor 检查证书的剩余有效期、证书链和主机名
+type CertMonitor struct {
+	cfg      Config
+	name     string
+	roots    *x509.CertPool
+	warning  int
+	critical int
+}
+
+// New 根据配置创建证书监控器，会读取 caFile
+func New(cfg Config) (*CertMonitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	m := &CertMonitor{cfg: cfg, name: cfg.Name}
+	if m.name == "" {
+		m.name = cfg.Address
+		if m.name == "" {
+			m.name = cfg.Files[0]
+		}
+	}
+	if m.cfg.Timeout == 0 {
+		m.cfg.Timeout = DefaultTimeout
+	}
+	if m.cfg.ServerName == "" && cfg.Address != "" {
+		m.cfg.ServerName, _, _ = net.SplitHostPort(cfg.Address)
+	}
+	m.warning, m.critical = cfg.days()
+
+	if cfg.CAFile != "" {
+		data, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, i18n.Errorf("tls.ca_invalid", cfg.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, i18n.Errorf("tls.ca_invalid", cfg.CAFile, i18n.Errorf("tls.no_certificates", cfg.CAFile))
+		}
+		m.roots = pool
+	}
+	return m, nil
+}
+
+// Name 返回监控器名称
+func (m *CertMonitor) Name() string {
+	return fmt.Sprintf("tls(%s)", m.name)
+}
+
+// problem 是一次检查中发现的问题
+type problem struct {
+	message  string
+	severity monitor.Severity
+}
+
+// Check 读取证书链并检查有效期和校验结果，多个问题合并成一条结果，严重程度取最高的
+func (m *CertMonitor) Check() monitor.Result {
+	now := time.Now()
+	var problems []problem
+	minDays := -1
+	check := func(source string, chain []*x509.Certificate) {
+		problems = append(problems, m.checkChain(source, chain, now, &minDays)...)
+	}
+
+	if m.cfg.Address != "" {
+		chain, err := m.fetch()
+		if err != nil {
+			problems = append(problems, problem{i18n.T("tls.connect_failed", m.cfg.Address, err), monitor.SeverityCritical})
+		} else {
+			check(m.cfg.Address, chain)
+		}
+	} else {
+		files, err := m.files()
+		if err != nil {
+			problems = append(problems, problem{err.Error(), monitor.SeverityCritical})
+		}
+		for _, path := range files {
+			chain, err := readPEM(path)
+			if err != nil {
+				problems = append(problems, problem{i18n.T("tls.read_failed", path, err), monitor.SeverityCritical})
+				continue
+			}
+			check(path, chain)
+		}
+	}
+
+	if len(problems) == 0 {
+		return monitor.Result{Success: true, Message: i18n.T("tls.ok", m.name, minDays), Labels: m.labels()}
+	}
+	severity := monitor.SeverityWarning
+	messages := make([]string, len(problems))
+	for i, p := range problems {
+		messages[i] = p.message
+		if p.severity == monitor.SeverityCritical {
+			severity = monitor.SeverityCritical
+		}
+	}
+	return monitor.Result{
+		Success:  false,
+		Message:  strings.Join(messages, "\n"),
+		Severity: severity,
+		Labels:   m.labels(),
+	}
+}
+
+// checkChain 检查一条证书链，chain[0] 是叶子证书
+func (m *CertMonitor) checkChain(source string, chain []*x509.Certificate, now time.Time, minDays *int) []problem {
+	var problems []problem
+	leaf := chain[0]
+	opts := x509.VerifyOptions{
+		Roots:         m.roots,
+		Intermediates: x509.NewCertPool(),
+		DNSName:       m.cfg.ServerName,
+		CurrentTime:   now,
+	}
+	for _, c := range chain[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	verified, err := leaf.Verify(opts)
+	var hostErr x509.HostnameError
+	if errors.As(err, &hostErr) {
+		problems = append(problems, problem{i18n.T("tls.hostname_mismatch", describe(leaf), source, m.cfg.ServerName), monitor.SeverityCritical})
+		// 不校验主机名再构建一次，以便只检查实际使用的证书的有效期
+		opts.DNSName = ""
+		verified, err = leaf.Verify(opts)
+	}
+
+	// 服务端可能附带已不再使用的证书 (例如过期的交叉签名中间证书)，
+	// 校验通过时只检查实际构建出的证书链，校验失败时检查发送的全部证书
+	certs := chain
+	if err == nil {
+		certs = bestChain(verified)
+	}
+	for _, cert := range certs {
+		days := int(cert.NotAfter.Sub(now).Hours() / 24)
+		if *minDays < 0 || days < *minDays {
+			*minDays = max(days, 0)
+		}
+		subject := describe(cert)
+		switch {
+		case !now.Before(cert.NotAfter):
+			problems = append(problems, problem{i18n.T("tls.expired", subject, source, cert.NotAfter.Format(time.DateOnly)), monitor.SeverityCritical})
+		case days <= m.critical:
+			problems = append(problems, problem{i18n.T("tls.expiring", subject, source, days, cert.NotAfter.Format(time.DateOnly)), monitor.SeverityCritical})
+		case days <= m.warning:
+			problems = append(problems, problem{i18n.T("tls.expiring", subject, source, days, cert.NotAfter.Format(time.DateOnly)), monitor.SeverityWarning})
+		}
+	}
+
+	var invalid x509.CertificateInvalidError
+	switch {
+	case err == nil:
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		// 过期已在上面报告
+	default:
+		problems = append(problems, problem{i18n.T("tls.verify_failed", describe(leaf), source, err), monitor.SeverityCritical})
+	}
+	return problems
+}
+
+// bestChain 从校验得到的多条证书链中选出最晚才会有证书过期的一条，客户端同样可以使用这条链
+func bestChain(chains [][]*x509.Certificate) []*x509.Certificate {
+	var best []*x509.Certificate
+	var bestExpiry time.Time
+	for _, chain := range chains {
+		expiry := chain[0].NotAfter
+		for _, c := range chain[1:] {
+			if c.NotAfter.Before(expiry) {
+				expiry = c.NotAfter
+			}
+		}
+		if best == nil || expiry.After(bestExpiry) {
+			best, bestExpiry = chain, expiry
+		}
+	}
+	return best
+}
+
+// fetch 连接服务端并返回它发送的证书链，不在握手时校验，以便报告具体的问题
+func (m *CertMonitor) fetch() ([]*x509.Certificate, error) {
+	dialer := &net.Dialer{Timeout: m.cfg.Timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", m.cfg.Address, &tls.Config{
+		ServerName:         m.cfg.ServerName,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	chain := conn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, i18n.Errorf("tls.no_certificates", m.cfg.Address)
+	}
+	return chain, nil
+}
+
+// files 展开所有文件模式
+func (m *CertMonitor) files() ([]string, error) {
+	var files []string
+	for _, p := range m.cfg.Files {
+		matches, _ := filepath.Glob(p)
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, i18n.Errorf("tls.no_files", m.cfg.Files)
+	}
+	return files, nil
+}
+
+// readPEM 读取文件中的全部证书，第一张视为叶子证书
+func readPEM(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, i18n.Errorf("tls.no_certificates", path)
+	}
+	return chain, nil
+}
+
+// describe 返回证书的可读名称
+func describe(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return cert.Subject.String()
+}
+
+// labels 返回附加在结果上的标签
+func (m *CertMonitor) labels() map[string]string {
+	return map[string]string{"type": "tls", "service": m.name}
+}
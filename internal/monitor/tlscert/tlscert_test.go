@@ -0,0 +1,242 @@
+package tlscert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"hashcowuwu/lychee/internal/monitor"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA 是测试用的自签名 CA
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string // CA 证书的 PEM 文件
+}
+
+func newCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// intermediate 签发一张在 notAfter 过期的中间 CA 证书
+func (ca *testCA) intermediate(t *testing.T, notAfter time.Time) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(3),
+		Subject:               pkix.Name{CommonName: "Test Intermediate"},
+		NotBefore:             time.Now().Add(-10 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, file: ca.file}
+}
+
+// issue 签发一张对 127.0.0.1 有效、在 notAfter 过期的服务端证书
+func (ca *testCA) issue(t *testing.T, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-48 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// serve 启动一个使用 cert 的 TLS 服务，返回它的地址
+func serve(t *testing.T, cert tls.Certificate) string {
+	t.Helper()
+	s := httptest.NewUnstartedServer(http.NotFoundHandler())
+	s.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	s.StartTLS()
+	t.Cleanup(s.Close)
+	return s.Listener.Addr().String()
+}
+
+func TestCheckAddress(t *testing.T) {
+	ca := newCA(t)
+	day := 24 * time.Hour
+	tests := []struct {
+		name       string
+		expires    time.Duration
+		serverName string
+		success    bool
+		severity   monitor.Severity
+	}{
+		{"valid", 60 * day, "", true, ""},
+		{"warning", 20 * day, "", false, monitor.SeverityWarning},
+		{"critical", 3 * day, "", false, monitor.SeverityCritical},
+		{"expired", -day, "", false, monitor.SeverityCritical},
+		{"hostname mismatch", 60 * day, "other.example", false, monitor.SeverityCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := serve(t, ca.issue(t, time.Now().Add(tt.expires)))
+			m, err := New(Config{Address: addr, ServerName: tt.serverName, CAFile: ca.file})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := m.Check()
+			if r.Success != tt.success || r.Severity != tt.severity {
+				t.Errorf("got Success = %v, Severity = %q, want %v, %q (%s)", r.Success, r.Severity, tt.success, tt.severity, r.Message)
+			}
+		})
+	}
+}
+
+func TestCheckChainExtras(t *testing.T) {
+	ca := newCA(t)
+	day := 24 * time.Hour
+	expired := ca.intermediate(t, time.Now().Add(-day))
+	expiring := ca.intermediate(t, time.Now().Add(10*day))
+	tests := []struct {
+		name     string
+		issuer   *testCA
+		extra    *testCA
+		success  bool
+		severity monitor.Severity
+	}{
+		// 服务端附带的过期中间证书不在校验出的证书链中，不影响客户端
+		{"expired unused intermediate", ca, expired, true, ""},
+		{"expiring intermediate in use", expiring, expiring, false, monitor.SeverityWarning},
+		{"expired intermediate in use", expired, expired, false, monitor.SeverityCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := tt.issuer.issue(t, time.Now().Add(60*day))
+			cert.Certificate = append(cert.Certificate, tt.extra.cert.Raw)
+			m, err := New(Config{Address: serve(t, cert), CAFile: ca.file})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := m.Check()
+			if r.Success != tt.success || r.Severity != tt.severity {
+				t.Errorf("got Success = %v, Severity = %q, want %v, %q (%s)", r.Success, r.Severity, tt.success, tt.severity, r.Message)
+			}
+		})
+	}
+}
+
+func TestCheckUntrusted(t *testing.T) {
+	addr := serve(t, newCA(t).issue(t, time.Now().Add(60*24*time.Hour)))
+	m, err := New(Config{Address: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := m.Check(); r.Success || r.Severity != monitor.SeverityCritical {
+		t.Errorf("got Success = %v, Severity = %q, want a critical alert (%s)", r.Success, r.Severity, r.Message)
+	}
+}
+
+func TestCheckFiles(t *testing.T) {
+	ca := newCA(t)
+	dir := t.TempDir()
+	write := func(name string, expires time.Duration) {
+		cert := ca.issue(t, time.Now().Add(expires))
+		data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.pem", 60*24*time.Hour)
+	write("b.pem", 10*24*time.Hour)
+	tests := []struct {
+		name     string
+		pattern  string
+		success  bool
+		severity monitor.Severity
+	}{
+		{"one file", "a.pem", true, ""},
+		{"worst of all files", "*.pem", false, monitor.SeverityWarning},
+		{"no match", "*.crt", false, monitor.SeverityCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(Config{Files: []string{filepath.Join(dir, tt.pattern)}, ServerName: "127.0.0.1", CAFile: ca.file})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := m.Check()
+			if r.Success != tt.success || r.Severity != tt.severity {
+				t.Errorf("got Success = %v, Severity = %q, want %v, %q (%s)", r.Success, r.Severity, tt.success, tt.severity, r.Message)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		valid bool
+	}{
+		{"address", Config{Address: "example.com:443"}, true},
+		{"files", Config{Files: []string{"/etc/ssl/*.pem"}}, true},
+		{"neither", Config{}, false},
+		{"both", Config{Address: "example.com:443", Files: []string{"/etc/ssl/*.pem"}}, false},
+		{"missing port", Config{Address: "example.com"}, false},
+		{"critical above warning", Config{Address: "example.com:443", WarningDays: 5, CriticalDays: 10}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
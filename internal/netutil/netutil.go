@@ -0,0 +1,19 @@
+// Package netutil 提供解析配置中网络地址的公共函数
+package netutil
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// ParseNetwork 解析网段 (例如 10.0.0.0/8) 或单个地址，单个地址视为只包含它自己的网段
+func ParseNetwork(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,32 @@
+package netutil
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestParseNetwork(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"10.0.0.0/8", "10.0.0.0/8"},
+		{"203.0.113.5", "203.0.113.5/32"},
+		{"2001:db8::/32", "2001:db8::/32"},
+		{"2001:db8::1", "2001:db8::1/128"},
+		{"10.0.0.0/33", ""},
+		{"example.com", ""},
+	}
+	for _, tt := range tests {
+		got, err := ParseNetwork(tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseNetwork(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != netip.MustParsePrefix(tt.want) {
+			t.Errorf("ParseNetwork(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
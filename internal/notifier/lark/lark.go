@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
 	"io" // 导入 io 包用于读取响应体
 	"net/http"
 )
 
+// MaxMessageSize 是一条飞书消息标题和正文的最大字节数。
+// 飞书自定义机器人的请求体不能超过 20 KB。标题和正文按 JSON 转义后的长度计算，
+// 这里为消息卡片的其余 JSON 结构预留了余量。
+const MaxMessageSize = 16 * 1024
+
 // LarkNotifier 实现了 notifier.Notifier 接口，用于发送飞书消息
 type LarkNotifier struct {
 	WebhookURLs []string
@@ -23,6 +28,11 @@ func New(webhookURLs []string) *LarkNotifier {
 	}
 }
 
+// MaxMessageSize 实现 notifier.Limiter
+func (n *LarkNotifier) MaxMessageSize() int {
+	return MaxMessageSize
+}
+
 // 飞书消息卡片的公共结构体
 type larkPayload struct {
 	MsgType string      `json:"msg_type"`
@@ -59,7 +69,7 @@ func (n *LarkNotifier) Notify(ctx context.Context, subject, message string) erro
 	// 这里的示例将整个 message 作为一行简单的 Markdown 文本
 	// 如果需要更复杂的 Markdown 结构，你需要根据 message 的内容自行解析和构建 content 数组
 	if len(n.WebhookURLs) == 0 {
-		return fmt.Errorf("没有配置飞书 Webhook URL，无法发送通知")
+		return i18n.Errorf("lark.no_webhook")
 	}
 	markdownContent := postContent{
 		Post: struct {
@@ -101,28 +111,28 @@ func (n *LarkNotifier) Notify(ctx context.Context, subject, message string) erro
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("序列化飞书消息失败: %w", err)
+		return i18n.Errorf("lark.marshal_failed", err)
 	}
 
 	var allErrors []error
 	for _, url := range n.WebhookURLs {
 		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 		if err != nil {
-			allErrors = append(allErrors, fmt.Errorf("创建飞书请求失败 (%s): %w", url, err))
+			allErrors = append(allErrors, i18n.Errorf("lark.request_failed", url, err))
 			continue // 继续尝试下一个 URL
 		}
 		req.Header.Set("Content-Type", "application/json")
 
 		resp, err := n.client.Do(req)
 		if err != nil {
-			allErrors = append(allErrors, fmt.Errorf("发送飞书消息失败 (%s): %w", url, err))
+			allErrors = append(allErrors, i18n.Errorf("lark.send_failed", url, err))
 			continue // 继续尝试下一个 URL
 		}
 		defer resp.Body.Close() // 确保每次循环都关闭响应体
 
 		if resp.StatusCode != http.StatusOK {
 			respBody, _ := io.ReadAll(resp.Body)
-			allErrors = append(allErrors, fmt.Errorf("发送飞书消息失败 (%s)，状态码: %d, 响应: %s", url, resp.StatusCode, string(respBody)))
+			allErrors = append(allErrors, i18n.Errorf("lark.bad_status", url, resp.StatusCode, string(respBody)))
 			continue // 继续尝试下一个 URL
 		}
 		// 如果成功发送到某个 URL，可以根据需求选择是否记录成功
@@ -130,7 +140,7 @@ func (n *LarkNotifier) Notify(ctx context.Context, subject, message string) erro
 	}
 	if len(allErrors) > 0 {
 		// 返回所有错误的聚合信息
-		return fmt.Errorf("向部分或所有飞书 Webhook 发送消息失败: %v", allErrors)
+		return i18n.Errorf("lark.partial_failure", allErrors)
 	}
 
 	return nil // 所有 URL 都成功发送
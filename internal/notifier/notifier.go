@@ -7,3 +7,17 @@ type Notifier interface {
 	// Notify 发送一条通知
 	Notify(ctx context.Context, subject, message string) error
 }
+
+// Limiter 由对消息大小有限制的通知器实现
+type Limiter interface {
+	// MaxMessageSize 返回标题和正文合计允许的最大字节数，按 JSON 转义后的长度计算
+	MaxMessageSize() int
+}
+
+// MaxMessageSize 返回通知器声明的消息大小上限，未声明时返回 0 表示不限制
+func MaxMessageSize(n Notifier) int {
+	if l, ok := n.(Limiter); ok {
+		return l.MaxMessageSize()
+	}
+	return 0
+}
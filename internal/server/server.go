@@ -0,0 +1,82 @@
+// Package server 提供 lychee 内置的 HTTP 服务，心跳 ping 地址和 /metrics 等需要接收外部请求的功能在其上注册处理器。
+package server
+
+import (
+	"errors"
+	"hashcowuwu/lychee/internal/i18n"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Config 描述内置 HTTP 服务
+type Config struct {
+	Listen string `yaml:"listen"` // 监听地址，例如 127.0.0.1:9380，为空时不启动
+}
+
+// Enabled 判断是否配置了监听地址
+func (c Config) Enabled() bool {
+	return c.Listen != ""
+}
+
+// Validate 检查监听地址的格式
+func (c Config) Validate() error {
+	if c.Listen == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+		return i18n.Errorf("server.listen_invalid", c.Listen, err)
+	}
+	return nil
+}
+
+// Server 是内置的 HTTP 服务
+type Server struct {
+	mux      *http.ServeMux
+	srv      *http.Server
+	listener net.Listener
+}
+
+// New 创建监听 addr 的 HTTP 服务，Start 之前通过 Handle 注册处理器
+func New(addr string) *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		mux: mux,
+		srv: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+		},
+	}
+}
+
+// Handle 为 pattern 注册处理器，pattern 的语法与 http.ServeMux 相同
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start 开始监听并在后台处理请求，监听失败时直接返回错误
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	s.listener = ln
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Print(i18n.T("server.serve_failed", err))
+		}
+	}()
+	return nil
+}
+
+// Addr 返回实际监听的地址，监听端口为 0 时可以由此得到分配的端口
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.srv.Addr
+	}
+	return s.listener.Addr().String()
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	s := New("127.0.0.1:0")
+	s.Handle("/hello", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.srv.Close() })
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/hello", http.StatusOK, "hello"},
+		{"/missing", http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get("http://" + s.Addr() + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || string(body) != tt.body {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, resp.StatusCode, body, tt.status, tt.body)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		listen string
+		valid  bool
+	}{
+		{"", true},
+		{"127.0.0.1:9380", true},
+		{":9380", true},
+		{"9380", false},
+	}
+	for _, tt := range tests {
+		if err := (Config{Listen: tt.listen}).Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%q) = %v, want valid %v", tt.listen, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Package state 负责把监控器需要跨重启保留的状态持久化为 JSON 文件。
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// DefaultDir 是未配置 stateDir 时使用的状态目录
+const DefaultDir = "/var/lib/lychee"
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Path 返回 kind 类监控器 name 的状态文件路径，name 中的特殊字符会被替换
+func Path(dir, kind, name string) string {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return filepath.Join(dir, kind+"-"+unsafeChars.ReplaceAllString(name, "_")+".json")
+}
+
+// Load 从 path 读取状态到 v。文件不存在时不修改 v 并返回 nil。
+func Load(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Save 把 v 原子地写入 path：先写临时文件再重命名，避免崩溃时留下半个文件
+func Save(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
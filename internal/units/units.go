@@ -0,0 +1,47 @@
+package units
+
+import (
+	"fmt"
+	"hashcowuwu/lychee/internal/i18n"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits 是支持的大小单位，K/M/G/T 与 KiB/MiB/GiB/TiB 都按 1024 进制计算
+var sizeUnits = map[string]uint64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+	"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+	"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+	"T": 1 << 40, "TB": 1 << 40, "TIB": 1 << 40,
+}
+
+// ParseBytes 解析 "512MiB"、"2G"、"1048576" 这样的大小
+func ParseBytes(s string) (uint64, error) {
+	t := strings.TrimSpace(s)
+	i := strings.IndexFunc(t, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(t)
+	}
+	n, err := strconv.ParseFloat(t[:i], 64)
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(t[i:]))]
+	if err != nil || !ok || n < 0 {
+		return 0, i18n.Errorf("units.size_invalid", s)
+	}
+	return uint64(n * float64(unit)), nil
+}
+
+// FormatBytes 把字节数格式化为易读的形式
+func FormatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
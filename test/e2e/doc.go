@@ -0,0 +1,4 @@
+// Package e2e 是端到端测试: 编译 lychee，把 testdata/bin 中模拟的 systemctl 和 journalctl 放在 PATH 最前面，
+// 用 httptest 模拟飞书 Webhook，按场景修改模拟的单元状态和日志，并逐条检查收到的通知。
+// 不需要 systemd 和 root 权限；go test -short 时跳过。
+package e2e
@@ -0,0 +1,390 @@
+//go:build unix
+
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const (
+	// timeout 是等待一条通知或一次检查的最长时间
+	timeout = 10 * time.Second
+	// subject 是英文的默认通知标题
+	subject = "🚨 Service Alert"
+)
+
+// baseConfig 是所有场景共用的配置: 每秒检查一次，告警不等待分组立即发送，持续的告警不重复提醒
+const baseConfig = `
+checkInterval: 1
+language: en
+stateDir: "$STATE_DIR"
+receivers:
+  - name: e2e
+    type: lark
+    webhookURLs: ["$LARK_URL"]
+    templates:
+      body: '{{ range .Alerts }}[{{ .Severity }}] {{ .Monitor }}: {{ .Details }}{{ "\n" }}{{ end }}'
+group:
+  groupWait: 1ms
+  groupInterval: 1ms
+  repeatInterval: 1h
+`
+
+var (
+	buildOnce sync.Once
+	buildDir  string // 编译结果所在的临时目录，由 TestMain 删除
+	binary    string
+	buildErr  error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if buildDir != "" {
+		os.RemoveAll(buildDir)
+	}
+	os.Exit(code)
+}
+
+// build 编译一次 lychee，所有场景共用
+func build(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping end-to-end test in short mode")
+	}
+	buildOnce.Do(func() {
+		var err error
+		if buildDir, err = os.MkdirTemp("", "lychee-e2e"); err != nil {
+			buildErr = err
+			return
+		}
+		binary = filepath.Join(buildDir, "lychee")
+		out, err := exec.Command("go", "build", "-o", binary, "hashcowuwu/lychee/cmd/app").CombinedOutput()
+		if err != nil {
+			buildErr = fmt.Errorf("go build: %v\n%s", err, out)
+		}
+	})
+	if buildErr != nil {
+		t.Fatal(buildErr)
+	}
+	return binary
+}
+
+// notification 是飞书 Webhook 收到的一条消息
+type notification struct {
+	Title string
+	Text  string
+}
+
+// larkServer 模拟飞书自定义机器人，把收到的消息按顺序放入 received
+type larkServer struct {
+	*httptest.Server
+	received chan notification
+}
+
+func newLarkServer(t *testing.T) *larkServer {
+	s := &larkServer{received: make(chan notification, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Content struct {
+				Post struct {
+					ZhCn struct {
+						Title   string `json:"title"`
+						Content [][]struct {
+							Text string `json:"text"`
+						} `json:"content"`
+					} `json:"zh_cn"`
+				} `json:"post"`
+			} `json:"content"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("lark: invalid payload: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		post := payload.Content.Post.ZhCn
+		n := notification{Title: post.Title}
+		for _, line := range post.Content {
+			for _, elem := range line {
+				n.Text += elem.Text
+			}
+		}
+		s.received <- n
+		fmt.Fprint(w, `{"code":0,"msg":"success"}`)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// harness 是一个运行中的 lychee 及其模拟环境
+type harness struct {
+	t    *testing.T
+	dir  string // LYCHEE_FAKE_DIR: units/、journal 和 calls
+	lark *larkServer
+	log  *bytes.Buffer
+	mu   *sync.Mutex // 保护 log
+	cmd  *exec.Cmd
+	seq  int // 已写入 journal 的行数，也是最后一条日志的 cursor
+}
+
+// setup 准备模拟环境但不启动 lychee，以便场景先设置初始状态
+func setup(t *testing.T) *harness {
+	t.Helper()
+	h := &harness{t: t, dir: t.TempDir(), lark: newLarkServer(t), log: new(bytes.Buffer), mu: new(sync.Mutex)}
+	if err := os.Mkdir(filepath.Join(h.dir, "units"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// start 用 baseConfig 加上场景的配置启动 lychee
+func (h *harness) start(config string) {
+	t := h.t
+	t.Helper()
+	bin := build(t)
+	config = strings.NewReplacer("$STATE_DIR", filepath.Join(h.dir, "state"), "$LARK_URL", h.lark.URL).Replace(baseConfig) + config
+	path := filepath.Join(h.dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeBin, err := filepath.Abs("testdata/bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.cmd = exec.Command(bin, "-config", path)
+	h.cmd.Env = append(os.Environ(), "PATH="+fakeBin+string(os.PathListSeparator)+os.Getenv("PATH"), "LYCHEE_FAKE_DIR="+h.dir)
+	h.cmd.Stdout = &lockedWriter{mu: h.mu, w: h.log}
+	h.cmd.Stderr = h.cmd.Stdout
+	// 放在单独的进程组中，结束时连同 journalctl -f 等子进程一起终止
+	h.cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := h.cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		syscall.Kill(-h.cmd.Process.Pid, syscall.SIGKILL)
+		h.cmd.Wait()
+		if t.Failed() {
+			h.mu.Lock()
+			t.Logf("lychee output:\n%s", h.log)
+			h.mu.Unlock()
+		}
+	})
+}
+
+// setUnit 设置模拟单元的 ActiveState
+func (h *harness) setUnit(unit, state string) {
+	h.t.Helper()
+	if err := os.WriteFile(filepath.Join(h.dir, "units", unit), []byte(state+"\n"), 0o644); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// appendJournal 一次性写入多条日志，时间为当前时间
+func (h *harness) appendJournal(messages ...string) {
+	h.t.Helper()
+	var b bytes.Buffer
+	for _, msg := range messages {
+		h.seq++
+		line, _ := json.Marshal(map[string]string{
+			"__CURSOR":             fmt.Sprint(h.seq),
+			"__REALTIME_TIMESTAMP": fmt.Sprint(time.Now().UnixMicro()),
+			"MESSAGE":              msg,
+			"_SYSTEMD_UNIT":        "app.service",
+			"PRIORITY":             "3",
+		})
+		b.Write(append(line, '\n'))
+	}
+	f, err := os.OpenFile(filepath.Join(h.dir, "journal"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer f.Close()
+	// 一次写入，轮询的 journalctl 不会只读到一部分
+	if _, err := f.Write(b.Bytes()); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// calls 返回以 prefix 开头的模拟命令调用次数
+func (h *harness) calls(prefix string) int {
+	data, _ := os.ReadFile(filepath.Join(h.dir, "calls"))
+	n := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// waitCalls 等待以 prefix 开头的调用再增加 n 次，用来确认 lychee 完成了若干轮检查
+func (h *harness) waitCalls(prefix string, n int) {
+	h.t.Helper()
+	want := h.calls(prefix) + n
+	deadline := time.Now().Add(timeout)
+	for h.calls(prefix) < want {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out waiting for %d more %q calls", n, prefix)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// expect 按顺序检查接下来收到的通知
+func (h *harness) expect(want ...notification) {
+	h.t.Helper()
+	for i, w := range want {
+		select {
+		case got := <-h.lark.received:
+			if got != w {
+				h.t.Fatalf("notification %d:\ngot  %q\n     %q\nwant %q\n     %q", i, got.Title, got.Text, w.Title, w.Text)
+			}
+		case <-time.After(timeout):
+			h.t.Fatalf("timed out waiting for notification %d: %q", i, w.Text)
+		}
+	}
+}
+
+// expectNone 检查 d 时间内没有收到通知
+func (h *harness) expectNone(d time.Duration) {
+	h.t.Helper()
+	select {
+	case got := <-h.lark.received:
+		h.t.Fatalf("unexpected notification: %q %q", got.Title, got.Text)
+	case <-time.After(d):
+	}
+}
+
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  *bytes.Buffer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+func alert(lines ...string) notification {
+	return notification{Title: subject, Text: strings.Join(lines, "\n")}
+}
+
+const servicesConfig = `
+systemd:
+  services: ["app.service"]
+`
+
+const inactive = "[critical] systemd-service(app.service): service app.service is not active or does not exist."
+
+func TestUnitDies(t *testing.T) {
+	h := setup(t)
+	h.setUnit("app.service", "active")
+	h.start(servicesConfig)
+
+	h.waitCalls("systemctl is-active", 2)
+	h.expectNone(time.Second)
+
+	h.setUnit("app.service", "failed")
+	h.expect(alert(inactive))
+	// 持续的告警在 repeatInterval 之前不会重复发送
+	h.waitCalls("systemctl is-active", 2)
+	h.expectNone(time.Second)
+}
+
+func TestUnitRecovers(t *testing.T) {
+	h := setup(t)
+	h.setUnit("app.service", "failed")
+	h.start(servicesConfig)
+	h.expect(alert(inactive))
+
+	h.setUnit("app.service", "active")
+	h.waitCalls("systemctl is-active", 2)
+	h.expectNone(time.Second)
+
+	// 恢复后再次故障是新的告警
+	h.setUnit("app.service", "inactive")
+	h.expect(alert(inactive))
+}
+
+func TestFailedUnits(t *testing.T) {
+	h := setup(t)
+	h.setUnit("app.service", "active")
+	h.setUnit("backup.service", "failed")
+	h.setUnit("scratch.mount", "failed")
+	h.start(`
+systemd:
+  failed:
+    enabled: true
+    exclude: ["*.mount"]
+`)
+	h.expect(alert("[critical] systemd-failed: 1 units are in failed state:\nbackup.service"))
+}
+
+const journalConfig = `
+journal:
+  - serviceName: "app.service"
+    keywords: ["ERROR"]
+    rules:
+      - name: "db-timeout"
+        pattern: "timeout"
+        threshold: 10
+        window: 1m
+`
+
+func TestLogBurst(t *testing.T) {
+	h := setup(t)
+	// 启动前的日志不会被回放
+	h.appendJournal("ERROR before start")
+	h.start(journalConfig)
+	h.waitCalls("journalctl -u app.service -o json --no-pager --after-cursor 1", 1)
+	h.expectNone(time.Second)
+
+	burst := []string{"ERROR disk full"}
+	for range 12 {
+		burst = append(burst, "timeout talking to db")
+	}
+	burst = append(burst, "ERROR disk still full", "all good")
+	h.appendJournal(burst...)
+
+	// 同一轮检查中的命中合并成一条告警，阈值规则只在越过阈值时告警一次
+	h.expect(alert(
+		"[critical] journal-app.service: service [app.service] journal matched rule 'ERROR': ERROR disk full",
+		"service [app.service] journal rule 'db-timeout' matched 10 times (window 1m0s, previous window 0), latest: timeout talking to db",
+		"service [app.service] journal matched rule 'ERROR': ERROR disk still full",
+	))
+	h.waitCalls(fmt.Sprintf("journalctl -u app.service -o json --no-pager --after-cursor %d", h.seq), 1)
+	h.expectNone(time.Second)
+
+	// 日志事件每次都是新告警
+	h.appendJournal("ERROR again")
+	h.expect(alert("[critical] journal-app.service: service [app.service] journal matched rule 'ERROR': ERROR again"))
+}
+
+func TestJournalStream(t *testing.T) {
+	h := setup(t)
+	h.appendJournal("started")
+	h.start(`
+journal:
+  - serviceName: "app.service"
+    stream: true
+    keywords: ["ERROR"]
+`)
+	// 从最后一条日志之后开始跟随，启动和跟随之间写入的日志也不会丢失
+	h.waitCalls("journalctl -u app.service -f -o json --no-pager --after-cursor 1", 1)
+	h.appendJournal("ERROR streamed")
+	h.expect(alert("[critical] journal-app.service: service [app.service] journal matched rule 'ERROR': ERROR streamed"))
+	h.expectNone(2 * time.Second)
+}